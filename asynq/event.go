@@ -3,17 +3,29 @@ package asynq
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ pa.EventService = (*EventService)(nil)
 
+// tracer spans the pushed and the handled events.
+var tracer = otel.Tracer("github.com/Lambels/patrickarvatu.com/asynq")
+
 type EventService struct {
-	worker *asynq.Server
-	mux    *asynq.ServeMux
-	client *asynq.Client
+	worker    *asynq.Server
+	mux       *asynq.ServeMux
+	client    *asynq.Client
+	inspector *asynq.Inspector // used to monitor the queue.
+	ctx       context.Context
+	cancel    func()
 
 	hand pa.SubscriptionService
 }
@@ -31,6 +43,8 @@ func NewEventService(redisDSN string) *EventService {
 		},
 		asynq.Config{
 			Concurrency: 10,
+			Logger:      logger{},
+			LogLevel:    asynq.DebugLevel, // filtered by the default logger.
 		},
 	)
 
@@ -44,41 +58,146 @@ func NewEventService(redisDSN string) *EventService {
 
 	s.client = client
 
+	s.inspector = asynq.NewInspector(
+		asynq.RedisClientOpt{
+			Addr: redisDSN,
+		},
+	)
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
 	return s
 }
 
 func (e *EventService) Open() error {
+	go e.monitor()
+
 	return e.worker.Start(e.mux)
 }
 
 func (e *EventService) Close() error {
+	e.cancel() // stop monitoring.
 	e.worker.Shutdown()
+
+	if err := e.inspector.Close(); err != nil {
+		return err
+	}
 	return e.client.Close()
 }
 
-func (e *EventService) Push(ctx context.Context, event pa.Event) error {
-	if jsonPayload, err := json.Marshal(event.Payload); err == nil {
-		_, err := e.client.EnqueueContext(ctx, asynq.NewTask(event.Topic, jsonPayload))
-		return err
-	} else {
+// taskPayload is the payload of the tasks, it carries the id of the request pushing the event so the
+// handlers log under it and the trace context of the push in the headers so the handlers continue its trace.
+type taskPayload struct {
+	RequestID string            `json:"requestID"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	PushedAt  time.Time         `json:"pushedAt"` // used to measure the queue latency.
+	Payload   json.RawMessage   `json:"payload"`
+}
+
+func (e *EventService) Push(ctx context.Context, event pa.Event) (err error) {
+	ctx, span := tracer.Start(ctx, event.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("asynq"),
+			semconv.MessagingDestinationName(event.Topic),
+			semconv.MessagingOperationPublish,
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
+	task, err := newTask(ctx, event)
+	if err != nil {
 		return err
 	}
+
+	_, err = e.client.EnqueueContext(ctx, task)
+	return err
+}
+
+// newTask returns the task of event pushed under ctx.
+func newTask(ctx context.Context, event pa.Event) (*asynq.Task, error) {
+	jsonPayload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	task := taskPayload{
+		RequestID: pa.RequestIDFromContext(ctx),
+		Headers:   make(map[string]string),
+		PushedAt:  time.Now().UTC(),
+		Payload:   jsonPayload,
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(task.Headers))
+
+	jsonTask, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(event.Topic, jsonTask), nil
+}
+
+// parseTaskPayload parses the payload of a task, tasks queued before the payloads carried the
+// request id hold the event payload only.
+func parseTaskPayload(data []byte) taskPayload {
+	var task taskPayload
+	if err := json.Unmarshal(data, &task); err != nil || task.Payload == nil {
+		return taskPayload{Payload: data}
+	}
+	return task
 }
 
 // Register all handlers before opening the server
 func (e *EventService) RegisterHandler(topic string, handler pa.EventHandler) {
-	e.mux.HandleFunc(topic, func(ctx context.Context, t *asynq.Task) error {
+	e.mux.HandleFunc(topic, func(ctx context.Context, t *asynq.Task) (err error) {
+		task := parseTaskPayload(t.Payload())
+
+		if task.RequestID != "" {
+			ctx = pa.NewContextWithRequestID(ctx, task.RequestID)
+		}
+
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(task.Headers))
+		ctx, span := tracer.Start(ctx, topic+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				semconv.MessagingSystem("asynq"),
+				semconv.MessagingDestinationName(topic),
+				semconv.MessagingOperationProcess,
+			),
+		)
+		defer func() { endSpan(span, err) }()
+
+		// tasks queued before the payloads carried the push time arent measured.
+		start := time.Now()
+		if !task.PushedAt.IsZero() {
+			queueLatency.WithLabelValues(topic).Observe(start.Sub(task.PushedAt).Seconds())
+		}
+		defer func() {
+			result := "success"
+			if err != nil {
+				result = "failure"
+			}
+			processingDuration.WithLabelValues(topic, result).Observe(time.Since(start).Seconds())
+		}()
+
 		return handler(
 			ctx,
 			e.hand,
 			pa.Event{
 				Topic:   topic,
-				Payload: t.Payload(),
+				Payload: []byte(task.Payload),
 			},
 		)
 	})
 }
 
+// endSpan ends span, marking it as failed by err.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 func (e *EventService) RegisterSubscriptionsHandler(hand pa.SubscriptionService) {
 	e.hand = hand
 }
@@ -1,12 +1,22 @@
 package asynq_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/Lambels/patrickarvatu.com/asynq"
+	hasynq "github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestEventService(t *testing.T) {
@@ -46,3 +56,129 @@ func TestEventService(t *testing.T) {
 		}
 	})
 }
+
+func TestProcessTask(t *testing.T) {
+	// the tasks are handled without going through the queue, the service never connects to redis.
+	s := asynq.NewEventService("127.0.0.1:6379")
+	defer s.Close()
+
+	var handled []pa.Event
+	s.RegisterHandler(pa.EventTopicNewSubBlog, func(ctx context.Context, _ pa.SubscriptionService, event pa.Event) error {
+		pa.LoggerFromContext(ctx).Info("event handled.")
+		handled = append(handled, event)
+		return nil
+	})
+
+	t.Run("Ok Process Call (Request ID)", func(t *testing.T) {
+		handled = nil
+
+		// the request id of the pushing request reaches the logger of the handler.
+		ctx := pa.NewContextWithRequestID(context.Background(), "req-123")
+		task, err := asynq.NewTask(ctx, pa.Event{
+			Topic:   pa.EventTopicNewSubBlog,
+			Payload: pa.SubBlogPayload{SubBlog: &pa.SubBlog{ID: 123}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := s.ProcessTask(NewContextWithBufferLogger(&buf), task); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(buf.String(), "requestID=req-123") {
+			t.Fatalf("log=%q", buf.String())
+		}
+		MustHandleSubBlogID(t, handled, 123)
+	})
+
+	t.Run("Ok Process Call (Trace)", func(t *testing.T) {
+		handled = nil
+		ended := MustRecordSpans(t)
+		n := len(ended())
+
+		// the consumer span continues the trace of the push.
+		ctx, span := otel.Tracer("test").Start(context.Background(), "push")
+		task, err := asynq.NewTask(ctx, pa.Event{
+			Topic:   pa.EventTopicNewSubBlog,
+			Payload: pa.SubBlogPayload{SubBlog: &pa.SubBlog{ID: 123}},
+		})
+		span.End()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.ProcessTask(NewContextWithBufferLogger(&bytes.Buffer{}), task); err != nil {
+			t.Fatal(err)
+		}
+		MustHandleSubBlogID(t, handled, 123)
+
+		spans := ended()[n:]
+		if len(spans) != 2 {
+			t.Fatalf("spans=%v", len(spans))
+		}
+		consumer := spans[1]
+		if consumer.Name() != pa.EventTopicNewSubBlog+" process" || consumer.SpanKind() != trace.SpanKindConsumer {
+			t.Fatalf("name=%v kind=%v", consumer.Name(), consumer.SpanKind())
+		} else if consumer.SpanContext().TraceID() != span.SpanContext().TraceID() {
+			t.Fatalf("trace id=%v want=%v", consumer.SpanContext().TraceID(), span.SpanContext().TraceID())
+		} else if consumer.Parent().SpanID() != span.SpanContext().SpanID() {
+			t.Fatalf("parent id=%v", consumer.Parent().SpanID())
+		}
+	})
+
+	t.Run("Ok Process Call (Legacy Payload)", func(t *testing.T) {
+		handled = nil
+
+		// tasks queued before the payloads carried the request id hold the event payload only.
+		var buf bytes.Buffer
+		task := hasynq.NewTask(pa.EventTopicNewSubBlog, []byte(`{"subBlog":{"id":123}}`))
+		if err := s.ProcessTask(NewContextWithBufferLogger(&buf), task); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(buf.String(), "requestID=") {
+			t.Fatalf("log=%q", buf.String())
+		}
+		MustHandleSubBlogID(t, handled, 123)
+	})
+}
+
+var (
+	spanRecorder     = tracetest.NewSpanRecorder()
+	spanRecorderOnce sync.Once
+)
+
+// MustRecordSpans records the spans of the package, the tracers delegate to the first global tracer
+// provider only so the provider is set once for all the tests.
+func MustRecordSpans(tb testing.TB) func() []sdktrace.ReadOnlySpan {
+	tb.Helper()
+
+	spanRecorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return spanRecorder.Ended
+}
+
+// NewContextWithBufferLogger returns a context logging to buf.
+func NewContextWithBufferLogger(buf *bytes.Buffer) context.Context {
+	return pa.NewContextWithLogger(context.Background(), slog.New(slog.NewTextHandler(buf, nil)))
+}
+
+// MustHandleSubBlogID asserts that a single sub blog event with the sub blog id: id got handled.
+func MustHandleSubBlogID(tb testing.TB, handled []pa.Event, id int) {
+	tb.Helper()
+
+	if len(handled) != 1 {
+		tb.Fatalf("handled=%v", len(handled))
+	}
+
+	var payload pa.SubBlogPayload
+	if err := json.Unmarshal(handled[0].Payload.([]byte), &payload); err != nil {
+		tb.Fatal(err)
+	} else if payload.SubBlog == nil || payload.SubBlog.ID != id {
+		tb.Fatalf("payload=%s", handled[0].Payload)
+	}
+}
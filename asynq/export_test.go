@@ -0,0 +1,16 @@
+package asynq
+
+import (
+	"context"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/hibiken/asynq"
+)
+
+// NewTask returns the task pushed for event under ctx.
+func NewTask(ctx context.Context, event pa.Event) (*asynq.Task, error) { return newTask(ctx, event) }
+
+// ProcessTask runs the handler registered for the topic of t, without going through the queue.
+func (e *EventService) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	return e.mux.ProcessTask(ctx, t)
+}
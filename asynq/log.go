@@ -0,0 +1,29 @@
+package asynq
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// logger logs the asynq worker through the default logger, the level of the default logger decides
+// what gets logged.
+type logger struct{}
+
+func (logger) Debug(args ...interface{}) {
+	slog.Default().Debug(fmt.Sprint(args...), "component", "asynq")
+}
+func (logger) Info(args ...interface{}) {
+	slog.Default().Info(fmt.Sprint(args...), "component", "asynq")
+}
+func (logger) Warn(args ...interface{}) {
+	slog.Default().Warn(fmt.Sprint(args...), "component", "asynq")
+}
+func (logger) Error(args ...interface{}) {
+	slog.Default().Error(fmt.Sprint(args...), "component", "asynq")
+}
+
+func (logger) Fatal(args ...interface{}) {
+	slog.Default().Error(fmt.Sprint(args...), "component", "asynq")
+	os.Exit(1)
+}
@@ -0,0 +1,83 @@
+package asynq
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	pendingEventsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "events_pending",
+		Help: "number of events waiting in the queue by topic",
+	}, []string{"topic"})
+
+	queueLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "events_queue_latency_seconds",
+		Help:    "time the events waited in the queue before being handled by topic",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	processingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "events_processing_duration_seconds",
+		Help:    "duration of the event handlers by topic and result",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic", "result"})
+)
+
+// queue is the queue of the events.
+const queue = "default"
+
+func (e *EventService) monitor() {
+	ticker := time.NewTicker(time.Second * 10)
+	defer ticker.Stop()
+
+	// topics seen pending before, reset to 0 once drained.
+	seen := make(map[string]struct{})
+
+	for {
+		select {
+		case <-e.ctx.Done(): // kill gorutine / stop monitoring when context is canceled
+			return
+
+		case <-ticker.C: // each tick represents one monitoring rutine
+		}
+
+		if err := e.update(seen); err != nil {
+			slog.Error("update stats failed.", "err", err, "component", "asynq")
+		}
+	}
+}
+
+// update counts the pending events of each topic.
+func (e *EventService) update(seen map[string]struct{}) error {
+	pending := make(map[string]int)
+	for page := 1; ; page++ {
+		tasks, err := e.inspector.ListPendingTasks(queue, asynq.PageSize(100), asynq.Page(page))
+		if errors.Is(err, asynq.ErrQueueNotFound) { // nothing pushed yet.
+			break
+		} else if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			pending[task.Type]++
+		}
+		if len(tasks) < 100 {
+			break
+		}
+	}
+
+	for topic := range pending {
+		seen[topic] = struct{}{}
+	}
+	for topic := range seen {
+		pendingEventsGauge.WithLabelValues(topic).Set(float64(pending[topic]))
+	}
+
+	return nil
+}
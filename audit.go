@@ -0,0 +1,145 @@
+package pa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// types of the audited targets.
+const (
+	AuditTargetBlog     = "blog"
+	AuditTargetSubBlog  = "subBlog"
+	AuditTargetComment  = "comment"
+	AuditTargetProject  = "project"
+	AuditTargetMedia    = "media"
+	AuditTargetUser     = "user"
+	AuditTargetTrash    = "trash"
+	AuditTargetSpamRule = "spamRule"
+	AuditTargetBan      = "ban"
+	AuditTargetBackup   = "backup"
+)
+
+// audited actions.
+const (
+	AuditActionCreate    = "create"
+	AuditActionUpdate    = "update"
+	AuditActionDelete    = "delete"
+	AuditActionReorder   = "reorder"
+	AuditActionRef       = "ref"
+	AuditActionUnref     = "unref"
+	AuditActionRestore   = "restore"
+	AuditActionPurge     = "purge"
+	AuditActionAnonymize = "anonymize"
+)
+
+// AuditEntry represents a recorded privileged or destructive action. Entries are recorded by the services
+// along the change they describe and cant be changed.
+type AuditEntry struct {
+	ID int `json:"id"`
+
+	// the user who acted, 0 for internal actions (ie: cron jobs).
+	ActorID int `json:"actorID"`
+
+	Action     string `json:"action"`
+	TargetType string `json:"targetType"`
+	TargetID   int    `json:"targetID"`
+
+	// the fields of the target changed by the action, see NewAuditDiff.
+	Diff json.RawMessage `json:"diff"`
+
+	// origin of the action, empty for internal actions.
+	Client
+
+	// timestamp.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Client represents the origin of a request.
+type Client struct {
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+}
+
+// AuditFilter represents a filter used by FindAuditEntries to filter the response.
+type AuditFilter struct {
+	// fields to filter on.
+	ActorID    *int    `json:"actorID"`
+	Action     *string `json:"action"`
+	TargetType *string `json:"targetType"`
+	TargetID   *int    `json:"targetID"`
+
+	// only the entries recorded before the entry with id: BeforeID, used to page through the log
+	// without the offset shifting under the entries recorded meanwhile.
+	BeforeID *int `json:"beforeID"`
+
+	// ordering and creation time range, entries can be sorted by "id" and "createdAt", latest
+	// first by default.
+	QuerySpec
+
+	// restrictions on the result set, used for pagination and set limits.
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+}
+
+// AuditService represents a service which reads the audit log.
+type AuditService interface {
+	// FindAuditEntries returns a range of audit entries and the length of the range.
+	// returns EINVALID if the query spec of the filter is invalid.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	FindAuditEntries(ctx context.Context, filter AuditFilter) ([]*AuditEntry, int, error)
+}
+
+// auditChange represents the before and after state of a changed field.
+type auditChange struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// NewAuditDiff returns the JSON fields which differ between before and after, keyed by field name:
+// {"title": {"before": "old", "after": "new"}}. before is nil for created targets and after is nil
+// for deleted targets.
+func NewAuditDiff(before, after interface{}) (json.RawMessage, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]auditChange)
+	for name, v := range b {
+		if !bytes.Equal(v, a[name]) {
+			diff[name] = auditChange{Before: v, After: a[name]}
+		}
+	}
+	for name, v := range a {
+		if _, ok := b[name]; !ok {
+			diff[name] = auditChange{After: v}
+		}
+	}
+
+	return json.Marshal(diff)
+}
+
+// auditFields returns the encoded JSON fields of v.
+func auditFields(v interface{}) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if v == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,43 @@
+package pa
+
+import (
+	"context"
+	"time"
+)
+
+// BackupNameFormat is the time layout of backup names, backups are named after their creation time
+// so they sort chronologically, ie: "20220101T150405Z.db".
+const BackupNameFormat = "20060102T150405Z.db"
+
+// Backup represents a point in time snapshot of the database.
+type Backup struct {
+	// the name of the snapshot in the backup file service, ie: "20220101T150405Z.db".
+	Name string `json:"name"`
+
+	Size int64 `json:"size"`
+
+	// timestamp.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BackupRetention decides which backups get pruned after a new backup is created.
+// the latest backup is never pruned.
+type BackupRetention struct {
+	// KeepLast is the number of most recent backups to keep, 0 keeps all of them.
+	KeepLast int
+
+	// MaxAge prunes backups older than it, 0 keeps backups regardless of their age.
+	MaxAge time.Duration
+}
+
+// BackupService represents a service which snapshots the database.
+type BackupService interface {
+	// FindBackups returns the stored backups, latest first.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	FindBackups(ctx context.Context) ([]*Backup, error)
+
+	// CreateBackup snapshots the database while it is in use and stores the snapshot,
+	// backups falling out of the retention get deleted.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	CreateBackup(ctx context.Context) (*Backup, error)
+}
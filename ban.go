@@ -0,0 +1,92 @@
+package pa
+
+import (
+	"context"
+	"time"
+)
+
+// ban scopes.
+const (
+	// BanScopeComment only stops the user from commenting.
+	BanScopeComment = "comment"
+
+	// BanScopeFull stops the user from authentificating at all.
+	BanScopeFull = "full"
+)
+
+// Ban represents a ban placed by the admin on a user. Full bans revoke the sessions and the api key of
+// the user.
+type Ban struct {
+	// the pk of the ban.
+	ID int `json:"id"`
+
+	// the banned user.
+	UserID int `json:"userID"`
+
+	Reason string `json:"reason"`
+
+	// the scope of the ban, ie: BanScopeFull -> ./ban.go.
+	Scope string `json:"scope"`
+
+	// the ban is lifted at ExpiresAt, permanent if nil.
+	ExpiresAt *time.Time `json:"expiresAt"`
+
+	// timestamp.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate performs basic validation on the ban.
+// returns EINVALID if any error is found.
+func (b *Ban) Validate() error {
+	if b.UserID == 0 {
+		return Errorf(EINVALID, "userID is a required field.")
+	}
+	if b.Reason == "" {
+		return Errorf(EINVALID, "reason is a required field.")
+	}
+	if b.Scope != BanScopeComment && b.Scope != BanScopeFull {
+		return Errorf(EINVALID, "unknown ban scope: %s.", b.Scope)
+	}
+	if b.ExpiresAt != nil && !b.ExpiresAt.After(b.CreatedAt) {
+		return Errorf(EINVALID, "expiresAt must be in the future.")
+	}
+	return nil
+}
+
+// IsActive reports whether the ban is in place at t.
+func (b *Ban) IsActive(t time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(t)
+}
+
+// BanService represents a service which manages the bans in the system.
+type BanService interface {
+	// FindBans returns a range of bans, latest first, and the length of the range.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	FindBans(ctx context.Context, filter BanFilter) ([]*Ban, int, error)
+
+	// FindActiveBan returns the active ban of the user with the widest scope, used to enforce the bans.
+	// returns ENOTFOUND if the user isnt banned.
+	FindActiveBan(ctx context.Context, userID int) (*Ban, error)
+
+	// CreateBan bans a user, full bans also revoke the sessions and the api key of the user.
+	// returns ENOTFOUND if the user doesent exist.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	CreateBan(ctx context.Context, ban *Ban) error
+
+	// DeleteBan lifts a ban.
+	// returns ENOTFOUND if the ban doesent exist.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	DeleteBan(ctx context.Context, id int) error
+}
+
+// BanFilter represents a filter used by FindBans to filter the response.
+type BanFilter struct {
+	// fields to filter on.
+	UserID *int  `json:"userID"`
+	Active *bool `json:"active"`
+
+	// restrictions on the result set, used for pagination and set limits.
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+}
@@ -12,10 +12,14 @@ type Blog struct {
 	// the pk of the blog.
 	ID int `json:"id"`
 
+	// the human-readable identifier of the blog, generated from the title.
+	Slug string `json:"slug"`
+
 	// the descriptive fields of the blog.
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
-	SubBlogs    []*SubBlog `json:"subBlogs"` // the list of sub blogs contained by the blog.
+	SubBlogs    []*SubBlog `json:"subBlogs"` // the list of sub blogs contained by the blog ordered by position.
+	Image       *Media     `json:"image"`    // the image of the blog from the media library.
 
 	// timestamps.
 	CreatedAt time.Time `json:"createdAt"`
@@ -33,24 +37,40 @@ func (b *Blog) Validate() error {
 
 // BlogService represents a service which manages blogs in the system.
 type BlogService interface {
-	// FindBlogByID returns a blog based on the id.
+	// FindBlogByID returns a blog based on the id with its whole series of sub blogs and their comments.
 	// returns ENOTFOUND if the blog doesent exist.
 	FindBlogByID(ctx context.Context, id int) (*Blog, error)
 
+	// FindBlogBySlug returns a blog based on the slug. Slugs which the blog had before being
+	// renamed still resolve to the blog, the current slug can be found under Blog.Slug.
+	// returns ENOTFOUND if the blog doesent exist.
+	FindBlogBySlug(ctx context.Context, slug string) (*Blog, error)
+
 	// FindBlogs returns a range of blogs and the length of the range. If filter
 	// is specified FindBlogs will apply the filter to return set response.
+	// The sub blogs and comments are only loaded if included by the filter.
+	// returns EINVALID if the query spec of the filter is invalid.
 	FindBlogs(ctx context.Context, filter BlogFilter) ([]*Blog, int, error)
 
-	// CreateBlog creates a blog.
+	// CreateBlog creates a blog and generates a unique slug from its title.
 	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	CreateBlog(ctx context.Context, blog *Blog) error
 
-	// UpdateBlog updates a blog based on the update field.
+	// UpdateBlog updates a blog based on the update field. A title change generates a new slug,
+	// the old slug is kept in the slug history.
 	// returns ENOTFOUND if blog doesent exist.
 	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	UpdateBlog(ctx context.Context, id int, update BlogUpdate) (*Blog, error)
 
-	// DeleteBlog permanently deletes a blog.
+	// ReorderSubBlogs sets the order of the sub blogs in the blog series, subBlogIDs must hold
+	// every sub blog of the blog exactly once.
+	// returns ENOTFOUND if blog doesent exist.
+	// returns EINVALID if subBlogIDs doesent match the sub blogs of the blog.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
+	ReorderSubBlogs(ctx context.Context, id int, subBlogIDs []int) (*Blog, error)
+
+	// DeleteBlog moves a blog to the trash, its sub blogs and comments are hidden along it until
+	// the blog is restored or purged (see TrashService).
 	// returns ENOTFOUND if blog doesent exist.
 	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	DeleteBlog(ctx context.Context, id int) error
@@ -59,12 +79,22 @@ type BlogService interface {
 // BlogFilter represents a filter used by FindBlogs to filter the response.
 type BlogFilter struct {
 	// fields to filter on.
-	ID    *int    `json:"id"`
-	Title *string `json:"title"`
+	ID            *int    `json:"id"`
+	Title         *string `json:"title"` // matched as TitleContains.
+	TitleContains *string `json:"titleContains"`
+	Slug          *string `json:"slug"`
+
+	// ordering and creation time range, blogs can be sorted by "id", "title", "createdAt" and "updatedAt".
+	QuerySpec
+
+	// related objects to load along the blogs, only the image is loaded by default.
+	IncludeSubBlogs bool `json:"includeSubBlogs"`
+	IncludeComments bool `json:"includeComments"` // comments of the sub blogs, implies IncludeSubBlogs.
 
 	// restrictions on the result set, used for pagination and set limits.
-	Offset int `json:"offset"`
-	Limit  int `json:"limit"`
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
 }
 
 // BlogUpdate represents an update used by UpdateBlog to update a blog.
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Snapshot the sqlite database into the backup dir",
+	Args:  cobra.NoArgs,
+	RunE:  RunBackup,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stored backups, latest first",
+	Args:  cobra.NoArgs,
+	RunE:  RunBackupList,
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore NAME",
+	Short: "Replace the sqlite database with a backup, the server must be stopped",
+	Args:  cobra.ExactArgs(1),
+	RunE:  RunRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+	backupCmd.AddCommand(backupListCmd)
+}
+
+func RunBackup(cmd *cobra.Command, _ []string) error {
+	backupService, cleanUp, err := openBackupService()
+	if err != nil {
+		return err
+	}
+	defer cleanUp()
+
+	backup, err := backupService.CreateBackup(newAdminContext())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created %s (%d bytes)\n", backup.Name, backup.Size)
+	return nil
+}
+
+func RunBackupList(cmd *cobra.Command, _ []string) error {
+	backupService, cleanUp, err := openBackupService()
+	if err != nil {
+		return err
+	}
+	defer cleanUp()
+
+	backups, err := backupService.FindBackups(newAdminContext())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tCREATED AT")
+	for _, backup := range backups {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", backup.Name, backup.Size, backup.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func RunRestore(cmd *cobra.Command, args []string) error {
+	var cfg pa.Config
+
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return err
+	}
+
+	if cfg.Database.Driver != "" && cfg.Database.Driver != "sqlite" {
+		return fmt.Errorf("backups arent supported by the %q driver", cfg.Database.Driver)
+	}
+
+	fileService, err := newFileService(&cfg, cfg.Backup.Dir, "./backups", 0, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := sqlite.RestoreBackup(context.Background(), fileService, args[0], cfg.Database.SqliteDSN); err != nil {
+		return err
+	}
+
+	fmt.Printf("restored %s, the replaced database was kept as %s.pre-restore\n", args[0], cfg.Database.SqliteDSN)
+	return nil
+}
+
+// openBackupService opens the sqlite database as is and returns its backup service.
+func openBackupService() (pa.BackupService, func(), error) {
+	var cfg pa.Config
+
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.Database.Driver != "" && cfg.Database.Driver != "sqlite" {
+		return nil, nil, fmt.Errorf("backups arent supported by the %q driver", cfg.Database.Driver)
+	}
+
+	db := newSqliteDB(&cfg)
+	db.AutoMigrate = false
+	if err := db.Open(); err != nil {
+		return nil, nil, err
+	}
+
+	backupService, err := newBackupService(&cfg, db)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return backupService, func() {
+		db.Close()
+	}, nil
+}
+
+// newAdminContext returns a context carrying the admin user, used by the cli commands.
+func newAdminContext() context.Context {
+	return pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+}
@@ -1,26 +1,151 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/Lambels/patrickarvatu.com/asynq"
 	"github.com/Lambels/patrickarvatu.com/fs"
 	"github.com/Lambels/patrickarvatu.com/http"
+	"github.com/Lambels/patrickarvatu.com/inmem"
+	"github.com/Lambels/patrickarvatu.com/postgres"
+	"github.com/Lambels/patrickarvatu.com/redis"
+	"github.com/Lambels/patrickarvatu.com/s3"
 	"github.com/Lambels/patrickarvatu.com/smtp"
+	"github.com/Lambels/patrickarvatu.com/spam"
 	"github.com/Lambels/patrickarvatu.com/sqlite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 )
 
-func newDB(cfg *pa.Config) (*sqlite.DB, func(), error) {
+// dbServices holds the services implemented by the configured database driver.
+type dbServices struct {
+	auth         pa.AuthService
+	user         pa.UserService
+	blog         pa.BlogService
+	subBlog      pa.SubBlogService
+	comment      pa.CommentService
+	subscription pa.SubscriptionService
+	project      pa.ProjectService
+	media        pa.MediaService
+	trash        pa.TrashService
+	audit        pa.AuditService
+	spam         pa.SpamService
+	ban          pa.BanService
+
+	sqliteDB *sqlite.DB // nil with other drivers, used for backups.
+}
+
+// newSqliteDB returns a sqlite db tuned by the database config.
+func newSqliteDB(cfg *pa.Config) *sqlite.DB {
 	db := sqlite.NewDB(cfg.Database.SqliteDSN)
+	if cfg.Database.SqliteMaxReadConns > 0 {
+		db.MaxReadConns = cfg.Database.SqliteMaxReadConns
+	}
+	if cfg.Database.SqliteBusyTimeout > 0 {
+		db.BusyTimeout = cfg.Database.SqliteBusyTimeout
+	}
 
-	if err := db.Open(); err != nil {
-		return nil, nil, err
+	return db
+}
+
+func newDB(cfg *pa.Config) (*dbServices, func(), error) {
+	switch cfg.Database.Driver {
+	case "", "sqlite":
+		db := newSqliteDB(cfg)
+
+		if err := db.Open(); err != nil {
+			return nil, nil, err
+		}
+
+		return &dbServices{
+			auth:         sqlite.NewAuthService(db),
+			user:         sqlite.NewUserService(db),
+			blog:         sqlite.NewBlogService(db),
+			subBlog:      sqlite.NewSubBlogService(db),
+			comment:      sqlite.NewCommentService(db),
+			subscription: sqlite.NewSubscriptionService(db),
+			project:      sqlite.NewProjectService(db),
+			media:        sqlite.NewMediaService(db),
+			trash:        sqlite.NewTrashService(db),
+			audit:        sqlite.NewAuditService(db),
+			spam:         sqlite.NewSpamService(db),
+			ban:          sqlite.NewBanService(db),
+			sqliteDB:     db,
+		}, func() {
+			db.Close()
+		}, nil
+
+	case "postgres":
+		db := postgres.NewDB(cfg.Database.PostgresDSN)
+
+		if err := db.Open(); err != nil {
+			return nil, nil, err
+		}
+
+		return &dbServices{
+			auth:         postgres.NewAuthService(db),
+			user:         postgres.NewUserService(db),
+			blog:         postgres.NewBlogService(db),
+			subBlog:      postgres.NewSubBlogService(db),
+			comment:      postgres.NewCommentService(db),
+			subscription: postgres.NewSubscriptionService(db),
+			project:      postgres.NewProjectService(db),
+			media:        postgres.NewMediaService(db),
+			trash:        postgres.NewTrashService(db),
+			audit:        postgres.NewAuditService(db),
+			spam:         postgres.NewSpamService(db),
+			ban:          postgres.NewBanService(db),
+		}, func() {
+			db.Close()
+		}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver: %q", cfg.Database.Driver)
 	}
+}
 
-	return db, func() {
-		db.Close()
-	}, nil
+// newMigrationService opens the configured database without migrating it.
+func newMigrationService(cfg *pa.Config) (pa.MigrationService, func(), error) {
+	switch cfg.Database.Driver {
+	case "", "sqlite":
+		db := newSqliteDB(cfg)
+		db.AutoMigrate = false
+
+		if err := db.Open(); err != nil {
+			return nil, nil, err
+		}
+
+		return db, func() {
+			db.Close()
+		}, nil
+
+	case "postgres":
+		db := postgres.NewDB(cfg.Database.PostgresDSN)
+		db.AutoMigrate = false
+
+		if err := db.Open(); err != nil {
+			return nil, nil, err
+		}
+
+		return db, func() {
+			db.Close()
+		}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver: %q", cfg.Database.Driver)
+	}
 }
 
 func newEventService(cfg *pa.Config) (*asynq.EventService, func(), error) {
@@ -31,12 +156,249 @@ func newEventService(cfg *pa.Config) (*asynq.EventService, func(), error) {
 	}, nil
 }
 
+// newRateLimiter returns the rate limiter of the configured driver.
+func newRateLimiter(cfg *pa.Config) (pa.RateLimiter, func(), error) {
+	switch cfg.RateLimit.Driver {
+	case "", "memory":
+		return inmem.NewRateLimiter(), func() {}, nil
+
+	case "redis":
+		rateLimiter := redis.NewRateLimiter(cfg.Database.RedisDSN)
+
+		return rateLimiter, func() {
+			rateLimiter.Close()
+		}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown rate limit driver: %q", cfg.RateLimit.Driver)
+	}
+}
+
+// newSpamFilter returns the spam filter run on the comments, configured by the spam config.
+func newSpamFilter(cfg *pa.Config, spamService pa.SpamService, commentService pa.CommentService) pa.SpamFilter {
+	spamCfg := cfg.Spam
+	if spamCfg.MaxLinks == 0 {
+		spamCfg.MaxLinks = spam.DefaultMaxLinks
+	}
+	if spamCfg.DuplicateWindow == 0 {
+		spamCfg.DuplicateWindow = spam.DefaultDuplicateWindow
+	}
+	if spamCfg.NewAccountAge == 0 {
+		spamCfg.NewAccountAge = spam.DefaultNewAccountAge
+	}
+
+	chain := spam.NewChain(
+		&spam.HoneypotChecker{Score: spam.DefaultRejectScore}, // only bots fill the honeypot.
+		&spam.LinkChecker{Max: spamCfg.MaxLinks, Score: 3},
+		&spam.RuleChecker{Service: spamService},
+		&spam.DuplicateChecker{Service: commentService, Window: spamCfg.DuplicateWindow, Score: 5},
+		&spam.NewAccountChecker{MinAge: spamCfg.NewAccountAge, Score: 2, LinkScore: 3},
+	)
+	if spamCfg.HoldScore > 0 {
+		chain.HoldScore = spamCfg.HoldScore
+	}
+	if spamCfg.RejectScore > 0 {
+		chain.RejectScore = spamCfg.RejectScore
+	}
+
+	return chain
+}
+
+// newLogger returns the logger configured by the log config.
+func newLogger(cfg *pa.Config) (*slog.Logger, error) {
+	var level slog.Level
+	switch strings.ToLower(cfg.Log.Level) {
+	case "", "info":
+		level = slog.LevelInfo
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return nil, fmt.Errorf("unknown log level: %s", cfg.Log.Level)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(cfg.Log.Format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", cfg.Log.Format)
+	}
+}
+
+// newTracerProvider installs the tracer provider of the configured exporter and the trace context propagator,
+// the returned func flushes the pending spans.
+func newTracerProvider(cfg *pa.Config) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Error("otel failed.", "err", err)
+	}))
+
+	var exporter sdktrace.SpanExporter
+	switch strings.ToLower(cfg.Trace.Exporter) {
+	case "", "none":
+		return func() {}, nil
+
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		exporter = exp
+
+	case "otlp":
+		endpoint := cfg.Trace.Endpoint
+		if endpoint == "" {
+			endpoint = "http://localhost:4318"
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("trace endpoint: %w", err)
+		}
+
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+		switch u.Scheme {
+		case "http":
+			opts = append(opts, otlptracehttp.WithInsecure())
+		case "https":
+		default:
+			return nil, fmt.Errorf("trace endpoint: unknown scheme: %q", u.Scheme)
+		}
+		if u.Path != "" && u.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+
+		exp, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, err
+		}
+		exporter = exp
+
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", cfg.Trace.Exporter)
+	}
+
+	serviceName := cfg.Trace.ServiceName
+	if serviceName == "" {
+		serviceName = "patrickarvatu.com"
+	}
+	ratio := cfg.Trace.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := tp.Shutdown(ctx); err != nil {
+			slog.Error("shutdown tracer provider failed.", "err", err)
+		}
+	}, nil
+}
+
 func newEmailService(cfg *pa.Config) pa.EmailService {
 	return smtp.NewEmailService(cfg.Smtp.Addr, cfg.Smtp.Identity, cfg.Smtp.Username, cfg.Smtp.Password, cfg.Smtp.Host)
 }
 
-func newFileService(root string) pa.FileService {
-	return fs.NewFileService(root)
+// newFileService returns the file service of the configured driver rooted at dir, an empty dir
+// falls back to defaultDir so the working directory never gets served.
+// created files are limited to maxSize bytes (0 means no limit) and the allowedMimeTypes.
+func newFileService(cfg *pa.Config, dir, defaultDir string, maxSize int64, allowedMimeTypes []string) (pa.FileService, error) {
+	if dir == "" {
+		dir = defaultDir
+	}
+
+	switch cfg.FileStructure.Driver {
+	case "", "fs":
+		fileService := fs.NewFileService(dir)
+		fileService.MaxSize = maxSize
+		fileService.AllowedMimeTypes = allowedMimeTypes
+
+		return fileService, nil
+
+	case "s3":
+		s3Cfg := cfg.FileStructure.S3
+		fileService, err := s3.NewFileService(s3Cfg.Endpoint, s3Cfg.Bucket)
+		if err != nil {
+			return nil, err
+		}
+
+		fileService.AccessKeyID = s3Cfg.AccessKeyID
+		fileService.SecretAccessKey = s3Cfg.SecretAccessKey
+		if s3Cfg.Region != "" {
+			fileService.Region = s3Cfg.Region
+		}
+		fileService.PathStyle = s3Cfg.PathStyle
+		fileService.Prefix = dir
+		fileService.MaxSize = maxSize
+		fileService.AllowedMimeTypes = allowedMimeTypes
+
+		return fileService, nil
+
+	default:
+		return nil, fmt.Errorf("unknown file structure driver: %q", cfg.FileStructure.Driver)
+	}
+}
+
+// newBackupService returns the backup service of db storing snapshots in the backup dir.
+// returns nil if db is nil, only the sqlite driver supports backups.
+func newBackupService(cfg *pa.Config, db *sqlite.DB) (pa.BackupService, error) {
+	if db == nil {
+		return nil, nil
+	}
+
+	// snapshots arent images, dont apply the file structure limits.
+	fileService, err := newFileService(cfg, cfg.Backup.Dir, "./backups", 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	backupService := sqlite.NewBackupService(db, fileService)
+	backupService.Retention = pa.BackupRetention{
+		KeepLast: cfg.Backup.KeepLast,
+		MaxAge:   cfg.Backup.MaxAge,
+	}
+
+	return backupService, nil
+}
+
+// importLegacyImages imports the images of the legacy blog and project image dirs into the media library.
+// the legacy dirs predate the file structure drivers and are always on the local disk.
+func importLegacyImages(cfg *pa.Config, s *http.Server) error {
+	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	for _, legacy := range []struct {
+		refType string
+		dir     string
+	}{
+		{pa.MediaRefBlog, cfg.FileStructure.BlogImagesDir},
+		{pa.MediaRefProject, cfg.FileStructure.ProjectImagesDir},
+	} {
+		if legacy.dir == "" {
+			continue
+		}
+
+		n, err := s.ImportLegacyImages(adminCtx, legacy.refType, fs.NewFileService(legacy.dir))
+		if err != nil {
+			return fmt.Errorf("import legacy %s images: %w", legacy.refType, err)
+		}
+		slog.Info("imported legacy images, the legacy image dirs can be removed from the config once imported.", "refType", legacy.refType, "dir", legacy.dir, "n", n)
+	}
+
+	return nil
 }
 
 func newServer(cfg *pa.Config,
@@ -49,8 +411,17 @@ func newServer(cfg *pa.Config,
 	subscriptionService pa.SubscriptionService,
 	emailService pa.EmailService,
 	projectService pa.ProjectService,
-	projectsFileSystem pa.FileService,
-	blogsFileSystem pa.FileService,
+	mediaService pa.MediaService,
+	mediaFileSystem pa.FileService,
+	imagesFileSystem pa.FileService,
+	exportsFileSystem pa.FileService,
+	backupService pa.BackupService,
+	trashService pa.TrashService,
+	auditService pa.AuditService,
+	rateLimiter pa.RateLimiter,
+	spamService pa.SpamService,
+	spamFilter pa.SpamFilter,
+	banService pa.BanService,
 ) (*http.Server, func(), error) {
 	s := http.NewServer(cfg)
 
@@ -63,12 +434,27 @@ func newServer(cfg *pa.Config,
 	s.SubscriptionService = subscriptionService
 	s.EmailService = emailService
 	s.ProjectService = projectService
-	s.ProjectsFileSystem = projectsFileSystem
-	s.BlogsFileSystem = blogsFileSystem
+	s.MediaService = mediaService
+	s.MediaFileSystem = mediaFileSystem
+	s.ImagesFileSystem = imagesFileSystem
+	s.ExportsFileSystem = exportsFileSystem
+	s.BackupService = backupService
+	s.TrashService = trashService
+	s.AuditService = auditService
+	s.RateLimiter = rateLimiter
+	s.SpamService = spamService
+	s.SpamFilter = spamFilter
+	s.BanService = banService
 
 	s.EventService.RegisterSubscriptionsHandler(s.SubscriptionService)
 	s.EventService.RegisterHandler(pa.EventTopicNewComment, s.HandleCommentEvent)
 	s.EventService.RegisterHandler(pa.EventTopicNewSubBlog, s.HandleSubBlogEvent)
+	s.EventService.RegisterHandler(pa.EventTopicUserExport, s.HandleUserExportEvent)
+	s.EventService.RegisterHandler(pa.EventTopicUserErase, s.HandleUserEraseEvent)
+
+	if err := importLegacyImages(cfg, s); err != nil {
+		return nil, nil, err
+	}
 
 	// open registered event service.
 	if err := eventService.Open(); err != nil {
@@ -88,60 +474,113 @@ func newServer(cfg *pa.Config,
 }
 
 func initializeServer(cfg *pa.Config) (*http.Server, func(), error) {
-	db, clnUpDB, err := newDB(cfg)
+	clnUpTracer, err := newTracerProvider(cfg)
 	if err != nil {
 		return nil, nil, err
 	}
-	log.Println("[DEBUG] Connected to db.")
+	slog.Debug("initialized tracer provider.")
+
+	dbSrv, clnUpDB, err := newDB(cfg)
+	if err != nil {
+		clnUpTracer()
+		return nil, nil, err
+	}
+	slog.Debug("connected to db.")
 
 	evSrv, clnUpEvSrv, err := newEventService(cfg)
 	if err != nil {
+		clnUpTracer()
 		clnUpDB()
 		return nil, nil, err
 	}
-	log.Println("[DEBUG] Initialized event service.")
+	slog.Debug("initialized event service.")
 
 	emSrv := newEmailService(cfg)
-	log.Println("[DEBUG] Initialized email service.")
+	slog.Debug("initialized email service.")
 
-	prFs := newFileService(cfg.FileStructure.ProjectImagesDir)
-	log.Println("[DEBUG] Initialized projects images file system.")
+	mdFs, err := newFileService(cfg, cfg.FileStructure.MediaDir, "./media", cfg.FileStructure.MaxFileSize, cfg.FileStructure.AllowedMimeTypes)
+	if err != nil {
+		clnUpTracer()
+		clnUpDB()
+		clnUpEvSrv()
+		return nil, nil, err
+	}
+	slog.Debug("initialized media file system.")
 
-	blFs := newFileService(cfg.FileStructure.BlogImagesDir)
-	log.Println("[DEBUG] Initialized blogs images file system.")
+	imFs, err := newFileService(cfg, cfg.FileStructure.ImagesDir, "./images", cfg.FileStructure.MaxFileSize, cfg.FileStructure.AllowedMimeTypes)
+	if err != nil {
+		clnUpTracer()
+		clnUpDB()
+		clnUpEvSrv()
+		return nil, nil, err
+	}
+	slog.Debug("initialized images file system.")
 
-	auSrv := sqlite.NewAuthService(db)
-	usSrv := sqlite.NewUserService(db)
-	blSrv := sqlite.NewBlogService(db)
-	sbSrv := sqlite.NewSubBlogService(db)
-	cmSrv := sqlite.NewCommentService(db)
-	subSrv := sqlite.NewSubscriptionService(db)
-	pjSrv := sqlite.NewProjectService(db)
-	log.Println("[DEBUG] Started database services.")
+	// exports arent uploads, dont apply the file structure limits.
+	exFs, err := newFileService(cfg, cfg.FileStructure.ExportsDir, "./exports", 0, nil)
+	if err != nil {
+		clnUpTracer()
+		clnUpDB()
+		clnUpEvSrv()
+		return nil, nil, err
+	}
+	slog.Debug("initialized exports file system.")
+
+	bkSrv, err := newBackupService(cfg, dbSrv.sqliteDB)
+	if err != nil {
+		clnUpTracer()
+		clnUpDB()
+		clnUpEvSrv()
+		return nil, nil, err
+	}
+	slog.Debug("initialized backup service.")
+
+	rlSrv, clnUpRlSrv, err := newRateLimiter(cfg)
+	if err != nil {
+		clnUpTracer()
+		clnUpDB()
+		clnUpEvSrv()
+		return nil, nil, err
+	}
+	slog.Debug("initialized rate limiter.")
 
 	serv, clnUpServ, err := newServer(
 		cfg,
-		auSrv,
-		usSrv,
-		blSrv,
-		sbSrv,
-		cmSrv,
+		dbSrv.auth,
+		dbSrv.user,
+		dbSrv.blog,
+		dbSrv.subBlog,
+		dbSrv.comment,
 		evSrv,
-		subSrv,
+		dbSrv.subscription,
 		emSrv,
-		pjSrv,
-		prFs,
-		blFs,
+		dbSrv.project,
+		dbSrv.media,
+		mdFs,
+		imFs,
+		exFs,
+		bkSrv,
+		dbSrv.trash,
+		dbSrv.audit,
+		rlSrv,
+		dbSrv.spam,
+		newSpamFilter(cfg, dbSrv.spam, dbSrv.comment),
+		dbSrv.ban,
 	)
 	if err != nil {
+		clnUpTracer()
 		clnUpDB()
 		clnUpEvSrv()
+		clnUpRlSrv()
+		return nil, nil, err
 	}
-	log.Println("[INFO] Started server on address", serv.Addr)
+	slog.Info("started server.", "addr", serv.Addr)
 
 	return serv, func() {
 		clnUpDB()
 		clnUpEvSrv()
+		clnUpRlSrv()
 		clnUpServ()
+		clnUpTracer() // flush the spans of the shutdown.
 	}, nil
 }
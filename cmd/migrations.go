@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// migrationsCmd represents the migrations command group
+var migrationsCmd = &cobra.Command{
+	Use:   "migrations",
+	Short: "Manage the database schema migrations",
+}
+
+var migrationsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List the migrations and whether they are applied",
+	Args:  cobra.NoArgs,
+	RunE:  RunMigrationsStatus,
+}
+
+var migrationsUpCmd = &cobra.Command{
+	Use:   "up [N]",
+	Short: "Apply the next N pending migrations, all of them by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  RunMigrationsUp,
+}
+
+var migrationsDownCmd = &cobra.Command{
+	Use:   "down N",
+	Short: "Revert the last N applied migrations",
+	Args:  cobra.ExactArgs(1),
+	RunE:  RunMigrationsDown,
+}
+
+func init() {
+	rootCmd.AddCommand(migrationsCmd)
+	migrationsCmd.AddCommand(migrationsStatusCmd, migrationsUpCmd, migrationsDownCmd)
+
+	migrationsUpCmd.Flags().Bool("dry-run", false, "list the migrations which would be applied without applying them.")
+	migrationsDownCmd.Flags().Bool("dry-run", false, "list the migrations which would be reverted without reverting them.")
+}
+
+func RunMigrationsStatus(cmd *cobra.Command, _ []string) error {
+	migrationService, cleanUp, err := openMigrationService()
+	if err != nil {
+		return err
+	}
+	defer cleanUp()
+
+	migrations, err := migrationService.Migrations(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tAPPLIED AT\tREVERSIBLE")
+	for _, migration := range migrations {
+		appliedAt := "-"
+		if !migration.AppliedAt.IsZero() {
+			appliedAt = migration.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", migration.Name, migrationStatus(migration), appliedAt, migration.Reversible)
+	}
+	return w.Flush()
+}
+
+func RunMigrationsUp(cmd *cobra.Command, args []string) error {
+	n := 0
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations: %q", args[0])
+		}
+	}
+
+	migrationService, cleanUp, err := openMigrationService()
+	if err != nil {
+		return err
+	}
+	defer cleanUp()
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	applied, err := migrationService.MigrateUp(context.Background(), n, dryRun)
+	if err != nil {
+		return err
+	}
+
+	printMigrations(applied, "apply", "applied", dryRun)
+	return nil
+}
+
+func RunMigrationsDown(cmd *cobra.Command, args []string) error {
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return fmt.Errorf("invalid number of migrations: %q", args[0])
+	}
+
+	migrationService, cleanUp, err := openMigrationService()
+	if err != nil {
+		return err
+	}
+	defer cleanUp()
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	reverted, err := migrationService.MigrateDown(context.Background(), n, dryRun)
+	if err != nil {
+		return err
+	}
+
+	printMigrations(reverted, "revert", "reverted", dryRun)
+	return nil
+}
+
+func openMigrationService() (pa.MigrationService, func(), error) {
+	var cfg pa.Config
+
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, nil, err
+	}
+
+	return newMigrationService(&cfg)
+}
+
+func printMigrations(migrations []*pa.Migration, verb, pastVerb string, dryRun bool) {
+	if len(migrations) == 0 {
+		fmt.Printf("nothing to %s.\n", verb)
+		return
+	}
+
+	for _, migration := range migrations {
+		if dryRun {
+			fmt.Printf("would %s %s\n", verb, migration.Name)
+		} else {
+			fmt.Printf("%s %s\n", pastVerb, migration.Name)
+		}
+	}
+}
+
+func migrationStatus(migration *pa.Migration) string {
+	switch {
+	case migration.Unknown:
+		return "unknown"
+	case migration.Modified:
+		return "modified"
+	case migration.Applied:
+		return "applied"
+	default:
+		return "pending"
+	}
+}
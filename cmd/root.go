@@ -1,9 +1,10 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
 
+	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 
@@ -19,7 +20,7 @@ var rootCmd = &cobra.Command{
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		log.Println("[Execute] err: ", err.Error())
+		slog.Error("Execute failed.", "err", err)
 		os.Exit(1)
 	}
 }
@@ -43,7 +44,7 @@ func initConfig() {
 		// find home directory.
 		home, err := homedir.Dir()
 		if err != nil {
-			log.Println("[homedir.Dir] err: ", err.Error())
+			slog.Error("homedir.Dir failed.", "err", err)
 			os.Exit(1)
 		}
 
@@ -55,7 +56,22 @@ func initConfig() {
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// if a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		log.Println("[INFO] Using config file:", viper.ConfigFileUsed())
+	configErr := viper.ReadInConfig()
+
+	// configure the default logger, the log package logs through it as well.
+	var cfg pa.Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		slog.Error("Unmarshal failed.", "err", err)
+		os.Exit(1)
+	}
+	logger, err := newLogger(&cfg)
+	if err != nil {
+		slog.Error("newLogger failed.", "err", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	if configErr == nil {
+		slog.Info("using config file.", "path", viper.ConfigFileUsed())
 	}
 }
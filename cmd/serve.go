@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -22,7 +22,7 @@ var serveCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(serveCmd)
 
-	serveCmd.Flags().BoolP("debug", "d", false, "starts a prom-http on port :8000 /metrics.")
+	serveCmd.Flags().BoolP("debug", "d", false, "starts a prom-http serving /metrics on the metrics addr (default :8000).")
 }
 
 func RunServe(cmd *cobra.Command, _ []string) error {
@@ -38,15 +38,24 @@ func RunServe(cmd *cobra.Command, _ []string) error {
 	}
 	defer cleanUp()
 
-	if debug, _ := cmd.Flags().GetBool("debug"); debug {
-		go http.RunDebugServer()
-		log.Println("[INFO] Started debug server on :8000 /metrics.")
+	if debug, _ := cmd.Flags().GetBool("debug"); debug || cfg.Metrics.Addr != "" {
+		addr := cfg.Metrics.Addr
+		if addr == "" {
+			addr = http.DefaultMetricsAddr
+		}
+
+		go func() {
+			if err := http.RunDebugServer(addr); err != nil {
+				slog.Error("RunDebugServer failed.", "err", err)
+			}
+		}()
+		slog.Info("started debug server.", "addr", addr, "path", "/metrics")
 	}
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	sig := <-c
-	log.Println("[INFO] Got signal", sig.String(), ", exiting.")
+	slog.Info("got signal, exiting.", "signal", sig.String())
 	return nil
 }
@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+// comment statuses, held comments wait for the admin user to approve them.
+const (
+	CommentStatusApproved = "approved"
+	CommentStatusHeld     = "held"
+)
+
 // Comment represents a comment in the system.
 type Comment struct {
 	// the pk of the comment.
@@ -18,6 +24,9 @@ type Comment struct {
 	// content of the comment.
 	Content string `json:"content"`
 
+	// moderation status of the comment, ie: CommentStatusHeld -> ./comment.go, default: CommentStatusApproved.
+	Status string `json:"status"`
+
 	// timestamp.
 	CreatedAt time.Time `json:"createdAt"`
 }
@@ -34,6 +43,9 @@ func (c *Comment) Validate() error {
 	if c.UserID == 0 {
 		return Errorf(EINVALID, "comment must be linked to a user.")
 	}
+	if c.Status != CommentStatusApproved && c.Status != CommentStatusHeld {
+		return Errorf(EINVALID, "unknown comment status: %s.", c.Status)
+	}
 
 	return nil
 }
@@ -46,9 +58,13 @@ type CommentService interface {
 
 	// FindComments returns a range of comments and the length of the range. If filter
 	// is specified FindComments will apply the filter to return set response.
+	// The users owning the comments are only loaded if included by the filter.
+	// Held comments are only returned to the admin user.
+	// returns EINVALID if the query spec of the filter is invalid.
+	// returns EUNAUTHORIZED if anyone other then the admin user filters on held comments.
 	FindComments(ctx context.Context, filter CommentFilter) ([]*Comment, int, error)
 
-	// CreateComment creates a comment.
+	// CreateComment creates a comment, approved unless its status says otherwise.
 	CreateComment(ctx context.Context, comment *Comment) error
 
 	// UpdateComment updates a comment based on the update field.
@@ -56,9 +72,10 @@ type CommentService interface {
 	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	UpdateComment(ctx context.Context, id int, update CommentUpdate) (*Comment, error)
 
-	// DeleteComment permanently deletes a comment.
+	// DeleteComment moves a comment to the trash until it is restored or purged (see TrashService).
 	// returns ENOTFOUND if comment doesent exist.
-	// returns EUNAUTHORIZED if used by anyone other then the user owning the comment.
+	// returns EUNAUTHORIZED if used by anyone other then the user owning the comment, the admin user
+	// can only delete held comments.
 	DeleteComment(ctx context.Context, id int) error
 }
 
@@ -69,13 +86,24 @@ type CommentFilter struct {
 	SubBlogID *int `json:"SubBlogID"`
 	UserID    *int `json:"userID"`
 
+	// moderation status, non admin users only get approved comments.
+	Status *string `json:"status"`
+
+	// ordering and creation time range, comments can be sorted by "id" and "createdAt".
+	QuerySpec
+
+	// related objects to load along the comments.
+	IncludeUser bool `json:"includeUser"`
+
 	// restrictions on the result set, used for pagination and set limits.
-	Offset int `json:"offset"`
-	Limit  int `json:"limit"`
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
 }
 
 // CommentUpdate represents an update used by UpdateComment to update a comment.
 type CommentUpdate struct {
 	// fields which can be updated.
 	Content *string `json:"content"`
+	Status  *string `json:"status"`
 }
@@ -1,5 +1,7 @@
 package pa
 
+import "time"
+
 // Config layouts the .toml config file structure its expecting.
 // uses mapstructure tags which is used by viper.
 type Config struct {
@@ -15,11 +17,58 @@ type Config struct {
 		BlockKey    string `mapstructure:"block-key"`
 		HashKey     string `mapstructure:"hash-key"`
 		FrontendURL string `mapstructure:"frontend-url"`
+
+		// addresses or networks of the reverse proxies in front of the server, the client ip of their
+		// requests is taken from the X-Forwarded-For header, ie: "10.0.0.0/8".
+		TrustedProxies []string `mapstructure:"trusted-proxies"`
+
+		// keys replaced by HashKey and BlockKey, still decoded until the cookies signed with them expire.
+		PreviousKeys []struct {
+			HashKey  string `mapstructure:"hash-key"`
+			BlockKey string `mapstructure:"block-key"`
+		} `mapstructure:"previous-keys"`
+
+		Cookie struct {
+			Domain     string        `mapstructure:"domain"`      // default: none, the cookie is only sent to the api host.
+			SameSite   string        `mapstructure:"same-site"`   // "lax" (default), "strict" or "none".
+			MaxAge     time.Duration `mapstructure:"max-age"`     // default: 0, the cookie lasts the browser session.
+			Secure     *bool         `mapstructure:"secure"`      // default: set when serving TLS.
+			NamePrefix string        `mapstructure:"name-prefix"` // ie: "__Host-".
+		} `mapstructure:"cookie"`
+
+		Headers struct {
+			HSTSMaxAge     time.Duration `mapstructure:"hsts-max-age"`    // default: 8760h, only sent when serving TLS.
+			ReferrerPolicy string        `mapstructure:"referrer-policy"` // default: "strict-origin-when-cross-origin".
+			FrameOptions   string        `mapstructure:"frame-options"`   // default: "DENY".
+			CSP            string        `mapstructure:"csp"`             // default: DefaultCSP -> ./http/security.go.
+		} `mapstructure:"headers"`
 	} `mapstructure:"http"`
 
+	Log struct {
+		Level  string `mapstructure:"level"`  // "debug", "info" (default), "warn" or "error".
+		Format string `mapstructure:"format"` // "text" (default) or "json".
+	} `mapstructure:"log"`
+
+	Trace struct {
+		Exporter    string  `mapstructure:"exporter"`     // "none" (default), "stdout" or "otlp".
+		Endpoint    string  `mapstructure:"endpoint"`     // url of the otlp http collector, default: "http://localhost:4318".
+		SampleRatio float64 `mapstructure:"sample-ratio"` // default: 1, fraction of the traces recorded.
+		ServiceName string  `mapstructure:"service-name"` // default: "patrickarvatu.com".
+	} `mapstructure:"trace"`
+
+	Metrics struct {
+		Addr string `mapstructure:"addr"` // address of the /metrics server, default: ":8000", setting it starts the server.
+	} `mapstructure:"metrics"`
+
 	Database struct {
-		SqliteDSN string `mapstructure:"sqlite-dsn"`
-		RedisDSN  string `mapstructure:"redis-dsn"`
+		Driver      string `mapstructure:"driver"` // "sqlite" (default) or "postgres".
+		SqliteDSN   string `mapstructure:"sqlite-dsn"`
+		PostgresDSN string `mapstructure:"postgres-dsn"`
+		RedisDSN    string `mapstructure:"redis-dsn"`
+
+		// sqlite connection pool tuning, zero values keep the defaults.
+		SqliteMaxReadConns int           `mapstructure:"sqlite-max-read-conns"`
+		SqliteBusyTimeout  time.Duration `mapstructure:"sqlite-busy-timeout"`
 	} `mapstructure:"database"`
 
 	Smtp struct {
@@ -31,7 +80,56 @@ type Config struct {
 	} `mapstructure:"smtp"`
 
 	FileStructure struct {
-		ProjectImagesDir string `mapstructure:"project-images-dir"`
+		Driver           string   `mapstructure:"driver"` // "fs" (default) or "s3".
+		MediaDir         string   `mapstructure:"media-dir"`
+		ImagesDir        string   `mapstructure:"images-dir"`
+		ExportsDir       string   `mapstructure:"exports-dir"` // default: ./exports, never served publicly.
+		MaxFileSize      int64    `mapstructure:"max-file-size"`
+		AllowedMimeTypes []string `mapstructure:"allowed-mime-types"`
+
+		// deprecated: the image dirs used before the media library, the images named after the id of
+		// their blog or project get imported into the media library on start.
 		BlogImagesDir    string `mapstructure:"blog-images-dir"`
+		ProjectImagesDir string `mapstructure:"project-images-dir"`
+
+		// bounds of the uploaded images, zero values keep imaging.DefaultLimits.
+		MaxImageWidth  int `mapstructure:"max-image-width"`
+		MaxImageHeight int `mapstructure:"max-image-height"`
+		MaxImagePixels int `mapstructure:"max-image-pixels"`
+
+		// S3 configures the s3 driver, the dirs are used as key prefixes in the bucket.
+		S3 struct {
+			Endpoint        string `mapstructure:"endpoint"`
+			Region          string `mapstructure:"region"`
+			Bucket          string `mapstructure:"bucket"`
+			AccessKeyID     string `mapstructure:"access-key-id"`
+			SecretAccessKey string `mapstructure:"secret-access-key"`
+			PathStyle       bool   `mapstructure:"path-style"`
+		} `mapstructure:"s3"`
 	} `mapstructure:"file-structure"`
+
+	Backup struct {
+		Dir      string        `mapstructure:"dir"`      // default: ./backups, key prefix with the s3 driver.
+		Schedule string        `mapstructure:"schedule"` // cron spec, ie: "@daily", empty disables scheduled backups.
+		KeepLast int           `mapstructure:"keep-last"`
+		MaxAge   time.Duration `mapstructure:"max-age"`
+	} `mapstructure:"backup"`
+
+	Trash struct {
+		Retention time.Duration `mapstructure:"retention"` // default: 720h, how long deleted objects stay in the trash.
+		Schedule  string        `mapstructure:"schedule"`  // cron spec of the purge job, default: "@daily".
+	} `mapstructure:"trash"`
+
+	Spam struct {
+		HoldScore       int           `mapstructure:"hold-score"`       // default: 5, comments scoring more are held for approval.
+		RejectScore     int           `mapstructure:"reject-score"`     // default: 10, comments scoring more are rejected.
+		MaxLinks        int           `mapstructure:"max-links"`        // default: 2, links allowed per comment.
+		DuplicateWindow time.Duration `mapstructure:"duplicate-window"` // default: 24h, how far back duplicates are looked for.
+		NewAccountAge   time.Duration `mapstructure:"new-account-age"`  // default: 24h, accounts younger are suspicious.
+	} `mapstructure:"spam"`
+
+	RateLimit struct {
+		Driver   string            `mapstructure:"driver"`   // "memory" (default) or "redis", the redis driver uses the redis dsn.
+		Policies []RateLimitPolicy `mapstructure:"policies"` // default: the http package default policies.
+	} `mapstructure:"rate-limit"`
 }
@@ -0,0 +1,1669 @@
+// Package conformance holds the test suite every storage backend of the pa services has to pass.
+// The suite only talks to the pa interfaces so backends stay interchangeable, backend specific
+// behaviour is tested in the package of the backend.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// Services represents the services of the storage backend under test.
+type Services struct {
+	AuthService         pa.AuthService
+	UserService         pa.UserService
+	BlogService         pa.BlogService
+	SubBlogService      pa.SubBlogService
+	CommentService      pa.CommentService
+	SubscriptionService pa.SubscriptionService
+	ProjectService      pa.ProjectService
+	MediaService        pa.MediaService
+	TrashService        pa.TrashService
+	AuditService        pa.AuditService
+	SpamService         pa.SpamService
+	BanService          pa.BanService
+}
+
+// OpenFunc opens an empty storage backend, closing the backend is registered with t.Cleanup.
+type OpenFunc func(t *testing.T) *Services
+
+// Run runs the suite against the backends opened by open, every test gets its own backend.
+func Run(t *testing.T, open OpenFunc) {
+	t.Run("UserService", func(t *testing.T) { testUserService(t, open) })
+	t.Run("AuthService", func(t *testing.T) { testAuthService(t, open) })
+	t.Run("BlogService", func(t *testing.T) { testBlogService(t, open) })
+	t.Run("SubBlogService", func(t *testing.T) { testSubBlogService(t, open) })
+	t.Run("CommentService", func(t *testing.T) { testCommentService(t, open) })
+	t.Run("SubscriptionService", func(t *testing.T) { testSubscriptionService(t, open) })
+	t.Run("ProjectService", func(t *testing.T) { testProjectService(t, open) })
+	t.Run("MediaService", func(t *testing.T) { testMediaService(t, open) })
+	t.Run("TrashService", func(t *testing.T) { testTrashService(t, open) })
+	t.Run("AuditService", func(t *testing.T) { testAuditService(t, open) })
+	t.Run("SpamService", func(t *testing.T) { testSpamService(t, open) })
+	t.Run("BanService", func(t *testing.T) { testBanService(t, open) })
+}
+
+func testUserService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		s := open(t)
+		ctx := MustCreateUser(t, s, &pa.User{Name: "Lambels", Email: "lamb@lambels.com"})
+		user := pa.UserFromContext(ctx)
+
+		if user.ID == 0 || user.APIKey == "" || user.CreatedAt.IsZero() {
+			t.Fatalf("user=%+v", user)
+		}
+
+		got, err := s.UserService.FindUserByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if got.Name != user.Name || got.Email != user.Email || got.APIKey != user.APIKey {
+			t.Fatalf("got=%+v", got)
+		} else if !got.CreatedAt.Equal(user.CreatedAt) {
+			t.Fatalf("created at=%v, want %v", got.CreatedAt, user.CreatedAt)
+		}
+
+		// find by api key.
+		if users, n, err := s.UserService.FindUsers(ctx, pa.UserFilter{APIKey: &user.APIKey}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || users[0].ID != user.ID {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Ok Update Call", func(t *testing.T) {
+		s := open(t)
+		ctx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		user := pa.UserFromContext(ctx)
+
+		name := "Lambels 2"
+		if got, err := s.UserService.UpdateUser(ctx, user.ID, pa.UserUpdate{Name: &name}); err != nil {
+			t.Fatal(err)
+		} else if got.Name != name {
+			t.Fatalf("name=%v", got.Name)
+		}
+
+		if got, err := s.UserService.FindUserByID(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		} else if got.Name != name {
+			t.Fatalf("name=%v", got.Name)
+		}
+	})
+
+	t.Run("Bad Update Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+		user := pa.UserFromContext(MustCreateUser(t, s, &pa.User{Name: "Lambels"}))
+		otherCtx := MustCreateUser(t, s, &pa.User{Name: "Other"})
+
+		name := "Lambels 2"
+		if _, err := s.UserService.UpdateUser(otherCtx, user.ID, pa.UserUpdate{Name: &name}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Delete Call", func(t *testing.T) {
+		s := open(t)
+		ctx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		user := pa.UserFromContext(ctx)
+
+		if err := s.UserService.DeleteUser(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.UserService.FindUserByID(ctx, user.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Anonymize Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		ctx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		user := pa.UserFromContext(ctx)
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+		if err := s.CommentService.CreateComment(ctx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.UserService.AnonymizeUser(adminCtx, user.ID); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		} else if err := s.UserService.AnonymizeUser(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.UserService.FindUserByID(ctx, user.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+
+		// the comment is kept under the tombstone.
+		if got, err := s.CommentService.FindCommentByID(context.Background(), comment.ID); err != nil {
+			t.Fatal(err)
+		} else if got.UserID != pa.DeletedUserID || got.Content != "nice" {
+			t.Fatalf("got=%+v", got)
+		} else if tombstone, err := s.UserService.FindUserByID(context.Background(), pa.DeletedUserID); err != nil {
+			t.Fatal(err)
+		} else if tombstone.Name != pa.DeletedUserName {
+			t.Fatalf("tombstone=%+v", tombstone)
+		}
+	})
+}
+
+func testAuthService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		s := open(t)
+		expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+		auth := &pa.Auth{
+			Source:       pa.AuthSourceGitHub,
+			SourceID:     "1",
+			AccessToken:  "ACCESS",
+			RefreshToken: "REFRESH",
+			Expiry:       &expiry,
+			User:         &pa.User{Name: "Lambels", Email: "lamb@lambels.com"},
+		}
+		if err := s.AuthService.CreateAuth(context.Background(), auth); err != nil {
+			t.Fatal(err)
+		} else if auth.ID == 0 || auth.UserID == 0 || auth.User == nil {
+			t.Fatalf("auth=%+v", auth)
+		}
+
+		ctx := pa.NewContextWithUser(context.Background(), auth.User)
+		got, err := s.AuthService.FindAuthByID(ctx, auth.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if got.UserID != auth.UserID || got.AccessToken != "ACCESS" {
+			t.Fatalf("got=%+v", got)
+		} else if got.Expiry == nil || !got.Expiry.Equal(expiry) {
+			t.Fatalf("expiry=%v, want %v", got.Expiry, expiry)
+		}
+
+		// the user gets the auth attached.
+		if user, err := s.UserService.FindUserByID(ctx, auth.UserID); err != nil {
+			t.Fatal(err)
+		} else if len(user.Auths) != 1 || user.Auths[0].ID != auth.ID {
+			t.Fatalf("auths=%v", user.Auths)
+		}
+	})
+
+	t.Run("Ok Create Call (Existing Source)", func(t *testing.T) {
+		s := open(t)
+
+		newAuth := func(token string) *pa.Auth {
+			return &pa.Auth{
+				Source:      pa.AuthSourceGitHub,
+				SourceID:    "1",
+				AccessToken: token,
+				User:        &pa.User{Name: "Lambels", Email: "lamb@lambels.com"},
+			}
+		}
+
+		first, second := newAuth("FIRST"), newAuth("SECOND")
+		if err := s.AuthService.CreateAuth(context.Background(), first); err != nil {
+			t.Fatal(err)
+		} else if err := s.AuthService.CreateAuth(context.Background(), second); err != nil {
+			t.Fatal(err)
+		}
+
+		// the second auth refreshes the first one.
+		if second.ID != first.ID || second.AccessToken != "SECOND" || second.Expiry != nil {
+			t.Fatalf("second=%+v", second)
+		} else if _, n, err := s.AuthService.FindAuths(context.Background(), pa.AuthFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Bad Delete Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+
+		auth := &pa.Auth{
+			Source:      pa.AuthSourceGitHub,
+			SourceID:    "1",
+			AccessToken: "ACCESS",
+			User:        &pa.User{Name: "Lambels"},
+		}
+		if err := s.AuthService.CreateAuth(context.Background(), auth); err != nil {
+			t.Fatal(err)
+		}
+
+		otherCtx := MustCreateUser(t, s, &pa.User{Name: "Other"})
+		if err := s.AuthService.DeleteAuth(otherCtx, auth.ID); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+func testBlogService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Hello World", Description: "desc"})
+		if blog.ID == 0 || blog.Slug != "hello-world" {
+			t.Fatalf("blog=%+v", blog)
+		}
+
+		got, err := s.BlogService.FindBlogByID(context.Background(), blog.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if got.Title != blog.Title || got.Slug != blog.Slug || !got.CreatedAt.Equal(blog.CreatedAt) {
+			t.Fatalf("got=%+v", got)
+		}
+
+		// the blog resolves through its slug.
+		if _, err := s.BlogService.FindBlogBySlug(context.Background(), "hello-world"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Bad Create Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+		ctx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+
+		if err := s.BlogService.CreateBlog(ctx, &pa.Blog{Title: "Title", Description: "desc"}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Find Call (Title)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Go Generics", Description: "desc"})
+		MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Rust", Description: "desc"})
+		MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Learning go", Description: "desc"})
+
+		// title matching is case insensitive.
+		title := "go"
+		if blogs, n, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{Title: &title}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(blogs) != 2 {
+			t.Fatalf("n=%v", n)
+		}
+
+		// the count ignores the limit.
+		if blogs, n, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{Limit: 1, Offset: 1}); err != nil {
+			t.Fatal(err)
+		} else if n != 3 || len(blogs) != 1 || blogs[0].Title != "Rust" {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Ok Find Call (Query Spec)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Go Generics", Description: "desc"})
+		MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Rust", Description: "desc"})
+		MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Learning go", Description: "desc"})
+
+		spec := pa.QuerySpec{SortBy: "title", Order: pa.OrderDesc}
+		if blogs, n, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{QuerySpec: spec}); err != nil {
+			t.Fatal(err)
+		} else if n != 3 || blogs[0].Title != "Rust" || blogs[1].Title != "Learning go" || blogs[2].Title != "Go Generics" {
+			t.Fatalf("n=%v blogs=%v", n, blogs)
+		}
+
+		// wildcards are matched literally.
+		title := "GO%"
+		if _, n, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{TitleContains: &title}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		}
+
+		hourAgo := time.Now().Add(-time.Hour)
+		if _, n, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{QuerySpec: pa.QuerySpec{CreatedAfter: &hourAgo}}); err != nil {
+			t.Fatal(err)
+		} else if n != 3 {
+			t.Fatalf("n=%v", n)
+		}
+		if _, n, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{QuerySpec: pa.QuerySpec{CreatedBefore: &hourAgo}}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		}
+
+		// only the whitelisted fields can be sorted by, and not along a cursor.
+		if _, _, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{QuerySpec: pa.QuerySpec{SortBy: "description"}}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+		if _, _, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{QuerySpec: spec, Cursor: &pa.Cursor{}}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Find Call (Include)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+
+		for _, title := range []string{"First", "Second"} {
+			blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: title, Description: "desc"})
+			for _, subTitle := range []string{"One", "Two"} {
+				subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: title + subTitle, Content: "content"})
+				if err := s.CommentService.CreateComment(usrCtx, &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		// only the blogs are loaded by default.
+		if blogs, _, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{}); err != nil {
+			t.Fatal(err)
+		} else if len(blogs) != 2 || blogs[0].SubBlogs != nil || blogs[1].SubBlogs != nil {
+			t.Fatalf("blogs=%v", blogs)
+		}
+
+		// sub blogs are grouped under their blog with their navigation.
+		blogs, _, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{IncludeSubBlogs: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, blog := range blogs {
+			if len(blog.SubBlogs) != 2 || blog.SubBlogs[0].BlogID != blog.ID || blog.SubBlogs[1].BlogID != blog.ID {
+				t.Fatalf("sub blogs=%v", blog.SubBlogs)
+			} else if blog.SubBlogs[0].Prev != nil || blog.SubBlogs[0].Next.ID != blog.SubBlogs[1].ID || blog.SubBlogs[1].Next != nil {
+				t.Fatalf("sub blogs=%v", blog.SubBlogs)
+			} else if blog.SubBlogs[0].Comments != nil {
+				t.Fatalf("comments=%v", blog.SubBlogs[0].Comments)
+			}
+		}
+
+		// comments imply the sub blogs.
+		if blogs, _, err := s.BlogService.FindBlogs(context.Background(), pa.BlogFilter{IncludeComments: true}); err != nil {
+			t.Fatal(err)
+		} else if len(blogs[1].SubBlogs) != 2 || len(blogs[1].SubBlogs[1].Comments) != 1 || blogs[1].SubBlogs[1].Comments[0].SubBlogID != blogs[1].SubBlogs[1].ID {
+			t.Fatalf("blogs=%v", blogs)
+		}
+
+		// the users of the comments are loaded only if included.
+		if comments, _, err := s.CommentService.FindComments(context.Background(), pa.CommentFilter{}); err != nil {
+			t.Fatal(err)
+		} else if len(comments) != 4 || comments[0].User != nil {
+			t.Fatalf("comments=%v", comments)
+		}
+		if comments, _, err := s.CommentService.FindComments(context.Background(), pa.CommentFilter{IncludeUser: true}); err != nil {
+			t.Fatal(err)
+		} else if len(comments) != 4 || comments[3].User == nil || comments[3].User.ID != pa.UserIDFromContext(usrCtx) {
+			t.Fatalf("comments=%v", comments)
+		}
+	})
+
+	t.Run("Ok Update Call (Slug History)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Old Title", Description: "desc"})
+
+		title := "New Title"
+		if got, err := s.BlogService.UpdateBlog(adminCtx, blog.ID, pa.BlogUpdate{Title: &title}); err != nil {
+			t.Fatal(err)
+		} else if got.Slug != "new-title" {
+			t.Fatalf("slug=%v", got.Slug)
+		}
+
+		// the old slug still resolves.
+		if got, err := s.BlogService.FindBlogBySlug(context.Background(), "old-title"); err != nil {
+			t.Fatal(err)
+		} else if got.ID != blog.ID {
+			t.Fatalf("id=%v", got.ID)
+		}
+	})
+
+	t.Run("Ok Reorder Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Series", Description: "desc"})
+
+		first := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "First", Content: "content"})
+		second := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Second", Content: "content"})
+
+		got, err := s.BlogService.ReorderSubBlogs(adminCtx, blog.ID, []int{second.ID, first.ID})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(got.SubBlogs) != 2 || got.SubBlogs[0].ID != second.ID || got.SubBlogs[1].Prev.ID != second.ID {
+			t.Fatalf("sub blogs=%v", got.SubBlogs)
+		}
+
+		if _, err := s.BlogService.ReorderSubBlogs(adminCtx, blog.ID, []int{first.ID}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Delete Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		if err := s.BlogService.DeleteBlog(adminCtx, blog.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.BlogService.FindBlogByID(adminCtx, blog.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+
+		// sub blogs are deleted with their blog.
+		if _, err := s.SubBlogService.FindSubBlogByID(adminCtx, subBlog.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+func testSubBlogService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call (Position)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Series", Description: "desc"})
+
+		first := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "First", Content: "content"})
+		last := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Last", Content: "content"})
+		middle := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Middle", Content: "content", Position: 2})
+
+		got, err := s.SubBlogService.FindSubBlogByID(context.Background(), middle.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if got.Position != 2 || got.Prev == nil || got.Prev.ID != first.ID || got.Next == nil || got.Next.ID != last.ID {
+			t.Fatalf("got=%+v", got)
+		}
+
+		// sub blogs are ordered by position.
+		if subBlogs, _, err := s.SubBlogService.FindSubBlogs(context.Background(), pa.SubBlogFilter{BlogID: &blog.ID}); err != nil {
+			t.Fatal(err)
+		} else if len(subBlogs) != 3 || subBlogs[1].ID != middle.ID || subBlogs[2].Position != 3 {
+			t.Fatalf("sub blogs=%v", subBlogs)
+		}
+	})
+
+	t.Run("Ok Update Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Series", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Old", Content: "content"})
+
+		title, content := "New", "new content"
+		if got, err := s.SubBlogService.UpdateSubBlog(adminCtx, subBlog.ID, pa.SubBlogUpdate{Title: &title, Content: &content}); err != nil {
+			t.Fatal(err)
+		} else if got.Title != title || got.Content != content || got.Slug != "new" {
+			t.Fatalf("got=%+v", got)
+		}
+
+		if got, err := s.SubBlogService.FindSubBlogBySlug(context.Background(), "old"); err != nil {
+			t.Fatal(err)
+		} else if got.ID != subBlog.ID {
+			t.Fatalf("id=%v", got.ID)
+		}
+	})
+
+	t.Run("Ok Delete Call (Close Gap)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Series", Description: "desc"})
+
+		first := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "First", Content: "content"})
+		second := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Second", Content: "content"})
+
+		if err := s.SubBlogService.DeleteSubBlog(adminCtx, first.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, err := s.SubBlogService.FindSubBlogByID(context.Background(), second.ID); err != nil {
+			t.Fatal(err)
+		} else if got.Position != 1 || got.Prev != nil {
+			t.Fatalf("got=%+v", got)
+		}
+	})
+}
+
+func testCommentService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+		if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		} else if comment.ID == 0 || comment.UserID != pa.UserIDFromContext(usrCtx) || comment.User == nil {
+			t.Fatalf("comment=%+v", comment)
+		}
+
+		// comments are attached to their sub blog.
+		if got, err := s.SubBlogService.FindSubBlogByID(context.Background(), subBlog.ID); err != nil {
+			t.Fatal(err)
+		} else if len(got.Comments) != 1 || got.Comments[0].Content != "nice" {
+			t.Fatalf("comments=%v", got.Comments)
+		}
+	})
+
+	t.Run("Bad Delete Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+		if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.CommentService.DeleteComment(adminCtx, comment.ID); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		} else if err := s.CommentService.DeleteComment(usrCtx, comment.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Ok Find Call (Held)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		otherCtx := MustCreateUser(t, s, &pa.User{Name: "Jhon Doe"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "buy now", Status: pa.CommentStatusHeld}
+		if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		// held comments are hidden from everyone but the admin and their author.
+		if _, n, err := s.CommentService.FindComments(otherCtx, pa.CommentFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		} else if _, err := s.CommentService.FindCommentByID(otherCtx, comment.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		} else if got, err := s.SubBlogService.FindSubBlogByID(context.Background(), subBlog.ID); err != nil {
+			t.Fatal(err)
+		} else if len(got.Comments) != 0 {
+			t.Fatalf("comments=%v", got.Comments)
+		}
+
+		if own, n, err := s.CommentService.FindComments(usrCtx, pa.CommentFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || own[0].ID != comment.ID || own[0].Status != pa.CommentStatusHeld {
+			t.Fatalf("n=%v comments=%v", n, own)
+		}
+
+		status := pa.CommentStatusHeld
+		if _, _, err := s.CommentService.FindComments(usrCtx, pa.CommentFilter{Status: &status}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		} else if held, n, err := s.CommentService.FindComments(adminCtx, pa.CommentFilter{Status: &status}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || held[0].ID != comment.ID || held[0].Status != pa.CommentStatusHeld {
+			t.Fatalf("n=%v comments=%v", n, held)
+		}
+
+		// approved comments are public.
+		status = pa.CommentStatusApproved
+		if _, err := s.CommentService.UpdateComment(adminCtx, comment.ID, pa.CommentUpdate{Status: &status}); err != nil {
+			t.Fatal(err)
+		} else if _, n, err := s.CommentService.FindComments(otherCtx, pa.CommentFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Ok Delete Call (Held)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "buy now", Status: pa.CommentStatusHeld}
+		if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		// the admin rejects held comments.
+		if err := s.CommentService.DeleteComment(adminCtx, comment.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.CommentService.FindCommentByID(adminCtx, comment.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Find Call (Cursor)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		var ids []int
+		for i := 0; i < 5; i++ {
+			comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+			if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, comment.ID)
+		}
+
+		// first page, comments created within the same second are ordered by id.
+		first, _, err := s.CommentService.FindComments(context.Background(), pa.CommentFilter{Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the page after the first one ignores the offset, the count is the number of comments left.
+		cursor := &pa.Cursor{CreatedAt: first[1].CreatedAt, ID: first[1].ID}
+		next, n, err := s.CommentService.FindComments(context.Background(), pa.CommentFilter{Cursor: cursor, Limit: 2, Offset: 10})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 3 || len(next) != 2 || next[0].ID != ids[2] || next[1].ID != ids[3] {
+			t.Fatalf("n=%v comments=%v", n, next)
+		}
+
+		// the page before the second one is the first one, in order.
+		cursor = &pa.Cursor{CreatedAt: next[0].CreatedAt, ID: next[0].ID, Before: true}
+		if prev, n, err := s.CommentService.FindComments(context.Background(), pa.CommentFilter{Cursor: cursor, Limit: 2}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(prev) != 2 || prev[0].ID != ids[0] || prev[1].ID != ids[1] {
+			t.Fatalf("n=%v comments=%v", n, prev)
+		}
+	})
+}
+
+func testSubscriptionService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		blogSub := &pa.Subscription{Topic: pa.EventTopicNewSubBlog, Payload: pa.SubBlogPayload{BlogID: blog.ID}}
+		subBlogSub := &pa.Subscription{Topic: pa.EventTopicNewComment, Payload: pa.CommentPayload{SubBlogID: subBlog.ID}}
+		for _, sub := range []*pa.Subscription{blogSub, subBlogSub} {
+			if err := s.SubscriptionService.CreateSubscription(usrCtx, sub); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// payloads get their blog / sub blog attached.
+		if got, err := s.SubscriptionService.FindSubscriptionByID(usrCtx, blogSub.ID, pa.EventTopicNewSubBlog); err != nil {
+			t.Fatal(err)
+		} else if payload := got.Payload.(pa.SubBlogPayload); payload.Blog == nil || payload.Blog.ID != blog.ID {
+			t.Fatalf("payload=%+v", payload)
+		}
+
+		topic := pa.EventTopicNewComment
+		if subs, n, err := s.SubscriptionService.FindSubscriptions(usrCtx, pa.SubscriptionFilter{Topic: &topic}); err != nil {
+			t.Fatal(err)
+		} else if payload := subs[0].Payload.(pa.CommentPayload); n != 1 || payload.SubBlog == nil || payload.SubBlog.ID != subBlog.ID {
+			t.Fatalf("n=%v payload=%+v", n, payload)
+		}
+	})
+
+	t.Run("Bad Delete Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+
+		sub := &pa.Subscription{Topic: pa.EventTopicNewSubBlog, Payload: pa.SubBlogPayload{BlogID: blog.ID}}
+		if err := s.SubscriptionService.CreateSubscription(usrCtx, sub); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.SubscriptionService.DeleteSubscription(adminCtx, sub.ID, sub.Topic); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		} else if err := s.SubscriptionService.DeleteSubscription(usrCtx, sub.ID, sub.Topic); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.SubscriptionService.FindSubscriptionByID(usrCtx, sub.ID, sub.Topic); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Find Call (Cursor)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+
+		var ids []int
+		for _, title := range []string{"First", "Second", "Third"} {
+			blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: title, Description: "desc"})
+			sub := &pa.Subscription{Topic: pa.EventTopicNewSubBlog, Payload: pa.SubBlogPayload{BlogID: blog.ID}}
+			if err := s.SubscriptionService.CreateSubscription(usrCtx, sub); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, sub.ID)
+		}
+
+		// the page after the first subscription, the count is the number of subscriptions left.
+		topic := pa.EventTopicNewSubBlog
+		first, _, err := s.SubscriptionService.FindSubscriptions(usrCtx, pa.SubscriptionFilter{Topic: &topic, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cursor := &pa.Cursor{CreatedAt: first[0].CreatedAt, ID: first[0].ID}
+		next, n, err := s.SubscriptionService.FindSubscriptions(usrCtx, pa.SubscriptionFilter{Topic: &topic, Cursor: cursor, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(next) != 1 || next[0].ID != ids[1] {
+			t.Fatalf("n=%v subs=%v", n, next)
+		}
+
+		cursor = &pa.Cursor{CreatedAt: next[0].CreatedAt, ID: next[0].ID, Before: true}
+		if prev, n, err := s.SubscriptionService.FindSubscriptions(usrCtx, pa.SubscriptionFilter{Topic: &topic, Cursor: cursor}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || len(prev) != 1 || prev[0].ID != ids[0] {
+			t.Fatalf("n=%v subs=%v", n, prev)
+		}
+	})
+}
+
+func testProjectService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Or Update Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		project := &pa.Project{Name: "pa", Description: "blog", HtmlURL: "https://github.com/Lambels/pa", Topics: []string{"go", "sql"}}
+		if err := s.ProjectService.CreateOrUpdateProject(adminCtx, project); err != nil {
+			t.Fatal(err)
+		}
+
+		// topics are shared between projects and replaced on update.
+		other := &pa.Project{Name: "other", Description: "other", HtmlURL: "https://github.com/Lambels/other", Topics: []string{"go"}}
+		if err := s.ProjectService.CreateOrUpdateProject(adminCtx, other); err != nil {
+			t.Fatal(err)
+		}
+
+		update := &pa.Project{Name: "pa", Description: "new", HtmlURL: "https://github.com/Lambels/pa", Topics: []string{"go", "web"}}
+		if err := s.ProjectService.CreateOrUpdateProject(adminCtx, update); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := s.ProjectService.FindProjectByName(context.Background(), "pa")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sort.Strings(got.Topics) // topics come unordered.
+		if got.Description != "new" || len(got.Topics) != 2 || got.Topics[0] != "go" || got.Topics[1] != "web" {
+			t.Fatalf("got=%+v", got)
+		}
+
+		if _, n, err := s.ProjectService.FindProjects(context.Background(), pa.ProjectFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Ok Find Call (Query Spec)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		for _, name := range []string{"pa", "other", "Pages"} {
+			if err := s.ProjectService.CreateOrUpdateProject(adminCtx, &pa.Project{Name: name, HtmlURL: "https://github.com/Lambels/" + name}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		name := "PA"
+		filter := pa.ProjectFilter{NameContains: &name, QuerySpec: pa.QuerySpec{SortBy: "id", Order: pa.OrderDesc}}
+		if projects, n, err := s.ProjectService.FindProjects(context.Background(), filter); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || projects[0].Name != "Pages" || projects[1].Name != "pa" {
+			t.Fatalf("n=%v projects=%v", n, projects)
+		}
+
+		// the projects are filtered on their creation time.
+		before, after := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+		if _, n, err := s.ProjectService.FindProjects(context.Background(), pa.ProjectFilter{QuerySpec: pa.QuerySpec{CreatedAfter: &before}}); err != nil {
+			t.Fatal(err)
+		} else if n != 3 {
+			t.Fatalf("n=%v", n)
+		}
+		if _, n, err := s.ProjectService.FindProjects(context.Background(), pa.ProjectFilter{QuerySpec: pa.QuerySpec{CreatedAfter: &after}}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Ok Delete Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		project := &pa.Project{Name: "pa", HtmlURL: "https://github.com/Lambels/pa"}
+		if err := s.ProjectService.CreateOrUpdateProject(adminCtx, project); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.ProjectService.DeleteProject(adminCtx, "pa"); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.ProjectService.FindProjectByName(context.Background(), "pa"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Find Call (Cursor)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		var ids []int
+		for _, name := range []string{"pa", "other", "Pages", "more"} {
+			project := &pa.Project{Name: name, HtmlURL: "https://github.com/Lambels/" + name}
+			if err := s.ProjectService.CreateOrUpdateProject(adminCtx, project); err != nil {
+				t.Fatal(err)
+			} else if project.CreatedAt.IsZero() {
+				t.Fatalf("project=%+v", project)
+			}
+			ids = append(ids, project.ID)
+		}
+
+		first, _, err := s.ProjectService.FindProjects(context.Background(), pa.ProjectFilter{Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the page after the first one ignores the offset, the count is the number of projects left.
+		cursor := &pa.Cursor{CreatedAt: first[1].CreatedAt, ID: first[1].ID}
+		next, n, err := s.ProjectService.FindProjects(context.Background(), pa.ProjectFilter{Cursor: cursor, Limit: 2, Offset: 10})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(next) != 2 || next[0].ID != ids[2] || next[1].ID != ids[3] {
+			t.Fatalf("n=%v projects=%v", n, next)
+		}
+
+		// the page before the second one is the first one, in order.
+		cursor = &pa.Cursor{CreatedAt: next[0].CreatedAt, ID: next[0].ID, Before: true}
+		if prev, n, err := s.ProjectService.FindProjects(context.Background(), pa.ProjectFilter{Cursor: cursor, Limit: 2}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(prev) != 2 || prev[0].ID != ids[0] || prev[1].ID != ids[1] {
+			t.Fatalf("n=%v projects=%v", n, prev)
+		}
+
+		// sorting cant be combined with a cursor.
+		if _, _, err := s.ProjectService.FindProjects(context.Background(), pa.ProjectFilter{Cursor: cursor, QuerySpec: pa.QuerySpec{SortBy: "name"}}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+func testMediaService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		media := MustCreateMedia(t, s, adminCtx, "aa")
+		got, err := s.MediaService.FindMediaByID(context.Background(), media.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if got.Hash != "aa" || got.Size != media.Size || len(got.Variants) != 1 || got.Variants[0].Name != "aa-320w.webp" {
+			t.Fatalf("got=%+v", got)
+		}
+
+		// media is content addressed.
+		if err := s.MediaService.CreateMedia(adminCtx, &pa.Media{Hash: "aa", Name: "aa.png", MimeType: "image/png", Size: 1}); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Ref Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		first, second := MustCreateMedia(t, s, adminCtx, "aa"), MustCreateMedia(t, s, adminCtx, "bb")
+
+		// a blog references a single image, referencing twice replaces the image.
+		for _, m := range []*pa.Media{first, second} {
+			if err := s.MediaService.CreateMediaRef(adminCtx, m.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: blog.ID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// referencing twice from a sub blog is a no-op.
+		for i := 0; i < 2; i++ {
+			if err := s.MediaService.CreateMediaRef(adminCtx, first.ID, pa.MediaRef{Type: pa.MediaRefSubBlog, ID: subBlog.ID}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := s.BlogService.FindBlogByID(context.Background(), blog.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if got.Image == nil || got.Image.ID != second.ID {
+			t.Fatalf("image=%+v", got.Image)
+		} else if len(got.SubBlogs[0].Media) != 1 || got.SubBlogs[0].Media[0].RefCount != 1 {
+			t.Fatalf("media=%v", got.SubBlogs[0].Media)
+		}
+
+		// referenced media cant be deleted.
+		if err := s.MediaService.DeleteMedia(adminCtx, second.ID); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatalf("err=%v", err)
+		} else if err := s.MediaService.DeleteMediaRef(adminCtx, second.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: blog.ID}); err != nil {
+			t.Fatal(err)
+		} else if err := s.MediaService.DeleteMediaRef(adminCtx, second.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: blog.ID}); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		} else if err := s.MediaService.DeleteMedia(adminCtx, second.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func testTrashService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Restore Call (Sub Blog)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		first := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "First", Content: "content"})
+		second := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Second", Content: "content"})
+
+		// the deleted sub blog leaves the series.
+		if err := s.SubBlogService.DeleteSubBlog(adminCtx, first.ID); err != nil {
+			t.Fatal(err)
+		} else if got, err := s.SubBlogService.FindSubBlogByID(context.Background(), second.ID); err != nil {
+			t.Fatal(err)
+		} else if got.Position != 1 || got.Prev != nil {
+			t.Fatalf("got=%+v", got)
+		}
+
+		if items, n, err := s.TrashService.FindTrash(adminCtx, pa.TrashFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || items[0].Type != pa.TrashTypeSubBlog || items[0].ID != first.ID || items[0].Title != "First" {
+			t.Fatalf("n=%v items=%v", n, items)
+		}
+
+		// the restored sub blog goes back to its position.
+		if err := s.TrashService.Restore(adminCtx, pa.TrashTypeSubBlog, first.ID); err != nil {
+			t.Fatal(err)
+		} else if got, err := s.SubBlogService.FindSubBlogByID(context.Background(), second.ID); err != nil {
+			t.Fatal(err)
+		} else if got.Position != 2 || got.Prev == nil || got.Prev.ID != first.ID {
+			t.Fatalf("got=%+v", got)
+		}
+
+		if err := s.TrashService.Restore(adminCtx, pa.TrashTypeSubBlog, first.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Restore Call (Blog)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+		if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		// the sub blogs and comments are hidden along the blog.
+		if err := s.BlogService.DeleteBlog(adminCtx, blog.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.SubBlogService.FindSubBlogByID(context.Background(), subBlog.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		} else if _, n, err := s.CommentService.FindComments(context.Background(), pa.CommentFilter{}); err != nil || n != 0 {
+			t.Fatalf("n=%v err=%v", n, err)
+		}
+
+		// the hidden sub blogs cant be deleted on their own.
+		if err := s.SubBlogService.DeleteSubBlog(adminCtx, subBlog.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+
+		if err := s.TrashService.Restore(adminCtx, pa.TrashTypeBlog, blog.ID); err != nil {
+			t.Fatal(err)
+		} else if got, err := s.BlogService.FindBlogByID(context.Background(), blog.ID); err != nil {
+			t.Fatal(err)
+		} else if len(got.SubBlogs) != 1 || len(got.SubBlogs[0].Comments) != 1 {
+			t.Fatalf("got=%+v", got)
+		}
+	})
+
+	t.Run("Bad Restore Call (Conflict)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		if err := s.SubBlogService.DeleteSubBlog(adminCtx, subBlog.ID); err != nil {
+			t.Fatal(err)
+		} else if err := s.BlogService.DeleteBlog(adminCtx, blog.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.TrashService.Restore(adminCtx, pa.TrashTypeSubBlog, subBlog.ID); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatalf("err=%v", err)
+		} else if err := s.TrashService.Restore(adminCtx, "user", subBlog.ID); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Purge Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+		if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		} else if err := s.CommentService.DeleteComment(usrCtx, comment.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// objects deleted within the retention are kept.
+		if n, err := s.TrashService.Purge(adminCtx, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		}
+
+		if n, err := s.TrashService.Purge(adminCtx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("n=%v", n)
+		} else if err := s.TrashService.Restore(adminCtx, pa.TrashTypeComment, comment.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Bad Find Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+
+		if _, _, err := s.TrashService.FindTrash(usrCtx, pa.TrashFilter{}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		} else if _, err := s.TrashService.Purge(usrCtx, time.Now()); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Find Call (Cursor)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		first := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "First", Description: "desc"})
+		second := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Second", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: second.ID, Title: "Sub", Content: "content"})
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+		if err := s.CommentService.CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		// the ids of the deleted blog, sub blog and comment collide, the type breaks the ties.
+		if err := s.CommentService.DeleteComment(usrCtx, comment.ID); err != nil {
+			t.Fatal(err)
+		} else if err := s.SubBlogService.DeleteSubBlog(adminCtx, subBlog.ID); err != nil {
+			t.Fatal(err)
+		} else if err := s.BlogService.DeleteBlog(adminCtx, first.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		all, _, err := s.TrashService.FindTrash(adminCtx, pa.TrashFilter{})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(all) != 3 {
+			t.Fatalf("items=%v", all)
+		}
+
+		// the page after the first two items, latest deleted first.
+		cursor := &pa.Cursor{CreatedAt: all[1].DeletedAt, ID: all[1].ID, Type: all[1].Type}
+		next, n, err := s.TrashService.FindTrash(adminCtx, pa.TrashFilter{Cursor: cursor, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 1 || len(next) != 1 || *next[0] != *all[2] {
+			t.Fatalf("n=%v items=%v", n, next)
+		}
+
+		cursor = &pa.Cursor{CreatedAt: next[0].DeletedAt, ID: next[0].ID, Type: next[0].Type, Before: true}
+		if prev, n, err := s.TrashService.FindTrash(adminCtx, pa.TrashFilter{Cursor: cursor, Limit: 2}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(prev) != 2 || *prev[0] != *all[0] || *prev[1] != *all[1] {
+			t.Fatalf("n=%v items=%v", n, prev)
+		}
+	})
+}
+
+func testAuditService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Find Call (Before ID)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		for _, title := range []string{"First", "Second", "Third"} {
+			MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: title, Description: "desc"})
+		}
+
+		all, _, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(all) != 3 {
+			t.Fatalf("entries=%v", all)
+		}
+
+		// the entries recorded before the given one, latest first.
+		if entries, n, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{BeforeID: &all[0].ID}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || entries[0].ID != all[1].ID || entries[1].ID != all[2].ID {
+			t.Fatalf("n=%v entries=%v", n, entries)
+		}
+	})
+
+	t.Run("Ok Find Call (Cursor)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		for _, title := range []string{"First", "Second", "Third"} {
+			MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: title, Description: "desc"})
+		}
+
+		all, _, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the page after the latest entry holds the older entries.
+		cursor := &pa.Cursor{CreatedAt: all[0].CreatedAt, ID: all[0].ID}
+		next, n, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{Cursor: cursor, Limit: 1, Offset: 10})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(next) != 1 || next[0].ID != all[1].ID {
+			t.Fatalf("n=%v entries=%v", n, next)
+		}
+
+		cursor = &pa.Cursor{CreatedAt: all[2].CreatedAt, ID: all[2].ID, Before: true}
+		if prev, n, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{Cursor: cursor}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(prev) != 2 || prev[0].ID != all[0].ID || prev[1].ID != all[1].ID {
+			t.Fatalf("n=%v entries=%v", n, prev)
+		}
+	})
+
+	t.Run("Ok Find Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		admin := pa.UserFromContext(adminCtx)
+		adminCtx = pa.NewContextWithClient(adminCtx, pa.Client{IP: "127.0.0.1", UserAgent: "test"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+
+		title := "New Title"
+		if _, err := s.BlogService.UpdateBlog(adminCtx, blog.ID, pa.BlogUpdate{Title: &title}); err != nil {
+			t.Fatal(err)
+		}
+
+		// updates leaving the blog as it was arent recorded.
+		if _, err := s.BlogService.UpdateBlog(adminCtx, blog.ID, pa.BlogUpdate{Title: &title}); err != nil {
+			t.Fatal(err)
+		}
+
+		target := pa.AuditTargetBlog
+		entries, n, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{TargetType: &target, TargetID: &blog.ID})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 2 {
+			t.Fatalf("n=%v", n)
+		}
+
+		// latest first.
+		update, create := entries[0], entries[1]
+		if update.Action != pa.AuditActionUpdate || create.Action != pa.AuditActionCreate {
+			t.Fatalf("update=%+v create=%+v", update, create)
+		} else if update.ActorID != admin.ID || update.IP != "127.0.0.1" || update.UserAgent != "test" || update.CreatedAt.IsZero() {
+			t.Fatalf("update=%+v", update)
+		}
+
+		// only the changed fields are kept.
+		var diff map[string]struct {
+			Before string `json:"before"`
+			After  string `json:"after"`
+		}
+		if err := json.Unmarshal(update.Diff, &diff); err != nil {
+			t.Fatal(err)
+		} else if diff["title"].Before != "Title" || diff["title"].After != "New Title" {
+			t.Fatalf("diff=%s", update.Diff)
+		} else if _, ok := diff["description"]; ok {
+			t.Fatalf("diff=%s", update.Diff)
+		}
+	})
+
+	t.Run("Ok Find Call (Erased User)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		for action, erase := range map[string]func(context.Context, int) error{
+			pa.AuditActionAnonymize: s.UserService.AnonymizeUser,
+			pa.AuditActionDelete:    s.UserService.DeleteUser,
+		} {
+			ctx := MustCreateUser(t, s, &pa.User{Name: "Lambels " + action, Email: action + "@lambels.com"})
+			user := pa.UserFromContext(ctx)
+			ctx = pa.NewContextWithClient(ctx, pa.Client{IP: "10.0.0.1", UserAgent: "browser"})
+
+			// an entry recorded by the user before the erasure.
+			comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}
+			if err := s.CommentService.CreateComment(ctx, comment); err != nil {
+				t.Fatal(err)
+			} else if err := s.CommentService.DeleteComment(ctx, comment.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := erase(ctx, user.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			target := pa.AuditTargetUser
+			entries, n, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{TargetType: &target, TargetID: &user.ID})
+			if err != nil {
+				t.Fatal(err)
+			} else if n != 1 || entries[0].Action != action {
+				t.Fatalf("n=%v entries=%+v", n, entries)
+			}
+
+			// only the action and the target are kept.
+			entry := entries[0]
+			if diff := string(entry.Diff); diff != "{}" {
+				t.Fatalf("diff=%s", diff)
+			} else if entry.IP != "" || entry.UserAgent != "" {
+				t.Fatalf("entry=%+v", entry)
+			}
+
+			// the origins of the entries recorded by the user are erased.
+			entries, _, err = s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{ActorID: &user.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if entry.IP != "" || entry.UserAgent != "" {
+					t.Fatalf("entry=%+v", entry)
+				}
+			}
+			if len(entries) != 2 {
+				t.Fatalf("entries=%+v", entries)
+			}
+		}
+	})
+
+	t.Run("Ok Find Call (Erased User Content)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		otherCtx := MustCreateUser(t, s, &pa.User{Name: "Jhon Doe"})
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		// the entries on the targets of the other users are kept.
+		other := &pa.Comment{SubBlogID: subBlog.ID, Content: "kept content"}
+		if err := s.CommentService.CreateComment(otherCtx, other); err != nil {
+			t.Fatal(err)
+		} else if err := s.CommentService.DeleteComment(otherCtx, other.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		for action, erase := range map[string]func(context.Context, int) error{
+			pa.AuditActionAnonymize: s.UserService.AnonymizeUser,
+			pa.AuditActionDelete:    s.UserService.DeleteUser,
+		} {
+			secret := "secret " + action
+			ctx := MustCreateUser(t, s, &pa.User{Name: secret, Email: action + "@lambels.com"})
+			user := pa.UserFromContext(ctx)
+
+			// held comments deleted and approved by the admin, and a comment edited by the admin.
+			deleted := &pa.Comment{SubBlogID: subBlog.ID, Content: secret + " deleted", Status: pa.CommentStatusHeld}
+			approved := &pa.Comment{SubBlogID: subBlog.ID, Content: secret + " approved", Status: pa.CommentStatusHeld}
+			edited := &pa.Comment{SubBlogID: subBlog.ID, Content: secret + " draft"}
+			for _, comment := range []*pa.Comment{deleted, approved, edited} {
+				if err := s.CommentService.CreateComment(ctx, comment); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			status, content := pa.CommentStatusApproved, secret+" edited"
+			if err := s.CommentService.DeleteComment(adminCtx, deleted.ID); err != nil {
+				t.Fatal(err)
+			} else if _, err := s.CommentService.UpdateComment(adminCtx, approved.ID, pa.CommentUpdate{Status: &status}); err != nil {
+				t.Fatal(err)
+			} else if _, err := s.CommentService.UpdateComment(adminCtx, edited.ID, pa.CommentUpdate{Content: &content}); err != nil {
+				t.Fatal(err)
+			} else if err := s.BanService.CreateBan(adminCtx, &pa.Ban{UserID: user.ID, Reason: secret + " reason", Scope: pa.BanScopeComment}); err != nil {
+				t.Fatal(err)
+			}
+
+			// the purged comments are only remembered by the audit log.
+			if _, err := s.TrashService.Purge(adminCtx, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := erase(ctx, user.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, _, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var kept bool
+			for _, entry := range entries {
+				if strings.Contains(string(entry.Diff), secret) {
+					t.Fatalf("entry=%+v", entry)
+				} else if entry.TargetID == approved.ID && entry.TargetType == pa.AuditTargetComment && entry.Action == pa.AuditActionUpdate && string(entry.Diff) != "{}" {
+					t.Fatalf("entry=%+v", entry)
+				}
+				kept = kept || strings.Contains(string(entry.Diff), "kept content")
+			}
+			if !kept {
+				t.Fatal("entry of the other user erased")
+			}
+		}
+	})
+
+	t.Run("Ok Find Call (Rolled Back)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		// the entry is recorded along the change, failed changes leave no entry.
+		if err := s.BlogService.CreateBlog(adminCtx, &pa.Blog{}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		} else if _, n, err := s.AuditService.FindAuditEntries(adminCtx, pa.AuditFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Bad Find Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+
+		if _, _, err := s.AuditService.FindAuditEntries(usrCtx, pa.AuditFilter{}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+func testSpamService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		rule := &pa.SpamRule{Type: pa.SpamRuleWord, Pattern: "casino", Score: 5}
+		if err := s.SpamService.CreateSpamRule(adminCtx, rule); err != nil {
+			t.Fatal(err)
+		} else if rule.ID == 0 || rule.CreatedAt.IsZero() {
+			t.Fatalf("rule=%+v", rule)
+		}
+
+		if rules, err := s.SpamService.FindSpamRules(adminCtx); err != nil {
+			t.Fatal(err)
+		} else if len(rules) != 1 || rules[0].Pattern != "casino" || rules[0].Score != 5 {
+			t.Fatalf("rules=%v", rules)
+		}
+	})
+
+	t.Run("Bad Create Call (Invalid)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		if err := s.SpamService.CreateSpamRule(adminCtx, &pa.SpamRule{Type: pa.SpamRuleRegex, Pattern: "(", Score: 5}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+
+		// duplicate rule.
+		if err := s.SpamService.CreateSpamRule(adminCtx, &pa.SpamRule{Type: pa.SpamRuleWord, Pattern: "casino", Score: 5}); err != nil {
+			t.Fatal(err)
+		} else if err := s.SpamService.CreateSpamRule(adminCtx, &pa.SpamRule{Type: pa.SpamRuleWord, Pattern: "casino", Score: 2}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Ok Delete Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+
+		rule := &pa.SpamRule{Type: pa.SpamRuleRegex, Pattern: `\bviagra\b`, Score: 5}
+		if err := s.SpamService.CreateSpamRule(adminCtx, rule); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.SpamService.DeleteSpamRule(adminCtx, rule.ID); err != nil {
+			t.Fatal(err)
+		} else if err := s.SpamService.DeleteSpamRule(adminCtx, rule.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		} else if rules, err := s.SpamService.FindSpamRules(adminCtx); err != nil {
+			t.Fatal(err)
+		} else if len(rules) != 0 {
+			t.Fatalf("rules=%v", rules)
+		}
+	})
+
+	t.Run("Bad Find Call (Unauthorized)", func(t *testing.T) {
+		s := open(t)
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+
+		if _, err := s.SpamService.FindSpamRules(usrCtx); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		} else if err := s.SpamService.CreateSpamRule(usrCtx, &pa.SpamRule{Type: pa.SpamRuleWord, Pattern: "casino", Score: 5}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+func testBanService(t *testing.T, open OpenFunc) {
+	t.Run("Ok Create Call (Full)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		usr := pa.UserFromContext(usrCtx)
+
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		ban := &pa.Ban{UserID: usr.ID, Reason: "abuse", Scope: pa.BanScopeFull, ExpiresAt: &expiresAt}
+		if err := s.BanService.CreateBan(adminCtx, ban); err != nil {
+			t.Fatal(err)
+		} else if ban.ID == 0 || ban.CreatedAt.IsZero() {
+			t.Fatalf("ban=%+v", ban)
+		}
+
+		if got, err := s.BanService.FindActiveBan(context.Background(), usr.ID); err != nil {
+			t.Fatal(err)
+		} else if got.ID != ban.ID || got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) {
+			t.Fatalf("ban=%+v", got)
+		}
+
+		// the sessions and the api key are revoked.
+		if got, err := s.UserService.FindUserByID(context.Background(), usr.ID); err != nil {
+			t.Fatal(err)
+		} else if got.APIKey == usr.APIKey || got.SessionsRevokedAt.IsZero() {
+			t.Fatalf("user=%+v", got)
+		}
+	})
+
+	t.Run("Ok Create Call (Comment)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		usr := pa.UserFromContext(usrCtx)
+		blog := MustCreateBlog(t, s, adminCtx, &pa.Blog{Title: "Title", Description: "desc"})
+		subBlog := MustCreateSubBlog(t, s, adminCtx, &pa.SubBlog{BlogID: blog.ID, Title: "Sub", Content: "content"})
+
+		if err := s.BanService.CreateBan(adminCtx, &pa.Ban{UserID: usr.ID, Reason: "spam", Scope: pa.BanScopeComment}); err != nil {
+			t.Fatal(err)
+		}
+
+		// banned users cant comment.
+		if err := s.CommentService.CreateComment(usrCtx, &pa.Comment{SubBlogID: subBlog.ID, Content: "nice"}); pa.ErrorCode(err) != pa.EFORBIDDEN {
+			t.Fatalf("err=%v", err)
+		}
+
+		// comment bans keep the sessions and the api key.
+		if got, err := s.UserService.FindUserByID(context.Background(), usr.ID); err != nil {
+			t.Fatal(err)
+		} else if got.APIKey != usr.APIKey || !got.SessionsRevokedAt.IsZero() {
+			t.Fatalf("user=%+v", got)
+		}
+	})
+
+	t.Run("Ok Find Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usr := pa.UserFromContext(MustCreateUser(t, s, &pa.User{Name: "Lambels"}))
+
+		if err := s.BanService.CreateBan(adminCtx, &pa.Ban{UserID: usr.ID, Reason: "spam", Scope: pa.BanScopeComment}); err != nil {
+			t.Fatal(err)
+		}
+		full := &pa.Ban{UserID: usr.ID, Reason: "abuse", Scope: pa.BanScopeFull}
+		if err := s.BanService.CreateBan(adminCtx, full); err != nil {
+			t.Fatal(err)
+		}
+
+		// the full ban wins.
+		if got, err := s.BanService.FindActiveBan(context.Background(), usr.ID); err != nil {
+			t.Fatal(err)
+		} else if got.ID != full.ID {
+			t.Fatalf("ban=%+v", got)
+		}
+
+		active, inactive := true, false
+		if bans, n, err := s.BanService.FindBans(adminCtx, pa.BanFilter{UserID: &usr.ID, Active: &active}); err != nil {
+			t.Fatal(err)
+		} else if n != 2 || bans[0].ID != full.ID {
+			t.Fatalf("n=%v bans=%v", n, bans)
+		} else if _, n, err := s.BanService.FindBans(adminCtx, pa.BanFilter{Active: &inactive}); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Ok Find Call (Cursor)", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usr := pa.UserFromContext(MustCreateUser(t, s, &pa.User{Name: "Lambels"}))
+
+		var ids []int
+		for _, reason := range []string{"spam", "more spam", "even more spam"} {
+			ban := &pa.Ban{UserID: usr.ID, Reason: reason, Scope: pa.BanScopeComment}
+			if err := s.BanService.CreateBan(adminCtx, ban); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, ban.ID)
+		}
+
+		// the page after the latest ban holds the older bans.
+		first, _, err := s.BanService.FindBans(adminCtx, pa.BanFilter{Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		} else if first[0].ID != ids[2] {
+			t.Fatalf("bans=%v", first)
+		}
+
+		cursor := &pa.Cursor{CreatedAt: first[0].CreatedAt, ID: first[0].ID}
+		next, n, err := s.BanService.FindBans(adminCtx, pa.BanFilter{Cursor: cursor, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 2 || len(next) != 1 || next[0].ID != ids[1] {
+			t.Fatalf("n=%v bans=%v", n, next)
+		}
+
+		cursor = &pa.Cursor{CreatedAt: next[0].CreatedAt, ID: next[0].ID, Before: true}
+		if prev, n, err := s.BanService.FindBans(adminCtx, pa.BanFilter{Cursor: cursor}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || len(prev) != 1 || prev[0].ID != ids[2] {
+			t.Fatalf("n=%v bans=%v", n, prev)
+		}
+	})
+
+	t.Run("Ok Delete Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usr := pa.UserFromContext(MustCreateUser(t, s, &pa.User{Name: "Lambels"}))
+
+		ban := &pa.Ban{UserID: usr.ID, Reason: "spam", Scope: pa.BanScopeComment}
+		if err := s.BanService.CreateBan(adminCtx, ban); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.BanService.DeleteBan(adminCtx, ban.ID); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.BanService.FindActiveBan(context.Background(), usr.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		} else if err := s.BanService.DeleteBan(adminCtx, ban.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Bad Create Call", func(t *testing.T) {
+		s := open(t)
+		adminCtx := MustCreateUser(t, s, &pa.User{Name: "Admin", IsAdmin: true})
+		usrCtx := MustCreateUser(t, s, &pa.User{Name: "Lambels"})
+		usr := pa.UserFromContext(usrCtx)
+
+		expiresAt := time.Now().Add(-time.Hour)
+		if err := s.BanService.CreateBan(usrCtx, &pa.Ban{UserID: usr.ID, Reason: "spam", Scope: pa.BanScopeFull}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatalf("err=%v", err)
+		} else if err := s.BanService.CreateBan(adminCtx, &pa.Ban{UserID: 100, Reason: "spam", Scope: pa.BanScopeFull}); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		} else if err := s.BanService.CreateBan(adminCtx, &pa.Ban{UserID: usr.ID, Reason: "spam", Scope: "all"}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		} else if err := s.BanService.CreateBan(adminCtx, &pa.Ban{UserID: usr.ID, Reason: "spam", Scope: pa.BanScopeFull, ExpiresAt: &expiresAt}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+// MustCreateUser creates user and returns a context holding the created user.
+func MustCreateUser(t *testing.T, s *Services, user *pa.User) context.Context {
+	t.Helper()
+
+	if err := s.UserService.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return pa.NewContextWithUser(context.Background(), user)
+}
+
+// MustCreateBlog creates blog with ctx.
+func MustCreateBlog(t *testing.T, s *Services, ctx context.Context, blog *pa.Blog) *pa.Blog {
+	t.Helper()
+
+	if err := s.BlogService.CreateBlog(ctx, blog); err != nil {
+		t.Fatal(err)
+	}
+	return blog
+}
+
+// MustCreateSubBlog creates subBlog with ctx.
+func MustCreateSubBlog(t *testing.T, s *Services, ctx context.Context, subBlog *pa.SubBlog) *pa.SubBlog {
+	t.Helper()
+
+	if err := s.SubBlogService.CreateSubBlog(ctx, subBlog); err != nil {
+		t.Fatal(err)
+	}
+	return subBlog
+}
+
+// MustCreateMedia creates a png media with a webp variant identified by hash.
+func MustCreateMedia(t *testing.T, s *Services, ctx context.Context, hash string) *pa.Media {
+	t.Helper()
+
+	media := &pa.Media{
+		Hash:     hash,
+		Name:     hash + ".png",
+		MimeType: "image/png",
+		Size:     1 << 20,
+		Width:    640,
+		Height:   480,
+		Variants: []*pa.MediaVariant{
+			{Label: "thumbnail", Name: hash + "-320w.webp", MimeType: "image/webp", Size: 1 << 10, Width: 320, Height: 240},
+		},
+	}
+	if err := s.MediaService.CreateMedia(ctx, media); err != nil {
+		t.Fatal(err)
+	}
+	return media
+}
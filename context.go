@@ -1,12 +1,24 @@
 package pa
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 type contextKey int
 
 const (
 	// userContextKey holds the user inside a ctx.
 	userContextKey = contextKey(iota + 1)
+
+	// clientContextKey holds the client inside a ctx.
+	clientContextKey
+
+	// loggerContextKey holds the logger inside a ctx.
+	loggerContextKey
+
+	// requestIDContextKey holds the request id inside a ctx.
+	requestIDContextKey
 )
 
 // NewContextWithUser enriches the context ctx with the user: user under the key userContextKey.
@@ -36,3 +48,40 @@ func IsAdminContext(ctx context.Context) bool {
 	}
 	return false
 }
+
+// NewContextWithClient enriches the context ctx with the client: client under the key clientContextKey.
+func NewContextWithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientContextKey, client)
+}
+
+// ClientFromContext pulls the client from context ctx, empty if the context doesent come from a request.
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientContextKey).(Client)
+	return client
+}
+
+// NewContextWithLogger enriches the context ctx with the logger: logger under the key loggerContextKey.
+func NewContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// LoggerFromContext pulls the logger from context ctx, the default logger if ctx doesent hold one.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// NewContextWithRequestID enriches the context ctx with the request id: id under the key requestIDContextKey.
+// The logger of ctx logs under the request id from then on.
+func NewContextWithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDContextKey, id)
+	return NewContextWithLogger(ctx, LoggerFromContext(ctx).With("requestID", id))
+}
+
+// RequestIDFromContext pulls the request id from context ctx, empty if the context doesent come from a request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
@@ -0,0 +1,21 @@
+package pa
+
+import "time"
+
+// Cursor represents a position in a range ordered by creation time, used for keyset pagination.
+// Keyset pages stay stable while new objects are inserted unlike offset pages.
+//
+// When a filter holds a cursor its offset is ignored, the range is ordered by (CreatedAt, ID) and
+// the count returned along the range is the number of objects left from the cursor on. The ranges
+// listing the latest objects first (audit entries, bans and the trash) page from the latest on.
+type Cursor struct {
+	CreatedAt time.Time `json:"createdAt"` // the deletion time of the trash items.
+	ID        int       `json:"id"`
+
+	// Type breaks the ties of the ranges mixing objects of different types, the ids of the trash
+	// items are only unique per type.
+	Type string `json:"type,omitempty"`
+
+	// Before selects the page ending right before the position instead of the page starting right after it.
+	Before bool `json:"before"`
+}
@@ -7,12 +7,14 @@ import (
 
 // Error codes which map good to http errors.
 const (
-	ECONFLICT       = "conflict"
-	EINTERNAL       = "internal"
-	EINVALID        = "invalid"
-	ENOTFOUND       = "not_found"
-	ENOTIMPLEMENTED = "not_implemented"
-	EUNAUTHORIZED   = "unauthorized"
+	ECONFLICT        = "conflict"
+	EINTERNAL        = "internal"
+	EINVALID         = "invalid"
+	ENOTFOUND        = "not_found"
+	ENOTIMPLEMENTED  = "not_implemented"
+	EUNAUTHORIZED    = "unauthorized"
+	ETOOMANYREQUESTS = "too_many_requests"
+	EFORBIDDEN       = "forbidden"
 )
 
 // Error is a struct containing full details about the error.
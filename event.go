@@ -11,6 +11,12 @@ const (
 
 	// Comments are branched under sub blogs.
 	EventTopicNewComment = "blog:sub_blog:comment:new"
+
+	// Users request an export of their data.
+	EventTopicUserExport = "user:export"
+
+	// Users request the erasure of their account.
+	EventTopicUserErase = "user:erase"
 )
 
 // EventHandler represents a fucntion which is called on each event.
@@ -42,6 +48,19 @@ type CommentPayload struct {
 	SubBlog   *SubBlog `json:"subBlog"`
 }
 
+// UserExportPayload represents the payload carried by a EventTopicUserExport -> ./event.go.
+type UserExportPayload struct {
+	UserID int `json:"userID"`
+}
+
+// UserErasePayload represents the payload carried by a EventTopicUserErase -> ./event.go.
+type UserErasePayload struct {
+	UserID int `json:"userID"`
+
+	// the erasure mode, ie: ErasureModeAnonymize -> ./user.go.
+	Mode string `json:"mode"`
+}
+
 // EventService represents a service which manages events in the system.
 type EventService interface {
 	// Push pushes event in the event queue.
@@ -3,14 +3,67 @@ package pa
 import (
 	"context"
 	"io"
+	"mime"
+	"strings"
+	"time"
 )
 
+// FileInfo describes a file in a FileService.
+type FileInfo struct {
+	// the path of the file in the file service, ie: "/bar/file.txt".
+	Path string `json:"path"`
+
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType"`
+	ModTime     time.Time `json:"modTime"`
+}
+
 // FileService represents a service which manages files in the system.
 // Should be used to create / delete files in a served fs.
 type FileService interface {
-	// CreateFile creates a new file.
+	// CreateFile creates a new file, an existing file gets replaced.
+	// returns EINVALID if the path or content is rejected.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	CreateFile(ctx context.Context, path string, content io.Reader) error
 
-	// DeletePath deletes path.
+	// OpenFile opens the file at path for reading, the caller must close the returned reader.
+	// returns ENOTFOUND if the file doesent exist.
+	OpenFile(ctx context.Context, path string) (io.ReadCloser, *FileInfo, error)
+
+	// Stat returns the file info of the file at path.
+	// returns ENOTFOUND if the file doesent exist.
+	Stat(ctx context.Context, path string) (*FileInfo, error)
+
+	// List returns the files under the directory prefix, ie: "/bar" lists all files in bar and its sub directories.
+	List(ctx context.Context, prefix string) ([]*FileInfo, error)
+
+	// DeleteFile deletes the file at path.
+	// returns ENOTFOUND if the file doesent exist.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	DeleteFile(ctx context.Context, path string) error
 }
+
+// MatchMimeType reports whether mimeType matches any of allowed, ie: "image/*" matches "image/png".
+// an empty allowed list matches any mime type.
+func MatchMimeType(mimeType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+
+	for _, a := range allowed {
+		if a == mediaType {
+			return true
+		}
+		// wildcard subtype, ie: "image/*".
+		if prefix := strings.TrimSuffix(a, "*"); prefix != a && strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
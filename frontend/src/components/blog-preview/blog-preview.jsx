@@ -1,3 +1,9 @@
+// srcSet builds the srcset attribute from the webp variants of a media.
+const srcSet = (media) => media?.variants
+  ?.filter((v) => v.mimeType === "image/webp")
+  .map((v) => `${process.env.NEXT_PUBLIC_API_URL}/v1/media/files/${v.name} ${v.width}w`)
+  .join(", ");
+
 function BlogPreview({ blog }) {
   return (
     <a href={`/blog/${blog.id}`}>
@@ -5,8 +11,9 @@ function BlogPreview({ blog }) {
         <div className="flex flex-col lg:min-w-[60rem] lg:min-h-[20rem] md:flex-row md:max-w-xl rounded-lg shadow-lg hover:bg-gray-100 dark:bg-gray-800 dark:border-gray-700 dark:hover:bg-gray-700">
           <img
             className="w-full lg:min-w-[20rem] md:h-auto object-cover md:w-48 rounded-t-lg md:rounded-none md:rounded-l-lg"
-            src={`${process.env.NEXT_PUBLIC_API_URL}/v1/images/blogs/${blog.id}.jpe`}
-            alt=""
+            src={blog.image ? `${process.env.NEXT_PUBLIC_API_URL}/v1/media/files/${blog.image.name}` : `${process.env.NEXT_PUBLIC_API_URL}/v1/images/blogs/${blog.id}.jpe`}
+            srcSet={srcSet(blog.image)}
+            alt={blog.image?.altText || ""}
           />
           <div className="p-6 flex flex-col justify-start">
             <h5 className="mb-2 text-2xl font-bold tracking-tight text-gray-900 dark:text-white">
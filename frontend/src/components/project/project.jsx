@@ -1,7 +1,13 @@
+// srcSet builds the srcset attribute from the webp variants of a media.
+const srcSet = (media) => media?.variants
+  ?.filter((v) => v.mimeType === "image/webp")
+  .map((v) => `${process.env.NEXT_PUBLIC_API_URL}/v1/media/files/${v.name} ${v.width}w`)
+  .join(", ");
+
 function Project({ project }) {
   return (
     <a href={project.html_url} className="block max-w-lg bg-white rounded-lg border-2 border-gray-200 shadow-xl hover:bg-gray-100 dark:bg-gray-800 dark:border-gray-700 dark:hover:bg-gray-700 transition ease-in-out delay-150 hover:-translate-y-1 hover:scale-109 duration-300">
-      <img className="w-full h-60 pb-1 rounded-lg" src={`${process.env.NEXT_PUBLIC_API_URL}/v1/images/projects/${project.id}.jpe`} alt="Project Image"/>
+      <img className="w-full h-60 pb-1 rounded-lg" src={project.image ? `${process.env.NEXT_PUBLIC_API_URL}/v1/media/files/${project.image.name}` : `${process.env.NEXT_PUBLIC_API_URL}/v1/images/projects/${project.id}.jpe`} srcSet={srcSet(project.image)} alt={project.image?.altText || "Project Image"}/>
       <div className="p-3">
         <h5 className="mb-2 text-2xl font-bold tracking-tight text-gray-900 dark:text-white">{project.name}</h5>
         <p className="font-normal text-gray-700 dark:text-gray-400">{project.description}</p>
@@ -19,19 +19,34 @@ function AuthProvider({ children }) {
   });
   const router = useRouter();
 
-  const logout = () => {
-    fetch(`${process.env.NEXT_PUBLIC_API_URL}/v1/oauth/user/logout`, {
-      method: "DELETE",
-      credentials: "include",
-    }).then((response) => {
-      if (response.ok)
-        setUserData({
-          user: {},
-          isAuth: false,
-          isAdmin: false,
-          pfpUrl: "",
-        });
-    });
+  const logout = async () => {
+    // state changing requests send back the csrf token of the session.
+    const csrf = await fetch(
+      `${process.env.NEXT_PUBLIC_API_URL}/v1/oauth/user/csrf`,
+      {
+        method: "GET",
+        credentials: "include",
+      }
+    );
+    if (!csrf.ok) return;
+    const { token } = await csrf.json();
+
+    const response = await fetch(
+      `${process.env.NEXT_PUBLIC_API_URL}/v1/oauth/user/logout`,
+      {
+        method: "DELETE",
+        credentials: "include",
+        headers: { "X-CSRF-Token": token },
+      }
+    );
+
+    if (response.ok)
+      setUserData({
+        user: {},
+        isAuth: false,
+        isAdmin: false,
+        pfpUrl: "",
+      });
   };
 
   const redirectToProvider = (provider) => {
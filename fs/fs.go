@@ -1,8 +1,12 @@
 package fs
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
+	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,8 +16,18 @@ import (
 
 var _ pa.FileService = (*FileService)(nil)
 
+// FileService represents a service used to manage files under a root directory on the local disk.
+// All paths are confined to the root, writes go through a temporary file which gets renamed into place
+// so a failed write never leaves a truncated file behind.
 type FileService struct {
 	root string
+
+	// MaxSize is the maximum size in bytes of a created file, 0 means no limit.
+	MaxSize int64
+
+	// AllowedMimeTypes restricts the sniffed mime type of created files, ie: "image/png" or "image/*".
+	// an empty list allows any type.
+	AllowedMimeTypes []string
 }
 
 // NewFileService returns a new FileService with root path set to root.
@@ -26,52 +40,179 @@ func NewFileService(root string) *FileService {
 }
 
 // CreateFile creates a new path ending with the file ie: "/bar/baz/file.txt" will create
-// a dir bar with children baz if they dont exist, and a file.txt in baz.
+// a dir bar with children baz if they dont exist, and a file.txt in baz. An existing file gets replaced.
+// returns EINVALID if the path escapes the root, the content is too large or of a disallowed type.
 func (s *FileService) CreateFile(ctx context.Context, path string, content io.Reader) error {
 	if !pa.IsAdminContext(ctx) {
 		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
 	}
 
-	// cut trailing "/" and splice the path on "/".
-	splicedPath := strings.Split(strings.TrimLeft(path, string(filepath.Separator)), string(filepath.Separator))
+	fullPath, err := s.resolve(path)
+	if err != nil {
+		return err
+	}
 
-	// we want to ensure the path leading to the file exists before opening it.
-	if len(splicedPath) > 1 {
-		// cut the file name from the path.
-		dirPath := strings.Join(splicedPath[:len(splicedPath)-1], "")
+	// sniff the type from the first 512 bytes, the most http.DetectContentType considers.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	head = head[:n]
 
-		// path doesent exist: create.
-		if !pathExists(filepath.Join(s.root, dirPath)) {
-			// create dir and dir parents.
-			if err := os.MkdirAll(filepath.Join(s.root, dirPath), 0777); err != nil {
-				return err
-			}
-		}
+	if mimeType := http.DetectContentType(head); !pa.MatchMimeType(mimeType, s.AllowedMimeTypes) {
+		return pa.Errorf(pa.EINVALID, "mime type not allowed: %s.", mimeType)
+	}
+
+	// ensure the path leading to the file exists.
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file in the same directory so the rename stays on the same file system.
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed.
+	defer tmp.Close()
+
+	r := io.MultiReader(bytes.NewReader(head), content)
+	if s.MaxSize > 0 {
+		r = io.LimitReader(r, s.MaxSize+1)
 	}
 
-	// create file with already existing path.
-	f, err := os.Create(filepath.Join(s.root, path))
+	written, err := io.Copy(tmp, r)
 	if err != nil {
 		return err
+	} else if s.MaxSize > 0 && written > s.MaxSize {
+		return pa.Errorf(pa.EINVALID, "file exceeds %d bytes.", s.MaxSize)
+	}
+
+	// flush to disk before the file becomes visible.
+	if err := tmp.Sync(); err != nil {
+		return err
+	} else if err := tmp.Chmod(0644); err != nil {
+		return err
+	} else if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fullPath)
+}
+
+// OpenFile opens the file at path for reading.
+// returns ENOTFOUND if the file doesent exist.
+// returns EINVALID if the path escapes the root.
+func (s *FileService) OpenFile(ctx context.Context, path string) (io.ReadCloser, *pa.FileInfo, error) {
+	fullPath, err := s.resolve(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, pa.Errorf(pa.ENOTFOUND, "file doesent exist.")
+		}
+		return nil, nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	} else if stat.IsDir() { // directories arent files.
+		f.Close()
+		return nil, nil, pa.Errorf(pa.ENOTFOUND, "file doesent exist.")
+	}
+
+	return f, newFileInfo(path, stat), nil
+}
+
+// Stat returns the file info of the file at path.
+// returns ENOTFOUND if the file doesent exist.
+// returns EINVALID if the path escapes the root.
+func (s *FileService) Stat(ctx context.Context, path string) (*pa.FileInfo, error) {
+	fullPath, err := s.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(fullPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, pa.Errorf(pa.ENOTFOUND, "file doesent exist.")
+		}
+		return nil, err
+	} else if stat.IsDir() {
+		return nil, pa.Errorf(pa.ENOTFOUND, "file doesent exist.")
+	}
+
+	return newFileInfo(path, stat), nil
+}
+
+// List returns the files under the directory prefix ordered by path.
+// returns EINVALID if the prefix escapes the root.
+func (s *FileService) List(ctx context.Context, prefix string) ([]*pa.FileInfo, error) {
+	dir := s.root
+	if cleaned := filepath.Clean("/" + filepath.ToSlash(prefix)); cleaned != "/" {
+		var err error
+		if dir, err = s.resolve(prefix); err != nil {
+			return nil, err
+		}
 	}
-	defer f.Close()
 
-	// copy content into file.
-	_, err = io.Copy(f, content)
-	return err
+	files := []*pa.FileInfo{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			// a missing prefix holds no files.
+			if errors.Is(err, os.ErrNotExist) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		// skip directories and in flight writes.
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, newFileInfo("/"+filepath.ToSlash(rel), stat))
+		return nil
+	})
+
+	return files, err
 }
 
 // DeleteFile deletes the file path in the file system.
 // returns ENOTFOUND if file isnt found.
+// returns EINVALID if the path escapes the root.
 func (s *FileService) DeleteFile(ctx context.Context, path string) error {
 	if !pa.IsAdminContext(ctx) {
 		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
 	}
 
+	fullPath, err := s.resolve(path)
+	if err != nil {
+		return err
+	}
+
 	// remove file.
-	if err := os.Remove(filepath.Join(s.root, path)); err != nil {
+	if err := os.Remove(fullPath); err != nil {
 		// parse error.
-		if os.IsNotExist(err) {
+		if errors.Is(err, os.ErrNotExist) {
 			return pa.Errorf(pa.ENOTFOUND, "file doesent exist.")
 		}
 		return err
@@ -79,7 +220,33 @@ func (s *FileService) DeleteFile(ctx context.Context, path string) error {
 	return nil
 }
 
-func pathExists(path string) bool {
-	_, err := os.Stat(path)
-	return !os.IsNotExist(err)
+// resolve maps path to a path on disk confined to the root.
+// returns EINVALID if the path holds ".." elements or doesent name a file.
+func (s *FileService) resolve(path string) (string, error) {
+	slashed := filepath.ToSlash(path)
+	for _, elem := range strings.Split(slashed, "/") {
+		if elem == ".." {
+			return "", pa.Errorf(pa.EINVALID, "invalid path: %q.", path)
+		}
+	}
+
+	// clean on a rooted path, the result can never leave the root.
+	cleaned := filepath.Clean("/" + slashed)
+	if cleaned == "/" {
+		return "", pa.Errorf(pa.EINVALID, "invalid path: %q.", path)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// newFileInfo returns the file info of the file at path with the stats: stat.
+func newFileInfo(path string, stat os.FileInfo) *pa.FileInfo {
+	cleaned := filepath.ToSlash(filepath.Clean("/" + filepath.ToSlash(path)))
+
+	return &pa.FileInfo{
+		Path:        cleaned,
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(cleaned)),
+		ModTime:     stat.ModTime(),
+	}
 }
@@ -46,6 +46,79 @@ func TestCreateFile(t *testing.T) {
 	}
 }
 
+func TestCreateFileNested(t *testing.T) {
+	fs, cln := CreateWithCleanup("./foo")
+	defer cln()
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	// create file under multiple dirs.
+	MustCreateFile(t, fs, adminUsrCtx, "/bar/baz/file.txt", strings.NewReader("FOO BAR"))
+
+	// assert creation.
+	if content, err := os.ReadFile("./foo/bar/baz/file.txt"); err != nil {
+		t.Fatal(err)
+	} else if string(content) != "FOO BAR" {
+		t.Fatalf("content=%q", content)
+	}
+
+	// replace file.
+	MustCreateFile(t, fs, adminUsrCtx, "/bar/baz/file.txt", strings.NewReader("BAZ"))
+	if content, err := os.ReadFile("./foo/bar/baz/file.txt"); err != nil {
+		t.Fatal(err)
+	} else if string(content) != "BAZ" {
+		t.Fatalf("content=%q", content)
+	}
+}
+
+func TestCreateFileBad(t *testing.T) {
+	fileService := fs.NewFileService("./foo")
+	defer os.RemoveAll("./foo")
+
+	fileService.MaxSize = 10
+	fileService.AllowedMimeTypes = []string{"text/*"}
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	t.Run("Bad Create Call (Un Auth)", func(t *testing.T) {
+		if err := fileService.CreateFile(context.Background(), "/file.txt", strings.NewReader("FOO")); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatal("err != EUNAUTHORIZED")
+		}
+	})
+
+	t.Run("Bad Create Call (Path Traversal)", func(t *testing.T) {
+		for _, path := range []string{"/../file.txt", "../../file.txt", "/bar/../../file.txt", "/"} {
+			if err := fileService.CreateFile(adminUsrCtx, path, strings.NewReader("FOO")); pa.ErrorCode(err) != pa.EINVALID {
+				t.Fatalf("%v: err != EINVALID", path)
+			}
+		}
+
+		if _, err := os.Stat("./file.txt"); !os.IsNotExist(err) {
+			os.Remove("./file.txt")
+			t.Fatal("file created outside of root")
+		}
+	})
+
+	t.Run("Bad Create Call (Too Large)", func(t *testing.T) {
+		if err := fileService.CreateFile(adminUsrCtx, "/large.txt", strings.NewReader("FOO BAR BAZ")); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// no partial file left behind.
+		if entries, err := os.ReadDir("./foo"); err != nil {
+			t.Fatal(err)
+		} else if len(entries) != 0 {
+			t.Fatalf("got %v files in root", len(entries))
+		}
+	})
+
+	t.Run("Bad Create Call (Mime Type)", func(t *testing.T) {
+		if err := fileService.CreateFile(adminUsrCtx, "/file.png", strings.NewReader("\x89PNG\r\n\x1a\n")); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+}
+
 func TestDeleteFile(t *testing.T) {
 	fs, _ := CreateWithCleanup("./foo")
 
@@ -65,6 +138,12 @@ func TestDeleteFile(t *testing.T) {
 			t.Fatal("err != ENOTFOUND")
 		}
 	})
+
+	t.Run("Bad Delete Call (Path Traversal)", func(t *testing.T) {
+		if err := fs.DeleteFile(adminUsrCtx, "/../fs.go"); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
 }
 
 func MustCreateFile(t *testing.T, fs pa.FileService, ctx context.Context, path string, content io.Reader) {
@@ -73,3 +152,72 @@ func MustCreateFile(t *testing.T, fs pa.FileService, ctx context.Context, path s
 		t.Fatal(err)
 	}
 }
+
+func TestOpenFile(t *testing.T) {
+	fileService, cln := CreateWithCleanup("./foo")
+	defer cln()
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	MustCreateFile(t, fileService, adminUsrCtx, "/bar/file.txt", strings.NewReader("FOO BAR"))
+
+	t.Run("Ok Open Call", func(t *testing.T) {
+		rc, info, err := fileService.OpenFile(context.Background(), "/bar/file.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+
+		if content, err := io.ReadAll(rc); err != nil {
+			t.Fatal(err)
+		} else if string(content) != "FOO BAR" {
+			t.Fatalf("content=%q", content)
+		} else if info.Path != "/bar/file.txt" || info.Size != 7 || !strings.HasPrefix(info.ContentType, "text/plain") {
+			t.Fatalf("info=%+v", info)
+		}
+	})
+
+	t.Run("Bad Open Call (Not Found)", func(t *testing.T) {
+		// directories arent files.
+		if _, _, err := fileService.OpenFile(context.Background(), "/bar"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		} else if _, err := fileService.Stat(context.Background(), "/missing.txt"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+	})
+
+	t.Run("Bad Open Call (Path Traversal)", func(t *testing.T) {
+		if _, _, err := fileService.OpenFile(context.Background(), "/../fs.go"); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	fileService, cln := CreateWithCleanup("./foo")
+	defer cln()
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	MustCreateFile(t, fileService, adminUsrCtx, "/a.txt", strings.NewReader("A"))
+	MustCreateFile(t, fileService, adminUsrCtx, "/bar/b.txt", strings.NewReader("B"))
+	MustCreateFile(t, fileService, adminUsrCtx, "/bar/baz/c.txt", strings.NewReader("C"))
+
+	if files, err := fileService.List(context.Background(), "/bar"); err != nil {
+		t.Fatal(err)
+	} else if len(files) != 2 || files[0].Path != "/bar/b.txt" || files[1].Path != "/bar/baz/c.txt" {
+		t.Fatalf("got %v files", len(files))
+	}
+
+	if files, err := fileService.List(context.Background(), "/"); err != nil {
+		t.Fatal(err)
+	} else if len(files) != 3 {
+		t.Fatalf("got %v files", len(files))
+	}
+
+	if files, err := fileService.List(context.Background(), "/missing"); err != nil {
+		t.Fatal(err)
+	} else if len(files) != 0 {
+		t.Fatalf("got %v files", len(files))
+	}
+}
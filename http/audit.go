@@ -0,0 +1,161 @@
+package http
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/go-chi/chi/v5"
+)
+
+// auditExportPageSize is the number of entries fetched at a time while exporting the audit log.
+const auditExportPageSize = 100
+
+// registerAuditRoutes registers the audit routes under r.
+func (s *Server) registerAuditRoutes(r chi.Router) {
+	r.Use(s.adminAuthMiddleware)
+
+	r.Get("/", s.handleGetAuditEntries)
+
+	r.Get("/export", s.handleExportAuditEntries)
+}
+
+// handleGetAuditEntries handels GET '/admin/audit/'
+// returns the audit entries, latest first by default.
+// the filter can be passed as a JSON body or as the "actorID", "action", "targetType", "targetID",
+// "offset", "cursor" and query spec params.
+func (s *Server) handleGetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := s.parseAuditFilter(r)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	entries, n, err := s.AuditService.FindAuditEntries(r.Context(), filter)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	page, err := s.newPageResponse(filter.Cursor, filter.SortBy, filter.Offset, n, len(entries), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: entries[i].CreatedAt, ID: entries[i].ID}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, getAuditResponse{
+		N:            n,
+		Entries:      entries,
+		pageResponse: page,
+	})
+}
+
+// handleExportAuditEntries handels GET '/admin/audit/export'
+// streams all the audit entries matching the filter as a CSV attachment, latest first. The filter is
+// passed the same way as to GET '/admin/audit/' but without the offset, cursor, limit and sorting. The
+// pages are read by id so that the entries recorded during the export dont shift them.
+func (s *Server) handleExportAuditEntries(w http.ResponseWriter, r *http.Request) {
+	filter, err := s.parseAuditFilter(r)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	filter.Offset, filter.Cursor, filter.Limit = 0, nil, auditExportPageSize
+	filter.SortBy, filter.Order = "id", pa.OrderDesc
+
+	// fetch the first page before writing the headers so errors can still be sent.
+	entries, _, err := s.AuditService.FindAuditEntries(r.Context(), filter)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "actorID", "action", "targetType", "targetID", "diff", "ip", "userAgent", "createdAt"})
+
+	for len(entries) > 0 {
+		for _, entry := range entries {
+			cw.Write([]string{
+				strconv.Itoa(entry.ID),
+				strconv.Itoa(entry.ActorID),
+				csvCell(entry.Action),
+				csvCell(entry.TargetType),
+				strconv.Itoa(entry.TargetID),
+				csvCell(string(entry.Diff)),
+				csvCell(entry.IP),
+				csvCell(entry.UserAgent),
+				entry.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		cw.Flush()
+
+		if len(entries) < auditExportPageSize {
+			break
+		}
+
+		filter.BeforeID = &entries[len(entries)-1].ID
+		if entries, _, err = s.AuditService.FindAuditEntries(r.Context(), filter); err != nil {
+			// the headers are already sent, cut the export short.
+			return
+		}
+	}
+}
+
+// csvCell escapes the cells which spreadsheets would run as formulas, ie: a user agent of "=cmd|...",
+// by prefixing them with a single quote.
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// parseAuditFilter parses the audit filter of r from its JSON body or its query params.
+// returns EINVALID if the filter is malformed.
+func (s *Server) parseAuditFilter(r *http.Request) (filter pa.AuditFilter, err error) {
+	if r.Header.Get("Content-Type") == "application/json" {
+		filter.Cursor, err = s.decodeListBody(r, &filter)
+		return filter, err
+	}
+
+	q := r.URL.Query()
+	for name, dst := range map[string]**int{
+		"actorID":  &filter.ActorID,
+		"targetID": &filter.TargetID,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, pa.Errorf(pa.EINVALID, "invalid %s format.", name)
+		}
+		*dst = &id
+	}
+	if v := q.Get("action"); v != "" {
+		filter.Action = &v
+	}
+	if v := q.Get("targetType"); v != "" {
+		filter.TargetType = &v
+	}
+	if filter.Offset, filter.Cursor, err = s.parseListQuery(r); err != nil {
+		return filter, err
+	}
+
+	if filter.QuerySpec, err = parseQuerySpec(r); err != nil {
+		return filter, err
+	}
+	filter.Limit = 20
+
+	return filter, nil
+}
@@ -0,0 +1,102 @@
+package http_test
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+// insertingAuditService records a new audit entry after each page read, like an admin working during
+// the export.
+type insertingAuditService struct {
+	pa.AuditService
+	insert func()
+}
+
+func (s *insertingAuditService) FindAuditEntries(ctx context.Context, filter pa.AuditFilter) ([]*pa.AuditEntry, int, error) {
+	defer s.insert()
+	return s.AuditService.FindAuditEntries(ctx, filter)
+}
+
+func TestHandleExportAuditEntries(t *testing.T) {
+	t.Run("Ok Export Call (Concurrent Entries)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		blogService := sqlite.NewBlogService(db)
+		var n int
+		createBlog := func() {
+			n++
+			if err := blogService.CreateBlog(adminUsrCtx, &pa.Blog{Title: "Epic Blog " + strconv.Itoa(n), Description: "Honestly the best blog ever."}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// more entries than fit on a page of the export.
+		for i := 0; i < 250; i++ {
+			createBlog()
+		}
+		s.AuditService = &insertingAuditService{AuditService: s.AuditService, insert: createBlog}
+
+		rows := MustExportAudit(t, s, adminUsrCtx)
+		if len(rows) != 250 {
+			t.Fatalf("rows=%v", len(rows))
+		}
+
+		// latest first, each entry once.
+		for i := 1; i < len(rows); i++ {
+			prev, _ := strconv.Atoi(rows[i-1][0])
+			id, _ := strconv.Atoi(rows[i][0])
+			if id >= prev {
+				t.Fatalf("id=%v after id=%v", id, prev)
+			}
+		}
+	})
+
+	t.Run("Ok Export Call (Formula Cells)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		clientCtx := pa.NewContextWithClient(adminUsrCtx, pa.Client{
+			IP:        "192.0.2.1",
+			UserAgent: `=HYPERLINK("http://evil.com")`,
+		})
+		blog := &pa.Blog{Title: "@SUM(A1:A2)", Description: "+1"}
+		if err := sqlite.NewBlogService(db).CreateBlog(clientCtx, blog); err != nil {
+			t.Fatal(err)
+		}
+
+		rows := MustExportAudit(t, s, adminUsrCtx)
+		if len(rows) != 1 {
+			t.Fatalf("rows=%v", rows)
+		} else if v := rows[0][7]; v != `'=HYPERLINK("http://evil.com")` {
+			t.Fatalf("userAgent=%v", v)
+		} else if v := rows[0][6]; v != "192.0.2.1" {
+			t.Fatalf("ip=%v", v)
+		}
+	})
+}
+
+// MustExportAudit exports the audit log through the api and returns the CSV rows without the header.
+func MustExportAudit(tb testing.TB, s *pahttp.Server, adminUsrCtx context.Context) [][]string {
+	tb.Helper()
+
+	resp := Serve(s, NewBearerRequest(http.MethodGet, "/v1/admin/audit/export", "", MustFindAPIKey(tb, s, adminUsrCtx)))
+	if resp.StatusCode != http.StatusOK {
+		tb.Fatalf("status=%v", resp.StatusCode)
+	}
+
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		tb.Fatal(err)
+	} else if len(rows) == 0 || rows[0][0] != "id" {
+		tb.Fatalf("rows=%v", rows)
+	}
+	return rows[1:]
+}
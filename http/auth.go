@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/go-chi/chi/v5"
@@ -24,6 +25,7 @@ func (s *Server) registerAuthRoutes(r chi.Router) {
 		r.Use(s.requireAuthMiddleware)
 		r.Get("/me", s.handleMe)
 		r.Get("/check-auth", s.handleCheckAuth)
+		r.Get("/csrf", s.handleGetCSRFToken)
 	})
 }
 
@@ -151,6 +153,13 @@ func (s *Server) handleGithubCallback(w http.ResponseWriter, r *http.Request) {
 	ses.State = ""
 	// set userID.
 	ses.UserID = auth.UserID // populated on creation
+	// sqlite stores timestamps to the second, sessions created within the second of a ban are revoked.
+	ses.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	// rotate the csrf token, tokens from before the login arent valid.
+	if ses.CSRFToken, err = newCSRFToken(); err != nil {
+		SendError(w, r, err)
+		return
+	}
 
 	if err := s.setSession(w, ses); err != nil {
 		SendError(w, r, err)
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/go-chi/chi/v5"
+)
+
+// registerBackupRoutes registers the backup routes under r.
+func (s *Server) registerBackupRoutes(r chi.Router) {
+	r.Use(s.adminAuthMiddleware)
+
+	r.Get("/", s.handleGetBackups)
+
+	r.Post("/", s.handleCreateBackup)
+}
+
+// handleGetBackups handels GET '/admin/backups/'
+// returns the stored database snapshots, latest first.
+func (s *Server) handleGetBackups(w http.ResponseWriter, r *http.Request) {
+	if s.BackupService == nil {
+		SendError(w, r, pa.Errorf(pa.ENOTIMPLEMENTED, "backups arent supported by the database driver."))
+		return
+	}
+
+	backups, err := s.BackupService.FindBackups(r.Context())
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, getBackupsResponse{
+		N:       len(backups),
+		Backups: backups,
+	})
+}
+
+// handleCreateBackup handels POST '/admin/backups/'
+// snapshots the database and responds with the created backup.
+func (s *Server) handleCreateBackup(w http.ResponseWriter, r *http.Request) {
+	if s.BackupService == nil {
+		SendError(w, r, pa.Errorf(pa.ENOTIMPLEMENTED, "backups arent supported by the database driver."))
+		return
+	}
+
+	backup, err := s.BackupService.CreateBackup(r.Context())
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	SendJSON(w, backup)
+}
+
+// backupJob represents a scheduled job snapshotting the database.
+func (s *Server) backupJob() {
+	logger := slog.Default().With("job", "backup")
+	logger.Info("running job.")
+	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	backup, err := s.BackupService.CreateBackup(adminCtx)
+	if err != nil {
+		logger.Error("CreateBackup failed.", "err", err)
+		return
+	}
+	logger.Info("created backup.", "name", backup.Name)
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/go-chi/chi/v5"
+)
+
+// registerBanRoutes registers the ban routes under r.
+func (s *Server) registerBanRoutes(r chi.Router) {
+	r.Use(s.adminAuthMiddleware)
+
+	r.Get("/", s.handleGetBans)
+	r.Post("/", s.handleCreateBan)
+	r.Delete("/{banID}", s.handleDeleteBan)
+}
+
+// handleGetBans handels GET '/admin/bans/'
+// returns the bans, latest first.
+// the "userID" query param filters on the banned user and "active" (true, false) on the expiry, the
+// pages are read with the "offset" or the "cursor" query params.
+func (s *Server) handleGetBans(w http.ResponseWriter, r *http.Request) {
+	filter := pa.BanFilter{
+		Limit: 20,
+	}
+
+	if v := r.URL.Query().Get("userID"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			SendError(w, r, pa.Errorf(pa.EINVALID, "invalid userID format"))
+			return
+		}
+		filter.UserID = &id
+	}
+	if v := r.URL.Query().Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			SendError(w, r, pa.Errorf(pa.EINVALID, "invalid active format"))
+			return
+		}
+		filter.Active = &active
+	}
+	offset, cursor, err := s.parseListQuery(r)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	filter.Offset, filter.Cursor = offset, cursor
+
+	bans, n, err := s.BanService.FindBans(r.Context(), filter)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	page, err := s.newPageResponse(filter.Cursor, "", filter.Offset, n, len(bans), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: bans[i].CreatedAt, ID: bans[i].ID}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, getBansResponse{
+		N:            n,
+		Bans:         bans,
+		pageResponse: page,
+	})
+}
+
+// handleCreateBan handels POST '/admin/bans/'
+// bans a user with the request body and responds with the created ban.
+func (s *Server) handleCreateBan(w http.ResponseWriter, r *http.Request) {
+	var ban pa.Ban
+	if err := json.NewDecoder(r.Body).Decode(&ban); err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid JSON body"))
+		return
+	}
+
+	if ban.UserID == pa.UserIDFromContext(r.Context()) {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "cant ban yourself."))
+		return
+	}
+
+	if err := s.BanService.CreateBan(r.Context(), &ban); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	SendJSON(w, ban)
+}
+
+// handleDeleteBan handels DELETE '/admin/bans/{banID}'
+// lifts the ban pointed to by banID, revoked sessions and api keys stay revoked.
+func (s *Server) handleDeleteBan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "banID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	if err := s.BanService.DeleteBan(r.Context(), id); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findFullBan returns the active full ban of the user, nil if the user isnt fully banned.
+func (s *Server) findFullBan(ctx context.Context, userID int) (*pa.Ban, error) {
+	ban, err := s.BanService.FindActiveBan(ctx, userID)
+	if pa.ErrorCode(err) == pa.ENOTFOUND {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if ban.Scope != pa.BanScopeFull {
+		return nil, nil
+	}
+	return ban, nil
+}
+
+// banError returns the error sent to the banned users.
+func banError(ban *pa.Ban) error {
+	if ban.ExpiresAt == nil {
+		return pa.Errorf(pa.EFORBIDDEN, "user is banned: %s.", ban.Reason)
+	}
+	return pa.Errorf(pa.EFORBIDDEN, "user is banned until %s: %s.", ban.ExpiresAt.Format(time.RFC3339), ban.Reason)
+}
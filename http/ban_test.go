@@ -0,0 +1,205 @@
+package http_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+	"github.com/Lambels/patrickarvatu.com/mock"
+	tmock "github.com/stretchr/testify/mock"
+)
+
+func TestBanEnforcement(t *testing.T) {
+	t.Run("Bad Get Call (Session Before Full Ban)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		user := pa.UserFromContext(MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"}))
+		cookie := MustLoginAt(t, s, user.ID, "token", time.Now().Add(-time.Minute))
+
+		newRequest := func(cookie *http.Cookie) *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/v1/users/"+strconv.Itoa(user.ID), nil)
+			r.AddCookie(cookie)
+			return r
+		}
+		if resp := Serve(s, newRequest(cookie)); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		MustBan(t, s, MustFindAPIKey(t, s, adminUsrCtx), user.ID, pa.BanScopeFull)
+
+		// the session got revoked by the ban, it gets dropped.
+		resp := Serve(s, newRequest(cookie))
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if cookies := resp.Cookies(); len(cookies) != 1 {
+			t.Fatalf("cookies=%v", cookies)
+		} else if ses, err := s.GetSession(newRequest(cookies[0])); err != nil || ses.UserID != 0 {
+			t.Fatalf("session=%+v err=%v", ses, err)
+		}
+
+		// sessions created during the ban are refused too.
+		if resp := Serve(s, newRequest(MustLogin(t, s, user.ID, "token"))); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Bad Get Call (API Key Before Full Ban)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+		user := pa.UserFromContext(usrCtx)
+		oldAPIKey := MustFindAPIKey(t, s, usrCtx)
+
+		MustBan(t, s, MustFindAPIKey(t, s, adminUsrCtx), user.ID, pa.BanScopeFull)
+
+		// the ban regenerated the api key.
+		newAPIKey := MustFindAPIKey(t, s, usrCtx)
+		if newAPIKey == oldAPIKey {
+			t.Fatal("api key not regenerated")
+		}
+
+		if resp := Serve(s, NewBearerRequest(http.MethodGet, "/v1/users/"+strconv.Itoa(user.ID), "", oldAPIKey)); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if resp := Serve(s, NewBearerRequest(http.MethodGet, "/v1/users/"+strconv.Itoa(user.ID), "", newAPIKey)); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Bad Post Call (Comment Ban)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+		user := pa.UserFromContext(usrCtx)
+		subBlog := MustCreateSubBlog(t, db, adminUsrCtx)
+		apiKey := MustFindAPIKey(t, s, usrCtx)
+
+		MustBan(t, s, MustFindAPIKey(t, s, adminUsrCtx), user.ID, pa.BanScopeComment)
+
+		resp := Serve(s, NewBearerRequest(http.MethodPost, "/v1/comments/", `{"subBlogID":`+strconv.Itoa(subBlog.ID)+`,"content":"nice"}`, apiKey))
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if trace := MustReadErrorTrace(t, resp); trace != "user is banned: spam." {
+			t.Fatalf("trace=%v", trace)
+		}
+
+		// comment bans keep the api key working for everything else.
+		if MustFindAPIKey(t, s, usrCtx) != apiKey {
+			t.Fatal("api key regenerated")
+		}
+		for _, path := range []string{
+			"/v1/users/" + strconv.Itoa(user.ID),
+			"/v1/comments/",
+			"/v1/sub-blogs/" + strconv.Itoa(subBlog.ID),
+		} {
+			if resp := Serve(s, NewBearerRequest(http.MethodGet, path, "", apiKey)); resp.StatusCode != http.StatusOK {
+				t.Fatalf("path=%v status=%v", path, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("Ok Get Call (Lifted Ban)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+		user := pa.UserFromContext(usrCtx)
+		subBlog := MustCreateSubBlog(t, db, adminUsrCtx)
+		adminAPIKey := MustFindAPIKey(t, s, adminUsrCtx)
+
+		eventService := &mock.EventService{}
+		eventService.On("Push", tmock.Anything, tmock.Anything).Return(nil)
+		s.EventService = eventService
+
+		full := MustBan(t, s, adminAPIKey, user.ID, pa.BanScopeFull)
+		comment := MustBan(t, s, adminAPIKey, user.ID, pa.BanScopeComment)
+
+		path := "/v1/users/" + strconv.Itoa(user.ID)
+		if resp := Serve(s, NewBearerRequest(http.MethodGet, path, "", MustFindAPIKey(t, s, usrCtx))); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		for _, ban := range []*pa.Ban{full, comment} {
+			if resp := Serve(s, NewBearerRequest(http.MethodDelete, "/v1/admin/bans/"+strconv.Itoa(ban.ID), "", adminAPIKey)); resp.StatusCode != http.StatusNoContent {
+				t.Fatalf("status=%v", resp.StatusCode)
+			}
+		}
+
+		// the current api key and new sessions work again.
+		apiKey := MustFindAPIKey(t, s, usrCtx)
+		if resp := Serve(s, NewBearerRequest(http.MethodGet, path, "", apiKey)); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.AddCookie(MustLogin(t, s, user.ID, "token"))
+		if resp := Serve(s, r); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		resp := Serve(s, NewBearerRequest(http.MethodPost, "/v1/comments/", `{"subBlogID":`+strconv.Itoa(subBlog.ID)+`,"content":"nice"}`, apiKey))
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+}
+
+// MustBan bans the user with id: userID through the admin api and returns the ban.
+func MustBan(tb testing.TB, s *pahttp.Server, adminAPIKey string, userID int, scope string) *pa.Ban {
+	tb.Helper()
+
+	body, err := json.Marshal(pa.Ban{UserID: userID, Reason: "spam", Scope: scope})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	resp := Serve(s, NewBearerRequest(http.MethodPost, "/v1/admin/bans/", string(body), adminAPIKey))
+	if resp.StatusCode != http.StatusCreated {
+		tb.Fatalf("status=%v", resp.StatusCode)
+	}
+
+	var ban pa.Ban
+	if err := json.NewDecoder(resp.Body).Decode(&ban); err != nil {
+		tb.Fatal(err)
+	}
+	return &ban
+}
+
+// NewBearerRequest returns a request authentificated by apiKey.
+func NewBearerRequest(method, path, body, apiKey string) *http.Request {
+	var r *http.Request
+	if body == "" {
+		r = httptest.NewRequest(method, path, nil)
+	} else {
+		r = httptest.NewRequest(method, path, strings.NewReader(body))
+	}
+	r.Header.Set("Authorization", "Bearer "+apiKey)
+	return r
+}
+
+// MustReadErrorTrace returns the message of the error response resp.
+func MustReadErrorTrace(tb testing.TB, resp *http.Response) string {
+	tb.Helper()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var v struct {
+		Trace string `json:"trace"`
+	}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		tb.Fatalf("body=%s: %v", buf, err)
+	}
+	return v.Trace
+}
@@ -2,8 +2,6 @@ package http
 
 import (
 	"encoding/json"
-	"fmt"
-	"mime"
 	"net/http"
 	"strconv"
 
@@ -14,7 +12,7 @@ import (
 // registerBlogRoutes registers the blog routes under r.
 func (s *Server) registerBlogRoutes(r chi.Router) {
 	r.Get("/", s.handleGetBlogs)
-	r.Get("/{blogID}", s.handleGetBlog)
+	r.Get("/{blogIDOrSlug}", s.handleGetBlog)
 	r.Get("/{blogID}/sub-blogs", s.handleGetSubBlogs)
 
 	r.Route("/", func(r chi.Router) {
@@ -25,6 +23,7 @@ func (s *Server) registerBlogRoutes(r chi.Router) {
 		r.Patch("/{blogID}", s.handleUpdateBlog)
 
 		r.Put("/{blogID}/image", s.handleAttachBlogImage)
+		r.Put("/{blogID}/order", s.handleReorderBlog)
 
 		r.Delete("/{blogID}", s.handleDeleteBlog)
 		r.Delete("/{blogID}/image", s.handleDeleteBlogImage)
@@ -32,30 +31,48 @@ func (s *Server) registerBlogRoutes(r chi.Router) {
 }
 
 // handleGetBlogs handels GET '/blogs/'
-// retrieves blogs based on request body.
+// retrieves blogs based on request body, the "include" query param (subBlogs, comments) selects the loaded relations.
+// the "sortBy", "order", "createdAfter", "createdBefore" and "titleContains" query params mirror the filter.
 func (s *Server) handleGetBlogs(w http.ResponseWriter, r *http.Request) {
 	var filter pa.BlogFilter
 
 	// get filter params from:
 	switch r.Header.Get("Content-Type") {
 	case "application/json":
-		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
-			SendError(w, r, pa.Errorf(pa.EINVALID, "invalid JSON body"))
+		cursor, err := s.decodeListBody(r, &filter)
+		if err != nil {
+			SendError(w, r, err)
 			return
 		}
+		filter.Cursor = cursor
 
 	default:
-		var offset int
-		var err error
-		if v := r.URL.Query().Get("offset"); v != "" {
-			if offset, err = strconv.Atoi(v); err != nil {
-				SendError(w, r, pa.Errorf(pa.EINVALID, "invalid offset format"))
-				return
-			}
+		offset, cursor, err := s.parseListQuery(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		include, err := parseInclude(r, "subBlogs", "comments")
+		if err != nil {
+			SendError(w, r, err)
+			return
 		}
 
+		spec, err := parseQuerySpec(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		filter.QuerySpec = spec
 		filter.Offset = offset
+		filter.Cursor = cursor
 		filter.Limit = 20
+		if v := r.URL.Query().Get("titleContains"); v != "" {
+			filter.TitleContains = &v
+		}
+		filter.IncludeSubBlogs, filter.IncludeComments = include["subBlogs"], include["comments"]
 	}
 
 	// fetch blogs from database.
@@ -65,21 +82,49 @@ func (s *Server) handleGetBlogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	page, err := s.newPageResponse(filter.Cursor, filter.SortBy, filter.Offset, n, len(blogs), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: blogs[i].CreatedAt, ID: blogs[i].ID}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
 	SendJSON(w, getBlogsResponse{
-		N:     n,
-		Blogs: blogs,
+		N:            n,
+		Blogs:        blogs,
+		pageResponse: page,
 	})
 }
 
-// handleGetBlog handels GET '/blogs/{blogID}'
-// retrieves blog with id: blogID.
+// handleGetBlog handels GET '/blogs/{blogIDOrSlug}'
+// checks if blogIDOrSlug is an integer, if not returns blog with slug: blogIDOrSlug
+// else returns blog with id: blogIDOrSlug.
+// redirects with 301 to the current slug if blogIDOrSlug is an old slug of the blog.
 func (s *Server) handleGetBlog(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "blogID"))
-	if err != nil {
-		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+	param := chi.URLParam(r, "blogIDOrSlug")
+
+	id, err := strconv.Atoi(param)
+	if err != nil { // we have a slug.
+		// fetch blog from database.
+		blog, err := s.BlogService.FindBlogBySlug(r.Context(), param)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		// the blog got renamed, point to the current slug.
+		if blog.Slug != param {
+			redirectToSlug(w, r, param, blog.Slug)
+			return
+		}
+
+		// send response.
+		SendJSON(w, blog)
 		return
 	}
 
+	// we have a integer.
 	// fetch blog from database.
 	blog, err := s.BlogService.FindBlogByID(r.Context(), id)
 	if err != nil {
@@ -137,8 +182,35 @@ func (s *Server) handleUpdateBlog(w http.ResponseWriter, r *http.Request) {
 	SendJSON(w, blog)
 }
 
+// handleReorderBlog handels PUT '/blogs/{blogID}/order'
+// sets the order of the sub blogs in the blog with id: blogID to the order in the request body.
+func (s *Server) handleReorderBlog(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "blogID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	// decode body.
+	var req reorderBlogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid JSON body"))
+		return
+	}
+
+	// reorder sub blogs.
+	blog, err := s.BlogService.ReorderSubBlogs(r.Context(), id, req.SubBlogIDs)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	// send response.
+	SendJSON(w, blog)
+}
+
 // handleDeleteBlog handels DELETE '/blogs/{blogID}'
-// deletes blog with id: blogID.
+// moves the blog with id: blogID to the trash.
 func (s *Server) handleDeleteBlog(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "blogID"))
 	if err != nil {
@@ -155,7 +227,7 @@ func (s *Server) handleDeleteBlog(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleAttachBlogImage handels PUT '/blogs/{blogID}/image'
-// creates a new image from the request body named after the blog id.
+// uploads the request body to the media library and sets it as the image of the blog.
 func (s *Server) handleAttachBlogImage(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "blogID"))
 	if err != nil {
@@ -166,31 +238,43 @@ func (s *Server) handleAttachBlogImage(w http.ResponseWriter, r *http.Request) {
 	if _, err := s.BlogService.FindBlogByID(r.Context(), id); err != nil { // cant attach image to un unexisting blog.
 		SendError(w, r, err)
 		return
-	} else if ext, err := mime.ExtensionsByType(r.Header.Get("Content-Type")); err != nil {
+	}
+
+	media, _, err := s.uploadMedia(r.Context(), r.Body, r.URL.Query().Get("altText"))
+	if err != nil {
 		SendError(w, r, err)
 		return
-	} else if len(ext) == 0 {
-		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid mime type"))
-		return
-	} else if err := s.BlogsFileSystem.CreateFile(r.Context(), "/"+fmt.Sprint(id)+ext[0], r.Body); err != nil {
+	}
+
+	// replaces the previous image of the blog.
+	if err := s.MediaService.CreateMediaRef(r.Context(), media.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: id}); err != nil {
 		SendError(w, r, err)
 		return
 	}
+
 	w.WriteHeader(http.StatusCreated)
+	SendJSON(w, media)
 }
 
 // handleDeleteBlogImage handels DELETE '/blogs/{blogID}/image'
-// deletes the image with name: id and extension: Content-Type.
+// removes the image of the blog, the media stays in the media library.
 func (s *Server) handleDeleteBlogImage(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "blogID")
+	id, err := strconv.Atoi(chi.URLParam(r, "blogID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
 
-	if ext, err := mime.ExtensionsByType(r.Header.Get("Content-Type")); err != nil {
+	blog, err := s.BlogService.FindBlogByID(r.Context(), id)
+	if err != nil {
 		SendError(w, r, err)
 		return
-	} else if len(ext) == 0 {
-		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid mime type"))
+	} else if blog.Image == nil {
+		SendError(w, r, pa.Errorf(pa.ENOTFOUND, "blog has no image."))
 		return
-	} else if err := s.BlogsFileSystem.DeleteFile(r.Context(), "/"+id+ext[0]); err != nil {
+	}
+
+	if err := s.MediaService.DeleteMediaRef(r.Context(), blog.Image.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: id}); err != nil {
 		SendError(w, r, err)
 		return
 	}
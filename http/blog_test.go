@@ -1 +1,55 @@
 package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestHandleGetBlog(t *testing.T) {
+	t.Run("Ok Get Call (Old Slug)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{
+			Name:  "Lambels",
+			Email: adminEmail,
+		})
+
+		blogService := sqlite.NewBlogService(db)
+		blog := &pa.Blog{
+			Title:       "Old Title",
+			Description: "Honestly the best blog ever.",
+		}
+		if err := blogService.CreateBlog(adminUsrCtx, blog); err != nil {
+			t.Fatal(err)
+		}
+
+		title := "New Title"
+		if _, err := blogService.UpdateBlog(adminUsrCtx, blog.ID, pa.BlogUpdate{Title: &title}); err != nil {
+			t.Fatal(err)
+		}
+
+		resp := Serve(s, httptest.NewRequest(http.MethodGet, "/v1/blogs/old-title?include=subBlogs", nil))
+		if resp.StatusCode != http.StatusMovedPermanently {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if location := resp.Header.Get("Location"); location != "/v1/blogs/new-title?include=subBlogs" {
+			t.Fatalf("location=%v", location)
+		}
+
+		// the current slug is served.
+		if resp := Serve(s, httptest.NewRequest(http.MethodGet, "/v1/blogs/new-title", nil)); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Bad Get Call (Unknown Slug)", func(t *testing.T) {
+		s, _ := MustOpenServer(t, nil)
+
+		if resp := Serve(s, httptest.NewRequest(http.MethodGet, "/v1/blogs/unknown", nil)); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+}
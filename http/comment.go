@@ -23,6 +23,8 @@ func (s *Server) registerCommentRoutes(r chi.Router) {
 
 // handleGetSubComments handels GET '/comments/', '/sub-blogs/{subBlogID}/comments'
 // looks for subBlogID and over writes it with anything passed in the body.
+// the "include" query param (user) selects the loaded relations.
+// the "sortBy", "order", "createdAfter" and "createdBefore" query params mirror the filter.
 func (s *Server) handleGetComments(w http.ResponseWriter, r *http.Request) {
 	var filter pa.CommentFilter
 
@@ -40,23 +42,37 @@ func (s *Server) handleGetComments(w http.ResponseWriter, r *http.Request) {
 	// get filter params from:
 	switch r.Header.Get("Content-Type") {
 	case "application/json":
-		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		cursor, err := s.decodeListBody(r, &filter)
+		if err != nil {
 			SendError(w, r, err)
 			return
 		}
+		filter.Cursor = cursor
 
 	default:
-		var offset int
-		var err error
-		if v := r.URL.Query().Get("offset"); v != "" {
-			if offset, err = strconv.Atoi(v); err != nil {
-				SendError(w, r, pa.Errorf(pa.EINVALID, "invalid offset format"))
-				return
-			}
+		offset, cursor, err := s.parseListQuery(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		include, err := parseInclude(r, "user")
+		if err != nil {
+			SendError(w, r, err)
+			return
 		}
 
+		spec, err := parseQuerySpec(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		filter.QuerySpec = spec
 		filter.Offset = offset
+		filter.Cursor = cursor
 		filter.Limit = 20
+		filter.IncludeUser = include["user"]
 	}
 
 	// fetch data from database.
@@ -66,9 +82,18 @@ func (s *Server) handleGetComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	page, err := s.newPageResponse(filter.Cursor, filter.SortBy, filter.Offset, n, len(comments), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: comments[i].CreatedAt, ID: comments[i].ID}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
 	SendJSON(w, getCommentsResponse{
-		N:        n,
-		Comments: comments,
+		N:            n,
+		Comments:     comments,
+		pageResponse: page,
 	})
 }
 
@@ -93,15 +118,43 @@ func (s *Server) handleGetComment(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleCreateComment handels POST '/comments/'
-// creates a comment with the request body, pushes a pa.EventTopicNewComment -> ./event.go and
-// creates a subscription on the sub blog on which the comment exists.
+// runs the request body through the spam filter and creates the comment, rejected comments arent
+// created and held comments wait for the admin user to approve them (202). Pushes a
+// pa.EventTopicNewComment -> ./event.go for approved comments and creates a subscription on the
+// sub blog on which the comment exists.
+// the "website" field of the body is a honeypot, left empty by the frontend.
 func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
-	var comment pa.Comment
+	var body struct {
+		pa.Comment
+		Website string `json:"website"`
+	}
 	// decode body.
-	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		SendError(w, r, err)
 		return
 	}
+	comment := body.Comment
+	comment.Status = pa.CommentStatusApproved // the status is up to the spam filter.
+
+	if s.SpamFilter != nil {
+		verdict, err := s.SpamFilter.FilterSpam(r.Context(), &pa.SpamCheck{
+			Comment:  &comment,
+			Author:   pa.UserFromContext(r.Context()),
+			Honeypot: body.Website,
+		})
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		switch verdict.Verdict {
+		case pa.SpamVerdictReject:
+			SendError(w, r, pa.Errorf(pa.EINVALID, "comment rejected as spam."))
+			return
+		case pa.SpamVerdictHold:
+			comment.Status = pa.CommentStatusHeld
+		}
+	}
 
 	// create comment.
 	if err := s.CommentService.CreateComment(r.Context(), &comment); err != nil {
@@ -109,15 +162,17 @@ func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// push event.
-	if err := s.EventService.Push(r.Context(), pa.Event{
-		Topic: pa.EventTopicNewComment,
-		Payload: pa.CommentPayload{
-			SubBlogID: comment.SubBlogID, // attach only sub blog id to payload for easy redirect.
-		},
-	}); err != nil {
-		SendError(w, r, err)
-		return
+	// push event, subscribers hear of held comments once approved.
+	if comment.Status == pa.CommentStatusApproved {
+		if err := s.EventService.Push(r.Context(), pa.Event{
+			Topic: pa.EventTopicNewComment,
+			Payload: pa.CommentPayload{
+				SubBlogID: comment.SubBlogID, // attach only sub blog id to payload for easy redirect.
+			},
+		}); err != nil {
+			SendError(w, r, err)
+			return
+		}
 	}
 
 	// create subscription.
@@ -130,6 +185,11 @@ func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
 		SendError(w, r, err)
 		return
 	}
+
+	if comment.Status == pa.CommentStatusHeld {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -163,7 +223,7 @@ func (s *Server) handleUpdateComment(w http.ResponseWriter, r *http.Request) {
 // TODO: revise code
 
 // handleDeleteComment handels DELETE '/comments/{commentID}'
-// permanently deletes the comment pointed to by commentID and deletes any existing subscription on the sub blog on which
+// moves the comment pointed to by commentID to the trash and deletes any existing subscription on the sub blog on which
 // the comment lives if there are no more left.
 func (s *Server) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "commentID"))
@@ -198,7 +258,9 @@ func (s *Server) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
 
 	} else if len(comments) == 0 { // we have no more comments on sub blog.
 		// delete subscription if exists.
+		topic := pa.EventTopicNewComment
 		if subs, _, err := s.SubscriptionService.FindSubscriptions(r.Context(), pa.SubscriptionFilter{ // check for subscription on sub blog.
+			Topic:  &topic,
 			UserID: &uID,
 			Payload: pa.CommentPayload{
 				SubBlogID: comment.SubBlogID,
@@ -1 +1,58 @@
 package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestHandleDeleteComment(t *testing.T) {
+	t.Run("Ok Delete Call (Own Held Comment)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+		subBlog := MustCreateSubBlog(t, db, adminUsrCtx)
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "buy now", Status: pa.CommentStatusHeld}
+		if err := sqlite.NewCommentService(db).CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		// the author takes back their comment before the admin gets to it.
+		r := httptest.NewRequest(http.MethodDelete, "/v1/comments/"+strconv.Itoa(comment.ID), nil)
+		r.Header.Set("Authorization", "Bearer "+MustFindAPIKey(t, s, usrCtx))
+		if resp := Serve(s, r); resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		if _, err := s.CommentService.FindCommentByID(adminUsrCtx, comment.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+	})
+
+	t.Run("Bad Delete Call (Other Held Comment)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+		otherCtx := MustCreateUser(t, db, &pa.User{Name: "Jane Doe", Email: "jane@doe.com"})
+		subBlog := MustCreateSubBlog(t, db, adminUsrCtx)
+
+		comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "buy now", Status: pa.CommentStatusHeld}
+		if err := sqlite.NewCommentService(db).CreateComment(usrCtx, comment); err != nil {
+			t.Fatal(err)
+		}
+
+		// held comments stay hidden from the other users.
+		r := httptest.NewRequest(http.MethodDelete, "/v1/comments/"+strconv.Itoa(comment.ID), nil)
+		r.Header.Set("Authorization", "Bearer "+MustFindAPIKey(t, s, otherCtx))
+		if resp := Serve(s, r); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// CSRFHeader is the header carrying the csrf token of the session on state changing requests.
+const CSRFHeader = "X-CSRF-Token"
+
+// newCSRFToken returns a new random csrf token.
+func newCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// handleGetCSRFToken handels GET '/oauth/user/csrf'.
+// returns the csrf token of the session, sessions created before the tokens get one.
+func (s *Server) handleGetCSRFToken(w http.ResponseWriter, r *http.Request) {
+	ses, err := s.getSession(r)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if ses.CSRFToken == "" {
+		if ses.CSRFToken, err = newCSRFToken(); err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		if err := s.setSession(w, ses); err != nil {
+			SendError(w, r, err)
+			return
+		}
+	}
+
+	SendJSON(w, getCSRFTokenResponse{
+		Token: ses.CSRFToken,
+	})
+}
+
+// csrfMiddleware rejects the cookie authentificated state changing requests which dont send back the
+// csrf token of their session under CSRFHeader. Requests authentificated by api key arent sent by
+// browsers on their own and skip the check.
+func (s *Server) csrfMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// unauthentificated requests have nothing to forge.
+		ses, _ := s.getSession(r)
+		if ses.UserID == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.Header.Get(CSRFHeader)
+		if ses.CSRFToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(ses.CSRFToken)) != 1 {
+			SendError(w, r, pa.Errorf(pa.EFORBIDDEN, "invalid csrf token."))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,148 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	s, db := MustOpenServer(t, nil)
+
+	usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+	user := pa.UserFromContext(usrCtx)
+	cookie := MustLogin(t, s, user.ID, "token")
+
+	// refreshing the api key is a cookie authentificated state changing request.
+	newRequest := func(method string) *http.Request {
+		r := httptest.NewRequest(method, "/v1/users/"+strconv.Itoa(user.ID)+"/refresh-api-key", nil)
+		r.AddCookie(cookie)
+		return r
+	}
+
+	t.Run("Bad Patch Call (Missing Token)", func(t *testing.T) {
+		if resp := Serve(s, newRequest(http.MethodPatch)); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Bad Patch Call (Mismatched Token)", func(t *testing.T) {
+		r := newRequest(http.MethodPatch)
+		r.Header.Set(pahttp.CSRFHeader, "other token")
+
+		if resp := Serve(s, r); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Bad Patch Call (Session Without Token)", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPatch, "/v1/users/"+strconv.Itoa(user.ID)+"/refresh-api-key", nil)
+		r.AddCookie(MustLogin(t, s, user.ID, ""))
+		r.Header.Set(pahttp.CSRFHeader, "") // an empty token never matches.
+
+		if resp := Serve(s, r); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Ok Patch Call", func(t *testing.T) {
+		r := newRequest(http.MethodPatch)
+		r.Header.Set(pahttp.CSRFHeader, "token")
+
+		if resp := Serve(s, r); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Ok Patch Call (Bearer)", func(t *testing.T) {
+		current, err := s.UserService.FindUserByID(usrCtx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// api keys arent sent by browsers on their own.
+		r := httptest.NewRequest(http.MethodPatch, "/v1/users/"+strconv.Itoa(user.ID)+"/refresh-api-key", nil)
+		r.Header.Set("Authorization", "Bearer "+current.APIKey)
+
+		if resp := Serve(s, r); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Ok Get Call (Safe Method)", func(t *testing.T) {
+		if resp := Serve(s, newRequest(http.MethodGet)); resp.StatusCode == http.StatusForbidden {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Ok Get Token Call", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/oauth/user/csrf", nil)
+		r.AddCookie(cookie)
+
+		resp := Serve(s, r)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		var body struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		} else if body.Token != "token" {
+			t.Fatalf("token=%v", body.Token)
+		}
+	})
+
+	t.Run("Ok Get Token Call (Session Without Token)", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/oauth/user/csrf", nil)
+		r.AddCookie(MustLogin(t, s, user.ID, ""))
+
+		resp := Serve(s, r)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		var body struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		} else if body.Token == "" {
+			t.Fatal("empty token")
+		}
+
+		// the new token is stored in the session.
+		cookies := resp.Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("cookies=%v", cookies)
+		}
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(cookies[0])
+		if ses, err := s.GetSession(r); err != nil {
+			t.Fatal(err)
+		} else if ses.CSRFToken != body.Token {
+			t.Fatalf("session token=%v", ses.CSRFToken)
+		}
+	})
+
+	t.Run("Ok Preflight Call (Patch)", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodOptions, "/v1/blogs/1", nil)
+		r.Header.Set("Origin", NewTestConfig().HTTP.FrontendURL)
+		r.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+		r.Header.Set("Access-Control-Request-Headers", pahttp.CSRFHeader)
+
+		resp := Serve(s, r)
+		if v := resp.Header.Get("Access-Control-Allow-Methods"); !strings.Contains(v, http.MethodPatch) {
+			t.Fatalf("allowed methods=%q", v)
+		} else if v := resp.Header.Get("Access-Control-Allow-Headers"); !strings.EqualFold(v, pahttp.CSRFHeader) {
+			t.Fatalf("allowed headers=%q", v)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// ServeHTTP serves r through the router of s without opening a listener.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// OpenSecureCookie exposes openSecureCookie to the tests.
+func (s *Server) OpenSecureCookie() error {
+	return s.openSecureCookie()
+}
+
+// OpenTrustedProxies exposes openTrustedProxies to the tests.
+func (s *Server) OpenTrustedProxies() error {
+	return s.openTrustedProxies()
+}
+
+// SetSession exposes setSession to the tests.
+func (s *Server) SetSession(w http.ResponseWriter, ses pa.Session) error {
+	return s.setSession(w, ses)
+}
+
+// GetSession exposes getSession to the tests.
+func (s *Server) GetSession(r *http.Request) (pa.Session, error) {
+	return s.getSession(r)
+}
+
+// PurgeExports exposes purgeExports to the tests.
+func (s *Server) PurgeExports(ctx context.Context, t time.Time) (int, error) {
+	return s.purgeExports(ctx, t)
+}
+
+// ParseListQuery exposes parseListQuery to the tests.
+func (s *Server) ParseListQuery(r *http.Request) (int, *pa.Cursor, error) {
+	return s.parseListQuery(r)
+}
+
+// DecodeCursor exposes decodeCursor to the tests.
+func (s *Server) DecodeCursor(v string) (*pa.Cursor, error) {
+	return s.decodeCursor(v)
+}
+
+// NewPageResponse exposes newPageResponse to the tests, returning the prev and next cursors.
+func (s *Server) NewPageResponse(cursor *pa.Cursor, sortBy string, offset, n, length int, at func(i int) pa.Cursor) (string, string, error) {
+	page, err := s.newPageResponse(cursor, sortBy, offset, n, length, at)
+	return page.PrevCursor, page.NextCursor, err
+}
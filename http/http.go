@@ -3,20 +3,23 @@ package http
 import (
 	"encoding/json"
 	"io"
-	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 )
 
 // error code -> http error code.
 var httpCodes = map[string]int{
-	pa.ENOTFOUND:       http.StatusNotFound,
-	pa.ECONFLICT:       http.StatusConflict,
-	pa.EINTERNAL:       http.StatusInternalServerError,
-	pa.EINVALID:        http.StatusBadRequest,
-	pa.ENOTIMPLEMENTED: http.StatusNotImplemented,
-	pa.EUNAUTHORIZED:   http.StatusUnauthorized,
+	pa.ENOTFOUND:        http.StatusNotFound,
+	pa.ECONFLICT:        http.StatusConflict,
+	pa.EINTERNAL:        http.StatusInternalServerError,
+	pa.EINVALID:         http.StatusBadRequest,
+	pa.ENOTIMPLEMENTED:  http.StatusNotImplemented,
+	pa.EUNAUTHORIZED:    http.StatusUnauthorized,
+	pa.ETOOMANYREQUESTS: http.StatusTooManyRequests,
+	pa.EFORBIDDEN:       http.StatusForbidden,
 }
 
 // getErrorCode maps the code to an http code if possible or returns 500.
@@ -51,9 +54,77 @@ func SendError(w http.ResponseWriter, r *http.Request, err error) {
 	})
 }
 
-// LogError logs an error with format '[HTTP] error: {method} {path}: {error message}'
+// LogError logs an error with the method and path of r under the logger of the request.
 func LogError(r *http.Request, err error) {
-	log.Printf("[HTTP] error: %s %s: %s\n", r.URL.Path, r.Method, err)
+	pa.LoggerFromContext(r.Context()).Error("request failed.", "method", r.Method, "path", r.URL.Path, "err", err)
+}
+
+// redirectToSlug permanently redirects r to the same path with the last occurence of oldSlug
+// replaced by newSlug.
+func redirectToSlug(w http.ResponseWriter, r *http.Request, oldSlug, newSlug string) {
+	i := strings.LastIndex(r.URL.Path, oldSlug)
+	if i == -1 {
+		SendError(w, r, pa.Errorf(pa.EINTERNAL, "slug not found in path."))
+		return
+	}
+
+	u := *r.URL
+	u.Path = r.URL.Path[:i] + newSlug + r.URL.Path[i+len(oldSlug):]
+	u.RawPath = ""
+	http.Redirect(w, r, u.RequestURI(), http.StatusMovedPermanently)
+}
+
+// parseQuerySpec parses the "sortBy", "order", "createdAfter" and "createdBefore" query params of r,
+// the times are formatted as RFC 3339.
+// returns EINVALID if a time is malformed.
+func parseQuerySpec(r *http.Request) (spec pa.QuerySpec, err error) {
+	q := r.URL.Query()
+	spec.SortBy, spec.Order = q.Get("sortBy"), q.Get("order")
+
+	for name, dst := range map[string]**time.Time{
+		"createdAfter":  &spec.CreatedAfter,
+		"createdBefore": &spec.CreatedBefore,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return spec, pa.Errorf(pa.EINVALID, "invalid %s format.", name)
+		}
+		*dst = &t
+	}
+
+	return spec, nil
+}
+
+// parseInclude returns the relations listed in the comma separated "include" query param of r.
+// returns EINVALID if a relation isnt one of allowed.
+func parseInclude(r *http.Request, allowed ...string) (map[string]bool, error) {
+	include := make(map[string]bool)
+
+	v := r.URL.Query().Get("include")
+	if v == "" {
+		return include, nil
+	}
+
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+
+		ok := false
+		for _, a := range allowed {
+			ok = ok || a == name
+		}
+		if !ok {
+			return nil, pa.Errorf(pa.EINVALID, "invalid include: %q.", name)
+		}
+
+		include[name] = true
+	}
+
+	return include, nil
 }
 
 // SendJSON sends json: data over http.
@@ -63,6 +134,10 @@ func SendJSON(w io.Writer, data interface{}) error {
 
 // response and request types ----------------------------
 
+type getCSRFTokenResponse struct {
+	Token string `json:"token"`
+}
+
 type getMeResponse struct {
 	User   *pa.User `json:"user"`
 	PfpURL string   `json:"pfpUrl"`
@@ -88,6 +163,7 @@ type getOtherUserProfileResponse struct {
 type getCommentsResponse struct {
 	N        int           `json:"n"`
 	Comments []*pa.Comment `json:"comments"`
+	pageResponse
 }
 
 type getSubscriptionsResponse struct {
@@ -97,6 +173,7 @@ type getSubscriptionsResponse struct {
 		UserID int `json:"userID"`
 		On     int `json:"on"`
 	} `json:"subscriptions"`
+	pageResponse
 }
 
 // TODO: test
@@ -136,14 +213,62 @@ func (s *getSubscriptionsResponse) serializeIn(subs ...*pa.Subscription) {
 type getSubBlogsResponse struct {
 	N        int           `json:"n"`
 	SubBlogs []*pa.SubBlog `json:"subBlogs"`
+	pageResponse
+}
+
+type reorderBlogRequest struct {
+	SubBlogIDs []int `json:"subBlogIDs"`
 }
 
 type getBlogsResponse struct {
 	N     int        `json:"n"`
 	Blogs []*pa.Blog `json:"blogs"`
+	pageResponse
 }
 
 type getProjectsResponse struct {
 	N        int           `json:"n"`
 	Projects []*pa.Project `json:"projects"`
+	pageResponse
+}
+
+type getMediaResponse struct {
+	N     int         `json:"n"`
+	Media []*pa.Media `json:"media"`
+	pageResponse
+}
+
+// pageResponse holds the opaque cursors of the pages around a page of a list response,
+// a cursor is left empty if there is no such page.
+type pageResponse struct {
+	PrevCursor string `json:"prevCursor,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type getBackupsResponse struct {
+	N       int          `json:"n"`
+	Backups []*pa.Backup `json:"backups"`
+}
+
+type getTrashResponse struct {
+	N     int             `json:"n"`
+	Items []*pa.TrashItem `json:"items"`
+	pageResponse
+}
+
+type getAuditResponse struct {
+	N       int              `json:"n"`
+	Entries []*pa.AuditEntry `json:"entries"`
+	pageResponse
+}
+
+type getSpamRulesResponse struct {
+	N     int            `json:"n"`
+	Rules []*pa.SpamRule `json:"rules"`
+}
+
+type getBansResponse struct {
+	N    int       `json:"n"`
+	Bans []*pa.Ban `json:"bans"`
+	pageResponse
 }
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	chimw "github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader is the header carrying the id of the request, set on each response.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware attaches an id to each request and a logger logging under it to the request
+// context. The id sent by a proxy in front of the server under RequestIDHeader is kept.
+func (s *Server) requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				SendError(w, r, err)
+				return
+			}
+			id = hex.EncodeToString(buf)
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(pa.NewContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether id is safe to log, ids are up to 64 letters, digits, '-', '_' or '.'.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// accessLogMiddleware logs each request once served.
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 { // nothing written.
+			status = http.StatusOK
+		}
+		pa.LoggerFromContext(r.Context()).Info("request served.",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+		)
+	})
+}
+
+// cronLogger logs the cron job scheduler through the default logger.
+type cronLogger struct{}
+
+func (cronLogger) Info(msg string, keysAndValues ...interface{}) {
+	slog.Default().Debug(msg, append(keysAndValues, "component", "cron")...)
+}
+
+func (cronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	slog.Default().Error(msg, append(keysAndValues, "component", "cron", "err", err)...)
+}
@@ -0,0 +1,89 @@
+package http_test
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+	"github.com/Lambels/patrickarvatu.com/mock"
+	tmock "github.com/stretchr/testify/mock"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	s, db := MustOpenServer(t, nil)
+
+	adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+	usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+	subBlog := MustCreateSubBlog(t, db, adminUsrCtx)
+
+	user, err := s.UserService.FindUserByID(usrCtx, pa.UserFromContext(usrCtx).ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pushed events carry the context of the request to the event service.
+	var pushed []context.Context
+	eventService := &mock.EventService{}
+	eventService.On("Push", tmock.Anything, tmock.Anything).Run(func(args tmock.Arguments) {
+		pushed = append(pushed, args.Get(0).(context.Context))
+	}).Return(nil)
+	s.EventService = eventService
+
+	newRequest := func(id string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/v1/comments/", strings.NewReader(`{"subBlogID":`+strconv.Itoa(subBlog.ID)+`,"content":"epic comment"}`))
+		r.Header.Set("Authorization", "Bearer "+user.APIKey)
+		if id != "" {
+			r.Header.Set(pahttp.RequestIDHeader, id)
+		}
+		return r
+	}
+
+	t.Run("Ok Post Call (Incoming Request ID)", func(t *testing.T) {
+		pushed = nil
+
+		resp := Serve(s, newRequest("req-123.abc_DEF"))
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if id := resp.Header.Get(pahttp.RequestIDHeader); id != "req-123.abc_DEF" {
+			t.Fatalf("id=%v", id)
+		}
+
+		if len(pushed) != 1 {
+			t.Fatalf("pushed=%v", len(pushed))
+		} else if id := pa.RequestIDFromContext(pushed[0]); id != "req-123.abc_DEF" {
+			t.Fatalf("pushed id=%v", id)
+		}
+	})
+
+	t.Run("Ok Post Call (Generated Request ID)", func(t *testing.T) {
+		pushed = nil
+
+		resp := Serve(s, newRequest(""))
+		id := resp.Header.Get(pahttp.RequestIDHeader)
+		if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+			t.Fatalf("id=%v", id)
+		}
+
+		if len(pushed) != 1 {
+			t.Fatalf("pushed=%v", len(pushed))
+		} else if pushedID := pa.RequestIDFromContext(pushed[0]); pushedID != id {
+			t.Fatalf("pushed id=%v want=%v", pushedID, id)
+		}
+	})
+
+	t.Run("Ok Post Call (Invalid Request ID)", func(t *testing.T) {
+		// ids unsafe to log get replaced.
+		for _, invalid := range []string{"bad id", "bad\nid", strings.Repeat("a", 65)} {
+			resp := Serve(s, newRequest(invalid))
+			if id := resp.Header.Get(pahttp.RequestIDHeader); id == invalid || len(id) != 32 {
+				t.Fatalf("id=%q", id)
+			}
+		}
+	})
+}
@@ -0,0 +1,437 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/imaging"
+	"github.com/go-chi/chi/v5"
+)
+
+// MaxMediaSize is the maximum size in bytes of an uploaded media.
+const MaxMediaSize = 32 << 20
+
+// registerMediaRoutes registers the media routes under r.
+func (s *Server) registerMediaRoutes(r chi.Router) {
+	// media files are content addressed, their content never changes.
+	r.With(immutableCacheMiddleware).Get("/files/*", s.handleGetMediaFile)
+
+	r.Route("/", func(r chi.Router) {
+		r.Use(s.adminAuthMiddleware)
+
+		r.Get("/", s.handleGetMedia)
+		r.Get("/{mediaID}", s.handleGetMediaByID)
+
+		r.Post("/", s.handleCreateMedia)
+
+		r.Patch("/{mediaID}", s.handleUpdateMedia)
+
+		r.Put("/{mediaID}/refs/{refType}/{refID}", s.handleCreateMediaRef)
+
+		r.Delete("/{mediaID}", s.handleDeleteMedia)
+		r.Delete("/{mediaID}/refs/{refType}/{refID}", s.handleDeleteMediaRef)
+	})
+}
+
+// registerImageRoutes registers the static image routes under r.
+func (s *Server) registerImageRoutes(r chi.Router) {
+	r.Get("/*", s.handleGetImage)
+}
+
+// handleGetMediaFile handels GET '/media/files/*'
+// streams the media file from the media file system.
+func (s *Server) handleGetMediaFile(w http.ResponseWriter, r *http.Request) {
+	serveFile(w, r, s.MediaFileSystem, "/"+chi.URLParam(r, "*"))
+}
+
+// handleGetImage handels GET '/images/*'
+// streams the image from the images file system.
+func (s *Server) handleGetImage(w http.ResponseWriter, r *http.Request) {
+	serveFile(w, r, s.ImagesFileSystem, "/"+chi.URLParam(r, "*"))
+}
+
+// handleGetMedia handels GET '/media/'
+// retrieves media based on request body.
+func (s *Server) handleGetMedia(w http.ResponseWriter, r *http.Request) {
+	var filter pa.MediaFilter
+
+	// get filter params from:
+	switch r.Header.Get("Content-Type") {
+	case "application/json":
+		cursor, err := s.decodeListBody(r, &filter)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+		filter.Cursor = cursor
+
+	default:
+		offset, cursor, err := s.parseListQuery(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		filter.Offset = offset
+		filter.Cursor = cursor
+		filter.Limit = 20
+	}
+
+	// fetch media from database.
+	media, n, err := s.MediaService.FindMedia(r.Context(), filter)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	page, err := s.newPageResponse(filter.Cursor, "", filter.Offset, n, len(media), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: media[i].CreatedAt, ID: media[i].ID}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, getMediaResponse{
+		N:            n,
+		Media:        media,
+		pageResponse: page,
+	})
+}
+
+// handleGetMediaByID handels GET '/media/{mediaID}'
+// returns media with id: mediaID.
+func (s *Server) handleGetMediaByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "mediaID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	// fetch media from database.
+	media, err := s.MediaService.FindMediaByID(r.Context(), id)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	// send response.
+	SendJSON(w, media)
+}
+
+// handleCreateMedia handels POST '/media/'
+// uploads the image in the request body to the media library, the alt text is read from the 'altText' query param.
+// responds with 201 if the media got created or 200 if the same content already exists.
+func (s *Server) handleCreateMedia(w http.ResponseWriter, r *http.Request) {
+	media, created, err := s.uploadMedia(r.Context(), r.Body, r.URL.Query().Get("altText"))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	SendJSON(w, media)
+}
+
+// handleUpdateMedia handels PATCH '/media/{mediaID}'
+// updates the media with id: mediaID with the request body.
+func (s *Server) handleUpdateMedia(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "mediaID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	// decode body.
+	var update pa.MediaUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid JSON body"))
+		return
+	}
+
+	// update media.
+	media, err := s.MediaService.UpdateMedia(r.Context(), id, update)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	// send response.
+	SendJSON(w, media)
+}
+
+// handleDeleteMedia handels DELETE '/media/{mediaID}'
+// deletes the media with id: mediaID and its file, media which is still referenced cant be deleted.
+func (s *Server) handleDeleteMedia(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "mediaID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	media, err := s.MediaService.FindMediaByID(r.Context(), id)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	// delete the media before the file, the media service guards against deleting referenced media.
+	if err := s.MediaService.DeleteMedia(r.Context(), id); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	// the media is gone, files left behind are only wasted space.
+	paths := []string{media.Path()}
+	for _, v := range media.Variants {
+		paths = append(paths, v.Path())
+	}
+	for _, path := range paths {
+		if err := s.MediaFileSystem.DeleteFile(r.Context(), path); err != nil {
+			LogError(r, err)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCreateMediaRef handels PUT '/media/{mediaID}/refs/{refType}/{refID}'
+// references the media with id: mediaID from the object of type: refType with id: refID.
+func (s *Server) handleCreateMediaRef(w http.ResponseWriter, r *http.Request) {
+	id, ref, err := mediaRefFromRequest(r)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if err := s.MediaService.CreateMediaRef(r.Context(), id, ref); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteMediaRef handels DELETE '/media/{mediaID}/refs/{refType}/{refID}'
+// removes the reference from the object of type: refType with id: refID to the media with id: mediaID.
+func (s *Server) handleDeleteMediaRef(w http.ResponseWriter, r *http.Request) {
+	id, ref, err := mediaRefFromRequest(r)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if err := s.MediaService.DeleteMediaRef(r.Context(), id, ref); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mediaRefFromRequest parses the media id and media reference from the url params.
+func mediaRefFromRequest(r *http.Request) (int, pa.MediaRef, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "mediaID"))
+	if err != nil {
+		return 0, pa.MediaRef{}, pa.Errorf(pa.EINVALID, "invalid id format")
+	}
+
+	refID, err := strconv.Atoi(chi.URLParam(r, "refID"))
+	if err != nil {
+		return 0, pa.MediaRef{}, pa.Errorf(pa.EINVALID, "invalid reference id format")
+	}
+
+	// allow url friendly reference types, ie: "sub-blog".
+	refType := strings.ReplaceAll(chi.URLParam(r, "refType"), "-", "_")
+
+	return id, pa.MediaRef{Type: refType, ID: refID}, nil
+}
+
+// uploadMedia processes content and stores it in the media library together with its variants. If the same
+// content already exists the existing media gets returned and created is false.
+func (s *Server) uploadMedia(ctx context.Context, content io.Reader, altText string) (media *pa.Media, created bool, err error) {
+	buf, err := io.ReadAll(io.LimitReader(content, MaxMediaSize+1))
+	if err != nil {
+		return nil, false, err
+	} else if len(buf) > MaxMediaSize {
+		return nil, false, pa.Errorf(pa.EINVALID, "media exceeds %d bytes.", MaxMediaSize)
+	}
+
+	sum := sha256.Sum256(buf)
+	hash := hex.EncodeToString(sum[:])
+
+	// media is content addressed, reuse existing media.
+	if existing, _, err := s.MediaService.FindMedia(ctx, pa.MediaFilter{Hash: &hash, Limit: 1}); err != nil {
+		return nil, false, err
+	} else if len(existing) > 0 {
+		return existing[0], false, nil
+	}
+
+	// validate the format, strip metadata and generate the variants.
+	img, err := imaging.Process(buf, imaging.Limits{
+		MaxWidth:  s.conf.FileStructure.MaxImageWidth,
+		MaxHeight: s.conf.FileStructure.MaxImageHeight,
+		MaxPixels: s.conf.FileStructure.MaxImagePixels,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	media = &pa.Media{
+		Hash:     hash,
+		Name:     hash + img.Ext,
+		MimeType: img.MimeType,
+		Size:     int64(len(img.Content)),
+		Width:    img.Width,
+		Height:   img.Height,
+		AltText:  altText,
+	}
+
+	files := map[string][]byte{media.Path(): img.Content}
+	for _, v := range img.Variants {
+		variant := &pa.MediaVariant{
+			Label:    v.Label,
+			Name:     fmt.Sprintf("%s-%dw%s", hash, v.Width, v.Ext),
+			MimeType: v.MimeType,
+			Size:     int64(len(v.Content)),
+			Width:    v.Width,
+			Height:   v.Height,
+		}
+
+		media.Variants = append(media.Variants, variant)
+		files[variant.Path()] = v.Content
+	}
+
+	// removeFiles cleans up the stored files on failure.
+	removeFiles := func() {
+		for path := range files {
+			s.MediaFileSystem.DeleteFile(ctx, path)
+		}
+	}
+
+	for path, content := range files {
+		if err := s.MediaFileSystem.CreateFile(ctx, path, bytes.NewReader(content)); err != nil {
+			removeFiles()
+			return nil, false, err
+		}
+	}
+
+	if err := s.MediaService.CreateMedia(ctx, media); err != nil {
+		// the files are shared with the conflicting media, only clean up on other errors.
+		if pa.ErrorCode(err) != pa.ECONFLICT {
+			removeFiles()
+		}
+		return nil, false, err
+	}
+
+	return media, true, nil
+}
+
+// ImportLegacyImages imports the images stored before the media library into it, the legacy images are
+// named after the id of their blog or project, ie: "/1.png" is the image of the object with id 1. refType
+// is either pa.MediaRefBlog or pa.MediaRefProject. Objects which already reference an image and files
+// which dont belong to an existing object are skipped so the import can run on every start.
+// returns the number of imported images.
+func (s *Server) ImportLegacyImages(ctx context.Context, refType string, fileSystem pa.FileService) (int, error) {
+	logger := pa.LoggerFromContext(ctx).With("refType", refType)
+
+	files, err := fileSystem.List(ctx, "/")
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, file := range files {
+		name := strings.TrimPrefix(file.Path, "/")
+		id, err := strconv.Atoi(strings.TrimSuffix(name, path.Ext(name)))
+		if err != nil || id <= 0 {
+			logger.Warn("skipping legacy image, not named after an id.", "path", file.Path)
+			continue
+		}
+		ref := pa.MediaRef{Type: refType, ID: id}
+
+		filter := pa.MediaFilter{Limit: 1}
+		switch refType {
+		case pa.MediaRefBlog:
+			filter.BlogID = &id
+		case pa.MediaRefProject:
+			filter.ProjectID = &id
+		default:
+			return n, pa.Errorf(pa.EINVALID, "legacy images of %q cant be imported.", refType)
+		}
+
+		// keep the images set since the media library.
+		if existing, _, err := s.MediaService.FindMedia(ctx, filter); err != nil {
+			return n, err
+		} else if len(existing) > 0 {
+			continue
+		}
+
+		media, err := s.importLegacyImage(ctx, fileSystem, file.Path)
+		if err != nil {
+			logger.Warn("skipping legacy image.", "path", file.Path, "err", err)
+			continue
+		}
+
+		if err := s.MediaService.CreateMediaRef(ctx, media.ID, ref); pa.ErrorCode(err) == pa.ENOTFOUND {
+			logger.Warn("skipping legacy image, its owner doesent exist.", "path", file.Path)
+			continue
+		} else if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// importLegacyImage uploads the file at name in fileSystem to the media library.
+func (s *Server) importLegacyImage(ctx context.Context, fileSystem pa.FileService, name string) (*pa.Media, error) {
+	rc, _, err := fileSystem.OpenFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	media, _, err := s.uploadMedia(ctx, rc, "")
+	return media, err
+}
+
+// immutableCacheMiddleware marks successful responses as cacheable forever, used for content addressed files.
+func immutableCacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&immutableCacheWriter{ResponseWriter: w}, r)
+	})
+}
+
+// immutableCacheWriter sets the immutable cache headers once the status is known so errors dont get cached.
+type immutableCacheWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *immutableCacheWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status == http.StatusOK || status == http.StatusPartialContent || status == http.StatusNotModified {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *immutableCacheWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,108 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/fs"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestImportLegacyImages(t *testing.T) {
+	t.Run("Ok Import Call", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{
+			Name:  "Lambels",
+			Email: adminEmail,
+		})
+
+		blog := &pa.Blog{
+			Title:       "Epic Blog",
+			Description: "Honestly the best blog ever.",
+		}
+		if err := sqlite.NewBlogService(db).CreateBlog(adminUsrCtx, blog); err != nil {
+			t.Fatal(err)
+		}
+
+		// images written by the old attach image handler, named after the blog id.
+		legacy := fs.NewFileService(filepath.Join(t.TempDir(), "blogs"))
+		MustCreateFile(t, legacy, "/1.png", MustEncodePNG(t, 16, 16))
+		MustCreateFile(t, legacy, "/99.png", MustEncodePNG(t, 8, 8)) // no such blog.
+		MustCreateFile(t, legacy, "/readme.txt", []byte("not an image"))
+
+		n, err := s.ImportLegacyImages(adminUsrCtx, pa.MediaRefBlog, legacy)
+		if err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("n=%v", n)
+		}
+
+		media, _, err := sqlite.NewMediaService(db).FindMedia(adminUsrCtx, pa.MediaFilter{BlogID: &blog.ID})
+		if err != nil {
+			t.Fatal(err)
+		} else if len(media) != 1 || media[0].Width != 16 || media[0].RefCount != 1 {
+			t.Fatalf("media=%+v", media)
+		}
+
+		// the content is stored in the media file system.
+		if _, err := s.MediaFileSystem.Stat(adminUsrCtx, media[0].Path()); err != nil {
+			t.Fatal(err)
+		}
+
+		// imported images arent imported twice.
+		if n, err := s.ImportLegacyImages(adminUsrCtx, pa.MediaRefBlog, legacy); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("n=%v", n)
+		}
+	})
+
+	t.Run("Bad Import Call (Invalid Ref Type)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{
+			Name:  "Lambels",
+			Email: adminEmail,
+		})
+
+		legacy := fs.NewFileService(filepath.Join(t.TempDir(), "sub-blogs"))
+		MustCreateFile(t, legacy, "/1.png", MustEncodePNG(t, 8, 8))
+
+		if _, err := s.ImportLegacyImages(adminUsrCtx, pa.MediaRefSubBlog, legacy); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+}
+
+// MustEncodePNG returns a png with the width and height.
+func MustEncodePNG(tb testing.TB, width, height int) []byte {
+	tb.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// MustCreateFile creates the file at path in fileService with content.
+func MustCreateFile(tb testing.TB, fileService pa.FileService, path string, content []byte) {
+	tb.Helper()
+
+	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+	if err := fileService.CreateFile(adminCtx, path, bytes.NewReader(content)); err != nil {
+		tb.Fatal(err)
+	}
+}
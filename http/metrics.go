@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultMetricsAddr is the address of the metrics server when none is configured.
+const DefaultMetricsAddr = ":8000"
+
+var (
+	requestCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "total number of requests served by route pattern, method and status",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "duration of the requests by route pattern and method",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	githubSyncCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "github_sync_runs_total",
+		Help: "total number of github repo syncs by result",
+	}, []string{"result"})
+
+	githubSyncDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "github_sync_duration_seconds",
+		Help:    "duration of the github repo syncs",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	githubSyncLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "github_sync_last_success_timestamp_seconds",
+		Help: "unix time of the last successful github repo sync",
+	})
+
+	githubSyncProjects = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "github_sync_projects",
+		Help: "number of projects synced from github by the last successful sync",
+	})
+)
+
+// metricsMiddleware records the rate, the errors and the duration of the requests by chi route pattern,
+// unrouted requests are recorded under "unmatched" so random paths dont grow the label set.
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		// the route context is filled in while routing.
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := ww.Status()
+		if status == 0 { // nothing written.
+			status = http.StatusOK
+		}
+
+		requestCounter.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+		requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RunDebugServer runs a debug server serving the metrics under /metrics on addr, DefaultMetricsAddr if empty.
+// blocking function.
+func RunDebugServer(addr string) error {
+	if addr == "" {
+		addr = DefaultMetricsAddr
+	}
+
+	s := http.NewServeMux()
+	s.Handle("/metrics", promhttp.Handler())
+	return http.ListenAndServe(addr, s)
+}
@@ -0,0 +1,67 @@
+package http_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// routeLabelRegexp matches the route labels of the scraped metrics.
+var routeLabelRegexp = regexp.MustCompile(`route="([^"]*)"`)
+
+func TestMetricsMiddleware(t *testing.T) {
+	s, _ := MustOpenServer(t, nil)
+
+	for _, path := range []string{
+		"/v1/blogs/4815",
+		"/v1/blogs/4815/sub-blogs",
+		"/v1/no-such-route-4815",
+	} {
+		Serve(s, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	metrics := MustScrapeMetrics(t)
+
+	t.Run("Ok Scrape Call (Route Patterns)", func(t *testing.T) {
+		for _, label := range []string{
+			`route="/v1/blogs/{blogIDOrSlug}"`,
+			`route="/v1/blogs/{blogID}/sub-blogs"`,
+		} {
+			if !strings.Contains(metrics, label) {
+				t.Fatalf("%s not found", label)
+			}
+		}
+	})
+
+	t.Run("Ok Scrape Call (Unmatched)", func(t *testing.T) {
+		if !strings.Contains(metrics, `route="unmatched"`) {
+			t.Fatal(`route="unmatched" not found`)
+		}
+	})
+
+	t.Run("Ok Scrape Call (No Raw Paths)", func(t *testing.T) {
+		for _, match := range routeLabelRegexp.FindAllStringSubmatch(metrics, -1) {
+			if strings.Contains(match[1], "4815") {
+				t.Fatalf("route=%q", match[1])
+			}
+		}
+	})
+}
+
+// MustScrapeMetrics returns the metrics of the default registry, as served by the debug server.
+func MustScrapeMetrics(tb testing.TB) string {
+	tb.Helper()
+
+	w := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	buf, err := io.ReadAll(w.Result().Body)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return string(buf)
+}
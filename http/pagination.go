@@ -0,0 +1,104 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/gorilla/securecookie"
+)
+
+// cursorName is the name the cursors are signed under by the secure cookie service, signing
+// ties a cursor to its purpose and keeps the positions opaque to the clients.
+const cursorName = "cursor"
+
+// parseListQuery parses the "offset" and "cursor" query params of a list request.
+// returns EINVALID if the offset isnt a number or the cursor wasnt issued by the server.
+func (s *Server) parseListQuery(r *http.Request) (offset int, cursor *pa.Cursor, err error) {
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			return 0, nil, pa.Errorf(pa.EINVALID, "invalid offset format")
+		}
+	}
+
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		if cursor, err = s.decodeCursor(v); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return offset, cursor, nil
+}
+
+// decodeListBody decodes the JSON body of a list request into filter and returns the cursor passed
+// under the "cursor" field, nil if none was passed.
+// returns EINVALID if the body is malformed or the cursor wasnt issued by the server.
+func (s *Server) decodeListBody(r *http.Request, filter interface{}) (*pa.Cursor, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var page struct {
+		Cursor string `json:"cursor"`
+	}
+	if err := json.Unmarshal(body, filter); err != nil {
+		return nil, pa.Errorf(pa.EINVALID, "invalid JSON body")
+	} else if err := json.Unmarshal(body, &page); err != nil {
+		return nil, pa.Errorf(pa.EINVALID, "invalid JSON body")
+	}
+
+	if page.Cursor == "" {
+		return nil, nil
+	}
+	return s.decodeCursor(page.Cursor)
+}
+
+// decodeCursor verifies and decodes the opaque cursor v.
+// returns EINVALID if v wasnt issued by the server or expired.
+func (s *Server) decodeCursor(v string) (*pa.Cursor, error) {
+	var cursor pa.Cursor
+	if err := securecookie.DecodeMulti(cursorName, v, &cursor, s.codecs...); err != nil {
+		return nil, pa.Errorf(pa.EINVALID, "invalid cursor.")
+	}
+	return &cursor, nil
+}
+
+// newPageResponse returns the opaque cursors of the pages around the page of length objects.
+// cursor, offset and n are the pagination of the filter and the count returned by the find function,
+// at returns the position of the i-th object of the page. The cursors follow the creation order so
+// pages sorted by sortBy dont get any.
+func (s *Server) newPageResponse(cursor *pa.Cursor, sortBy string, offset, n, length int, at func(i int) pa.Cursor) (page pageResponse, err error) {
+	if length == 0 || sortBy != "" {
+		return page, nil
+	}
+
+	var hasPrev, hasNext bool
+	switch {
+	case cursor == nil: // offset pages, n counts the whole range.
+		hasPrev, hasNext = offset > 0, n > offset+length
+
+	case cursor.Before: // n counts the objects before the cursor.
+		hasPrev, hasNext = n > length, true
+
+	default: // n counts the objects after the cursor.
+		hasPrev, hasNext = true, n > length
+	}
+
+	if hasPrev {
+		prev := at(0)
+		prev.Before = true
+		if page.PrevCursor, err = securecookie.EncodeMulti(cursorName, prev, s.codecs...); err != nil {
+			return page, err
+		}
+	}
+	if hasNext {
+		if page.NextCursor, err = securecookie.EncodeMulti(cursorName, at(length-1), s.codecs...); err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,233 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+)
+
+func TestParseListQuery(t *testing.T) {
+	s, _ := MustOpenServer(t, nil)
+	_, next := MustNewPageResponse(t, s, nil, 0, 2, []pa.Cursor{{ID: 1}})
+
+	t.Run("Ok Parse Call", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/?offset=3&cursor="+url.QueryEscape(next), nil)
+		if offset, cursor, err := s.ParseListQuery(r); err != nil {
+			t.Fatal(err)
+		} else if offset != 3 || cursor == nil || cursor.ID != 1 || cursor.Before {
+			t.Fatalf("offset=%v cursor=%+v", offset, cursor)
+		}
+	})
+
+	t.Run("Ok Parse Call (No Params)", func(t *testing.T) {
+		if offset, cursor, err := s.ParseListQuery(httptest.NewRequest(http.MethodGet, "/", nil)); err != nil {
+			t.Fatal(err)
+		} else if offset != 0 || cursor != nil {
+			t.Fatalf("offset=%v cursor=%+v", offset, cursor)
+		}
+	})
+
+	t.Run("Bad Parse Call", func(t *testing.T) {
+		for _, query := range []string{"offset=one", "cursor=" + url.QueryEscape(next[:len(next)-2])} {
+			if _, _, err := s.ParseListQuery(httptest.NewRequest(http.MethodGet, "/?"+query, nil)); pa.ErrorCode(err) != pa.EINVALID {
+				t.Fatalf("query=%v err=%v", query, err)
+			}
+		}
+	})
+}
+
+func TestDecodeCursor(t *testing.T) {
+	s, _ := MustOpenServer(t, nil)
+	createdAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, next := MustNewPageResponse(t, s, nil, 0, 2, []pa.Cursor{{CreatedAt: createdAt, ID: 7, Type: pa.TrashTypeComment}})
+
+	t.Run("Ok Decode Call", func(t *testing.T) {
+		if cursor, err := s.DecodeCursor(next); err != nil {
+			t.Fatal(err)
+		} else if !cursor.CreatedAt.Equal(createdAt) || cursor.ID != 7 || cursor.Type != pa.TrashTypeComment || cursor.Before {
+			t.Fatalf("cursor=%+v", cursor)
+		}
+	})
+
+	t.Run("Bad Decode Call (Tampered)", func(t *testing.T) {
+		tampered := []byte(next)
+		tampered[len(tampered)/2] ^= 1
+		for _, v := range []string{string(tampered), next[:len(next)-1], "eyJpZCI6MX0="} {
+			if _, err := s.DecodeCursor(v); pa.ErrorCode(err) != pa.EINVALID {
+				t.Fatalf("v=%v err=%v", v, err)
+			}
+		}
+	})
+
+	t.Run("Bad Decode Call (Other Keys)", func(t *testing.T) {
+		// cursors of servers with other keys arent accepted.
+		conf := NewTestConfig()
+		conf.HTTP.HashKey = "fedcba9876543210fedcba9876543210"
+		conf.HTTP.BlockKey = "9876543210fedcba9876543210fedcba"
+		other, _ := MustOpenServer(t, conf)
+
+		if _, err := other.DecodeCursor(next); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+func TestNewPageResponse(t *testing.T) {
+	s, _ := MustOpenServer(t, nil)
+	page := []pa.Cursor{{ID: 3}, {ID: 4}}
+
+	t.Run("Ok New Call (Offset)", func(t *testing.T) {
+		// the first page has no prev page.
+		if prev, next := MustNewPageResponse(t, s, nil, 0, 5, page); prev != "" || next == "" {
+			t.Fatalf("prev=%v next=%v", prev, next)
+		}
+		// the last page has no next page.
+		if prev, next := MustNewPageResponse(t, s, nil, 3, 5, page); prev == "" || next != "" {
+			t.Fatalf("prev=%v next=%v", prev, next)
+		}
+	})
+
+	t.Run("Ok New Call (Round Trip)", func(t *testing.T) {
+		// the prev cursor ends right before the first object, the next cursor starts right after the last one.
+		prev, next := MustNewPageResponse(t, s, &pa.Cursor{ID: 2}, 0, 3, page)
+		if cursor, err := s.DecodeCursor(prev); err != nil {
+			t.Fatal(err)
+		} else if cursor.ID != 3 || !cursor.Before {
+			t.Fatalf("prev=%+v", cursor)
+		}
+		if cursor, err := s.DecodeCursor(next); err != nil {
+			t.Fatal(err)
+		} else if cursor.ID != 4 || cursor.Before {
+			t.Fatalf("next=%+v", cursor)
+		}
+
+		// n counts the objects left before a before cursor, the page after it is always there.
+		if prev, next := MustNewPageResponse(t, s, &pa.Cursor{ID: 5, Before: true}, 0, 2, page); prev != "" || next == "" {
+			t.Fatalf("prev=%v next=%v", prev, next)
+		}
+	})
+
+	t.Run("Ok New Call (No Cursors)", func(t *testing.T) {
+		// sorted and empty pages get no cursors.
+		if prev, next, err := s.NewPageResponse(nil, "name", 1, 5, 2, func(i int) pa.Cursor { return page[i] }); err != nil {
+			t.Fatal(err)
+		} else if prev != "" || next != "" {
+			t.Fatalf("prev=%v next=%v", prev, next)
+		}
+		if prev, next := MustNewPageResponse(t, s, &pa.Cursor{ID: 2}, 0, 0, nil); prev != "" || next != "" {
+			t.Fatalf("prev=%v next=%v", prev, next)
+		}
+	})
+}
+
+func TestListCursors(t *testing.T) {
+	s, db := MustOpenServer(t, nil)
+
+	adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+	usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+	adminAPIKey, apiKey := MustFindAPIKey(t, s, adminUsrCtx), MustFindAPIKey(t, s, usrCtx)
+
+	for i := 0; i < 21; i++ {
+		name := "project-" + strconv.Itoa(i)
+		if err := s.ProjectService.CreateOrUpdateProject(adminUsrCtx, &pa.Project{Name: name, HtmlURL: "https://github.com/Lambels/" + name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Ok Get Call (Round Trip)", func(t *testing.T) {
+		first := MustGetProjects(t, s, "/v1/projects/")
+		if len(first.Projects) != 20 || first.PrevCursor != "" || first.NextCursor == "" {
+			t.Fatalf("first=%+v", first)
+		}
+
+		// the next page holds the project left.
+		next := MustGetProjects(t, s, "/v1/projects/?cursor="+url.QueryEscape(first.NextCursor))
+		if next.N != 1 || len(next.Projects) != 1 || next.Projects[0].Name != "project-20" {
+			t.Fatalf("next=%+v", next)
+		} else if next.PrevCursor == "" || next.NextCursor != "" {
+			t.Fatalf("next=%+v", next)
+		}
+
+		// the page before the next page is the first page.
+		prev := MustGetProjects(t, s, "/v1/projects/?cursor="+url.QueryEscape(next.PrevCursor))
+		if len(prev.Projects) != 20 || prev.NextCursor == "" {
+			t.Fatalf("prev=%+v", prev)
+		}
+		for i, project := range prev.Projects {
+			if project.ID != first.Projects[i].ID {
+				t.Fatalf("prev[%d]=%v first[%d]=%v", i, project.ID, i, first.Projects[i].ID)
+			}
+		}
+	})
+
+	t.Run("Bad Get Call (Subscriptions Cursor Without Topic)", func(t *testing.T) {
+		first := MustGetProjects(t, s, "/v1/projects/")
+		query := "?cursor=" + url.QueryEscape(first.NextCursor)
+
+		// the cursors page through a single topic.
+		if resp := Serve(s, NewBearerRequest(http.MethodGet, "/v1/subscriptions/"+query, "", apiKey)); resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if resp := Serve(s, NewBearerRequest(http.MethodGet, "/v1/subscriptions/"+query+"&topic="+pa.EventTopicNewComment, "", apiKey)); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Bad Get Call (Tampered Cursor)", func(t *testing.T) {
+		first := MustGetProjects(t, s, "/v1/projects/")
+		tampered := []byte(first.NextCursor)
+		tampered[len(tampered)/2] ^= 1
+		query := "?cursor=" + url.QueryEscape(string(tampered))
+
+		for path, key := range map[string]string{
+			"/v1/projects/":      apiKey,
+			"/v1/subscriptions/": apiKey,
+			"/v1/admin/trash/":   adminAPIKey,
+			"/v1/admin/audit/":   adminAPIKey,
+			"/v1/admin/bans/":    adminAPIKey,
+		} {
+			if resp := Serve(s, NewBearerRequest(http.MethodGet, path+query, "", key)); resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("path=%v status=%v", path, resp.StatusCode)
+			}
+		}
+	})
+}
+
+// MustNewPageResponse returns the prev and next cursors of the page holding the objects at the positions
+// of page, read with cursor or offset out of n objects.
+func MustNewPageResponse(tb testing.TB, s *pahttp.Server, cursor *pa.Cursor, offset, n int, page []pa.Cursor) (prev, next string) {
+	tb.Helper()
+
+	prev, next, err := s.NewPageResponse(cursor, "", offset, n, len(page), func(i int) pa.Cursor { return page[i] })
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return prev, next
+}
+
+// projectsPage is the response of GET '/projects/'.
+type projectsPage struct {
+	N          int           `json:"n"`
+	Projects   []*pa.Project `json:"projects"`
+	PrevCursor string        `json:"prevCursor"`
+	NextCursor string        `json:"nextCursor"`
+}
+
+// MustGetProjects returns the projects page served at path.
+func MustGetProjects(tb testing.TB, s *pahttp.Server, path string) (page projectsPage) {
+	tb.Helper()
+
+	resp := Serve(s, httptest.NewRequest(http.MethodGet, path, nil))
+	if resp.StatusCode != http.StatusOK {
+		tb.Fatalf("status=%v", resp.StatusCode)
+	} else if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		tb.Fatal(err)
+	}
+	return page
+}
@@ -2,8 +2,6 @@ package http
 
 import (
 	"encoding/json"
-	"fmt"
-	"mime"
 	"net/http"
 	"strconv"
 
@@ -30,29 +28,41 @@ func (s *Server) registerProjectRoutes(r chi.Router) {
 
 // handleGetProjects handels GET '/projects/'
 // retrieves projects based on request body.
+// the "sortBy", "order" and "nameContains" query params mirror the filter, the pages are read with the
+// "offset" or the "cursor" query params.
 func (s *Server) handleGetProjects(w http.ResponseWriter, r *http.Request) {
 	var filter pa.ProjectFilter
 
 	// get filter params from:
 	switch r.Header.Get("Content-Type") {
 	case "application/json":
-		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
-			SendError(w, r, pa.Errorf(pa.EINVALID, "invalid JSON body"))
+		cursor, err := s.decodeListBody(r, &filter)
+		if err != nil {
+			SendError(w, r, err)
 			return
 		}
+		filter.Cursor = cursor
 
 	default:
-		var offset int
-		var err error
-		if v := r.URL.Query().Get("offset"); v != "" {
-			if offset, err = strconv.Atoi(v); err != nil {
-				SendError(w, r, pa.Errorf(pa.EINVALID, "invalid offset format"))
-				return
-			}
+		offset, cursor, err := s.parseListQuery(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
 		}
 
+		spec, err := parseQuerySpec(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		filter.QuerySpec = spec
 		filter.Offset = offset
+		filter.Cursor = cursor
 		filter.Limit = 20
+		if v := r.URL.Query().Get("nameContains"); v != "" {
+			filter.NameContains = &v
+		}
 	}
 
 	// fetch projects from database.
@@ -62,9 +72,18 @@ func (s *Server) handleGetProjects(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	page, err := s.newPageResponse(filter.Cursor, filter.SortBy, filter.Offset, n, len(projects), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: projects[i].CreatedAt, ID: projects[i].ID}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
 	SendJSON(w, getProjectsResponse{
-		N:        n,
-		Projects: projects,
+		N:            n,
+		Projects:     projects,
+		pageResponse: page,
 	})
 }
 
@@ -128,6 +147,8 @@ func (s *Server) handleDeleteProject(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleAttachProjectImage handels PUT '/projects/{projectID}/image'
+// uploads the request body to the media library and sets it as the image of the project.
 func (s *Server) handleAttachProjectImage(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "projectID"))
 	if err != nil {
@@ -138,29 +159,43 @@ func (s *Server) handleAttachProjectImage(w http.ResponseWriter, r *http.Request
 	if _, err := s.ProjectService.FindProjectByID(r.Context(), id); err != nil { // cant attach image to un unexisting project.
 		SendError(w, r, err)
 		return
-	} else if ext, err := mime.ExtensionsByType(r.Header.Get("Content-Type")); err != nil {
+	}
+
+	media, _, err := s.uploadMedia(r.Context(), r.Body, r.URL.Query().Get("altText"))
+	if err != nil {
 		SendError(w, r, err)
 		return
-	} else if len(ext) == 0 {
-		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid mime type"))
-		return
-	} else if err := s.ProjectsFileSystem.CreateFile(r.Context(), "/"+fmt.Sprint(id)+ext[0], r.Body); err != nil {
+	}
+
+	// replaces the previous image of the project.
+	if err := s.MediaService.CreateMediaRef(r.Context(), media.ID, pa.MediaRef{Type: pa.MediaRefProject, ID: id}); err != nil {
 		SendError(w, r, err)
 		return
 	}
+
 	w.WriteHeader(http.StatusCreated)
+	SendJSON(w, media)
 }
 
+// handleDeleteProjectImage handels DELETE '/projects/{projectID}/image'
+// removes the image of the project, the media stays in the media library.
 func (s *Server) handleDeleteProjectImage(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "projectID")
+	id, err := strconv.Atoi(chi.URLParam(r, "projectID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
 
-	if ext, err := mime.ExtensionsByType(r.Header.Get("Content-Type")); err != nil {
+	project, err := s.ProjectService.FindProjectByID(r.Context(), id)
+	if err != nil {
 		SendError(w, r, err)
 		return
-	} else if len(ext) == 0 {
-		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid mime type"))
+	} else if project.Image == nil {
+		SendError(w, r, pa.Errorf(pa.ENOTFOUND, "project has no image."))
 		return
-	} else if err := s.ProjectsFileSystem.DeleteFile(r.Context(), "/"+id+ext[0]); err != nil {
+	}
+
+	if err := s.MediaService.DeleteMediaRef(r.Context(), project.Image.ID, pa.MediaRef{Type: pa.MediaRefProject, ID: id}); err != nil {
 		SendError(w, r, err)
 		return
 	}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// openTrustedProxies parses the addresses and networks of the trusted proxies under the config.
+func (s *Server) openTrustedProxies() error {
+	s.trustedProxies = nil
+	for _, v := range s.conf.HTTP.TrustedProxies {
+		prefix, err := parseProxy(v)
+		if err != nil {
+			return fmt.Errorf("trusted proxy: %w", err)
+		}
+		s.trustedProxies = append(s.trustedProxies, prefix)
+	}
+
+	return nil
+}
+
+// parseProxy parses an address, ie: "10.0.0.1", or a network, ie: "10.0.0.0/8".
+func parseProxy(v string) (netip.Prefix, error) {
+	if strings.Contains(v, "/") {
+		prefix, err := netip.ParsePrefix(v)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(v)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// clientIP returns the ip of the client of r. Requests coming from a trusted proxy take the ip from
+// the X-Forwarded-For header, read from the right so that a client cant spoof it: the first address
+// which isnt a trusted proxy is the client.
+func (s *Server) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(ip)
+	if err != nil || !s.isTrustedProxy(remote) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break // the hops from here on cant be trusted.
+		}
+
+		ip = addr.Unmap().String()
+		if !s.isTrustedProxy(addr) {
+			break
+		}
+	}
+	return ip
+}
+
+// isTrustedProxy reports whether addr belongs to a trusted proxy.
+func (s *Server) isTrustedProxy(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// DefaultRateLimitPolicies are the rate limit policies used when none are configured.
+var DefaultRateLimitPolicies = []pa.RateLimitPolicy{
+	// creating a comment pushes an event and creates a subscription.
+	{Path: "/v1/comments", Method: http.MethodPost, Key: pa.RateLimitKeyUser, Limit: 10, Period: time.Minute, Burst: 3},
+
+	// the oauth flow talks to the providers.
+	{Path: "/v1/oauth", Key: pa.RateLimitKeyIP, Limit: 30, Period: time.Minute},
+
+	{Path: "/", Key: pa.RateLimitKeyUser, Limit: 300, Period: time.Minute, Burst: 60},
+}
+
+// rateLimitPolicies returns the configured rate limit policies or the default policies.
+func (s *Server) rateLimitPolicies() []pa.RateLimitPolicy {
+	if len(s.conf.RateLimit.Policies) != 0 {
+		return s.conf.RateLimit.Policies
+	}
+	return DefaultRateLimitPolicies
+}
+
+// matchRateLimitPolicy returns the policy applying to r, the policy with the longest path wins and
+// policies restricted to the method of r win ties.
+// returns nil if no policy applies to r.
+func matchRateLimitPolicy(policies []pa.RateLimitPolicy, r *http.Request) *pa.RateLimitPolicy {
+	var match *pa.RateLimitPolicy
+	for i, policy := range policies {
+		if !policy.Matches(r.Method, r.URL.Path) {
+			continue
+		}
+
+		if match == nil || len(policy.Path) > len(match.Path) ||
+			len(policy.Path) == len(match.Path) && match.Method == "" && policy.Method != "" {
+			match = &policies[i]
+		}
+	}
+	return match
+}
+
+// rateLimitKey returns the key of the bucket of policy r takes its token from.
+func rateLimitKey(policy *pa.RateLimitPolicy, r *http.Request) string {
+	key := "ip:" + pa.ClientFromContext(r.Context()).IP
+
+	switch policy.Key {
+	case pa.RateLimitKeyAPIKey:
+		if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			// dont leak the api keys into the store.
+			sum := sha256.Sum256([]byte(strings.TrimPrefix(header, "Bearer ")))
+			key = "apiKey:" + hex.EncodeToString(sum[:])
+		}
+
+	case "", pa.RateLimitKeyUser:
+		if id := pa.UserIDFromContext(r.Context()); id != 0 {
+			key = "user:" + strconv.Itoa(id)
+		}
+	}
+
+	return policy.Method + " " + policy.Path + " " + key
+}
+
+// rateLimitMiddleware takes a token from the bucket of the policy applying to each request, requests
+// finding their bucket empty are rejected with 429. Requests go through if the rate limiter fails.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := matchRateLimitPolicy(s.rateLimitPolicies(), r)
+		if s.RateLimiter == nil || policy == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limit, err := s.RateLimiter.Allow(r.Context(), rateLimitKey(policy, r), *policy)
+		if err != nil {
+			LogError(r, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+		w.Header().Set("RateLimit-Reset", formatSeconds(limit.Reset))
+
+		if !limit.Allowed {
+			w.Header().Set("Retry-After", formatSeconds(limit.RetryAfter))
+			SendError(w, r, pa.Errorf(pa.ETOOMANYREQUESTS, "rate limit exceeded, retry in %ss.", formatSeconds(limit.RetryAfter)))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// formatSeconds formats d as a number of seconds rounded up.
+func formatSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,123 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+	"github.com/Lambels/patrickarvatu.com/inmem"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Run("Bad Get Call (Exhausted Bucket)", func(t *testing.T) {
+		conf := NewTestConfig()
+		conf.RateLimit.Policies = []pa.RateLimitPolicy{
+			{Path: "/v1/blogs", Key: pa.RateLimitKeyIP, Limit: 2, Period: time.Minute},
+		}
+		s, _ := MustOpenServer(t, conf)
+
+		now := time.Now()
+		limiter := inmem.NewRateLimiter()
+		limiter.Now = func() time.Time { return now }
+		s.RateLimiter = limiter
+
+		newRequest := func(path, ip string) *http.Request {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			r.RemoteAddr = ip + ":1234"
+			return r
+		}
+
+		for _, remaining := range []string{"1", "0"} {
+			resp := Serve(s, newRequest("/v1/blogs", "192.0.2.1"))
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status=%v", resp.StatusCode)
+			} else if v := resp.Header.Get("RateLimit-Limit"); v != "2" {
+				t.Fatalf("limit=%v", v)
+			} else if v := resp.Header.Get("RateLimit-Remaining"); v != remaining {
+				t.Fatalf("remaining=%v want=%v", v, remaining)
+			} else if v := resp.Header.Get("Retry-After"); v != "" {
+				t.Fatalf("retry after=%v", v)
+			}
+		}
+
+		// the bucket is empty, a token refills every 30s.
+		resp := Serve(s, newRequest("/v1/blogs", "192.0.2.1"))
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if v := resp.Header.Get("Retry-After"); v != "30" {
+			t.Fatalf("retry after=%v", v)
+		} else if v := resp.Header.Get("RateLimit-Remaining"); v != "0" {
+			t.Fatalf("remaining=%v", v)
+		} else if v := resp.Header.Get("RateLimit-Reset"); v != "60" {
+			t.Fatalf("reset=%v", v)
+		}
+
+		// other ips have their own bucket.
+		if resp := Serve(s, newRequest("/v1/blogs", "192.0.2.2")); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		// routes without a policy arent limited.
+		if resp := Serve(s, newRequest("/v1/projects", "192.0.2.1")); resp.Header.Get("RateLimit-Limit") != "" {
+			t.Fatalf("headers=%v", resp.Header)
+		}
+
+		// the bucket refills.
+		now = now.Add(30 * time.Second)
+		if resp := Serve(s, newRequest("/v1/blogs", "192.0.2.1")); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+	t.Run("Ok Get Call (Trusted Proxy)", func(t *testing.T) {
+		conf := NewTestConfig()
+		conf.HTTP.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.10"}
+		conf.RateLimit.Policies = []pa.RateLimitPolicy{
+			{Path: "/v1/blogs", Key: pa.RateLimitKeyIP, Limit: 1, Period: time.Minute},
+		}
+		s, _ := MustOpenServer(t, conf)
+		s.RateLimiter = inmem.NewRateLimiter()
+
+		newRequest := func(remoteIP, forwardedFor string) *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/v1/blogs", nil)
+			r.RemoteAddr = remoteIP + ":1234"
+			if forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", forwardedFor)
+			}
+			return r
+		}
+
+		// the clients behind the proxy get a bucket each.
+		if resp := Serve(s, newRequest("10.0.0.1", "203.0.113.1")); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if resp := Serve(s, newRequest("10.0.0.1", "203.0.113.2")); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if resp := Serve(s, newRequest("10.0.0.2", "203.0.113.1")); resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		// the client cant spoof its ip by prepending to the header, the trusted hops are skipped.
+		if resp := Serve(s, newRequest("10.0.0.1", "203.0.113.3, 203.0.113.1, 192.0.2.10")); resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		// the header of requests which dont come from a trusted proxy is ignored.
+		if resp := Serve(s, newRequest("198.51.100.1", "203.0.113.4")); resp.StatusCode != http.StatusOK {
+			t.Fatalf("status=%v", resp.StatusCode)
+		} else if resp := Serve(s, newRequest("198.51.100.1", "203.0.113.5")); resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+	})
+
+	t.Run("Bad Open Call (Invalid Trusted Proxy)", func(t *testing.T) {
+		conf := NewTestConfig()
+		conf.HTTP.TrustedProxies = []string{"10.0.0.0/33"}
+
+		s := pahttp.NewServer(conf)
+		if err := s.OpenTrustedProxies(); err == nil {
+			t.Fatal("err == nil")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+)
+
+// default security headers, see the http.headers config.
+const (
+	DefaultHSTSMaxAge     = 365 * 24 * time.Hour
+	DefaultReferrerPolicy = "strict-origin-when-cross-origin"
+	DefaultFrameOptions   = "DENY"
+
+	// DefaultCSP is the content security policy of the api, the api only serves data so nothing
+	// it responds with gets to load or run anything.
+	DefaultCSP = "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"
+)
+
+// FileCSP is the content security policy of the served files, opened on their own the files cant run
+// scripts or load anything while the inline styles of svgs still render.
+const FileCSP = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+
+// inlineContentTypes are the content types of the files rendered in the browser, every other file
+// (svgs included) is served as an attachment.
+var inlineContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+	"image/avif": true,
+}
+
+// securityHeadersMiddleware sets the security headers of the http.headers config on each response.
+func (s *Server) securityHeadersMiddleware(next http.Handler) http.Handler {
+	cfg := s.conf.HTTP.Headers
+	if cfg.HSTSMaxAge == 0 {
+		cfg.HSTSMaxAge = DefaultHSTSMaxAge
+	}
+	if cfg.ReferrerPolicy == "" {
+		cfg.ReferrerPolicy = DefaultReferrerPolicy
+	}
+	if cfg.FrameOptions == "" {
+		cfg.FrameOptions = DefaultFrameOptions
+	}
+	if cfg.CSP == "" {
+		cfg.CSP = DefaultCSP
+	}
+	hsts := fmt.Sprintf("max-age=%d; includeSubDomains", int(cfg.HSTSMaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		if s.UseTLS() {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		h.Set("X-Frame-Options", cfg.FrameOptions)
+		h.Set("Content-Security-Policy", cfg.CSP)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setFileHeaders sets the strict content security policy of the files and serves the files which
+// arent raster images as attachments.
+func setFileHeaders(w http.ResponseWriter, name, contentType string) {
+	w.Header().Set("Content-Security-Policy", FileCSP)
+
+	if inlineContentTypes[contentType] {
+		w.Header().Set("Content-Disposition", "inline")
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(name)))
+}
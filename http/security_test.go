@@ -0,0 +1,97 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	t.Run("Ok Get Call (Default Headers)", func(t *testing.T) {
+		s, _ := MustOpenServer(t, nil)
+
+		// errors carry the headers too.
+		for _, path := range []string{"/v1/blogs", "/v1/unknown"} {
+			resp := Serve(s, httptest.NewRequest(http.MethodGet, path, nil))
+			MustHaveHeaders(t, resp, map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           pahttp.DefaultFrameOptions,
+				"Referrer-Policy":           pahttp.DefaultReferrerPolicy,
+				"Content-Security-Policy":   pahttp.DefaultCSP,
+				"Strict-Transport-Security": "", // only sent when serving TLS.
+			})
+		}
+	})
+
+	t.Run("Ok Get Call (Configured Headers)", func(t *testing.T) {
+		conf := NewTestConfig()
+		conf.HTTP.Headers.FrameOptions = "SAMEORIGIN"
+		conf.HTTP.Headers.ReferrerPolicy = "no-referrer"
+		conf.HTTP.Headers.CSP = "default-src 'self'"
+		s, _ := MustOpenServer(t, conf)
+
+		MustHaveHeaders(t, Serve(s, httptest.NewRequest(http.MethodGet, "/v1/blogs", nil)), map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "SAMEORIGIN",
+			"Referrer-Policy":         "no-referrer",
+			"Content-Security-Policy": "default-src 'self'",
+		})
+	})
+}
+
+func TestServeFile(t *testing.T) {
+	s, _ := MustOpenServer(t, nil)
+
+	MustCreateFile(t, s.ImagesFileSystem, "/logo.png", MustEncodePNG(t, 8, 8))
+	MustCreateFile(t, s.ImagesFileSystem, "/logo.svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+	MustCreateFile(t, s.ImagesFileSystem, "/page.html", []byte(`<script>alert(1)</script>`))
+
+	for path, headers := range map[string]map[string]string{
+		// raster images render inline.
+		"/v1/images/logo.png": {
+			"Content-Type":        "image/png",
+			"Content-Disposition": "inline",
+		},
+		// anything able to run scripts is downloaded.
+		"/v1/images/logo.svg": {
+			"Content-Type":        "image/svg+xml",
+			"Content-Disposition": `attachment; filename="logo.svg"`,
+		},
+		"/v1/images/page.html": {
+			"Content-Disposition": `attachment; filename="page.html"`,
+		},
+	} {
+		resp := Serve(s, httptest.NewRequest(http.MethodGet, path, nil))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("path=%v status=%v", path, resp.StatusCode)
+		}
+
+		t.Log(path)
+		headers["Content-Security-Policy"] = pahttp.FileCSP
+		headers["X-Content-Type-Options"] = "nosniff"
+		MustHaveHeaders(t, resp, headers)
+	}
+
+	// missing files dont get the file policy.
+	resp := Serve(s, httptest.NewRequest(http.MethodGet, "/v1/images/missing.png", nil))
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status=%v", resp.StatusCode)
+	}
+	MustHaveHeaders(t, resp, map[string]string{
+		"Content-Security-Policy": pahttp.DefaultCSP,
+		"Content-Disposition":     "",
+	})
+}
+
+// MustHaveHeaders checks the headers of resp, an empty value asserts the header isnt set.
+func MustHaveHeaders(tb testing.TB, resp *http.Response, headers map[string]string) {
+	tb.Helper()
+
+	for name, want := range headers {
+		if got := resp.Header.Get(name); got != want {
+			tb.Fatalf("%s=%q want %q", name, got, want)
+		}
+	}
+}
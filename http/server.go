@@ -1,21 +1,23 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 	"time"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/go-chi/chi/v5"
-	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/gorilla/securecookie"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/robfig/cron/v3"
 	"golang.org/x/crypto/acme/autocert"
 	"golang.org/x/oauth2"
@@ -25,6 +27,19 @@ import (
 // ServerShutdownTime is the time the server allows processes to finish before shuting down.
 const ServerShutdownTime = 3 * time.Second
 
+// defaults of the trash purge job, deleted objects stay in the trash for the retention before being purged.
+const (
+	DefaultTrashSchedule  = "@daily"
+	DefaultTrashRetention = 30 * 24 * time.Hour
+)
+
+// DefaultExportTTL is the time the data exports of the users can be downloaded for, expired exports
+// get deleted on DefaultExportPurgeSchedule.
+const (
+	DefaultExportTTL           = 7 * 24 * time.Hour
+	DefaultExportPurgeSchedule = "@hourly"
+)
+
 // ReposEndpoint represents the endpoint to get repos for projects state, configurable for tests.
 var ReposEndpoint string = "https://api.github.com/users/Lambels/repos"
 
@@ -33,9 +48,12 @@ type Server struct {
 	server *http.Server
 	router *chi.Mux
 	ln     net.Listener
-	sc     *securecookie.SecureCookie
+	codecs []securecookie.Codec // the first codec encodes, all codecs decode.
+	cookie http.Cookie          // session cookie attributes.
 	cron   *cron.Cron
 
+	trustedProxies []netip.Prefix // forward the client ip under X-Forwarded-For.
+
 	// server address.
 	Addr   string
 	Domain string
@@ -50,8 +68,23 @@ type Server struct {
 	SubscriptionService pa.SubscriptionService
 	EmailService        pa.EmailService
 	ProjectService      pa.ProjectService
-	ProjectsFileSystem  pa.FileService
-	BlogsFileSystem     pa.FileService
+	MediaService        pa.MediaService
+	MediaFileSystem     pa.FileService
+	ImagesFileSystem    pa.FileService
+	ExportsFileSystem   pa.FileService
+	TrashService        pa.TrashService
+	AuditService        pa.AuditService
+	SpamService         pa.SpamService
+	BanService          pa.BanService
+
+	// SpamFilter decides on the comments before they get created, nil accepts all comments.
+	SpamFilter pa.SpamFilter
+
+	// RateLimiter holds the token buckets of the rate limit policies, nil disables rate limiting.
+	RateLimiter pa.RateLimiter
+
+	// BackupService is nil if the database driver doesent support backups.
+	BackupService pa.BackupService
 
 	conf *pa.Config
 }
@@ -62,29 +95,34 @@ func NewServer(conf *pa.Config) *Server {
 		server: &http.Server{},
 		router: chi.NewRouter(),
 		cron: cron.New(cron.WithLogger(
-			cron.DefaultLogger,
+			cronLogger{},
 		)),
 		conf: conf,
 	}
 
 	// middleware stack.
-	s.router.Use(chimw.Logger)
-	s.router.Use(s.authentificateMiddleware) // attempt to authentificate each request.
+	s.router.Use(s.requestIDMiddleware)       // tag each request and its logs with an id.
+	s.router.Use(s.traceMiddleware)           // span each request.
+	s.router.Use(s.metricsMiddleware)         // record the rate, errors and duration of each route.
+	s.router.Use(s.accessLogMiddleware)       // log each request.
+	s.router.Use(s.securityHeadersMiddleware) // set the security headers on each response.
+	s.router.Use(s.clientMiddleware)          // record the origin of each request for the audit log.
+	s.router.Use(s.authentificateMiddleware)  // attempt to authentificate each request.
 	s.router.Use(cors.Handler(
 		cors.Options{
 			AllowedOrigins:   []string{s.conf.HTTP.FrontendURL},
-			AllowedMethods:   []string{http.MethodGet, http.MethodDelete, http.MethodPost, http.MethodOptions, http.MethodPut},
+			AllowedMethods:   []string{http.MethodGet, http.MethodDelete, http.MethodPost, http.MethodOptions, http.MethodPut, http.MethodPatch},
+			AllowedHeaders:   []string{"Accept", "Content-Type", "X-Requested-With", CSRFHeader},
 			AllowCredentials: true,
+			ExposedHeaders:   []string{"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
 		},
 	))
+	s.router.Use(s.rateLimitMiddleware) // rate limit each request by user, api key or ip.
+	s.router.Use(s.csrfMiddleware)      // check the csrf token of the cookie authentificated state changing requests.
 
 	// set custom not found api handler.
 	s.router.NotFound(s.handleNotFound)
 
-	// register file servers.
-	imagesDir := http.Dir("./images")
-	s.registerFileServer(s.router, "/v1/images", imagesDir)
-
 	s.router.Route("/v1/oauth", func(r chi.Router) {
 		s.registerAuthRoutes(r)
 	})
@@ -116,6 +154,34 @@ func NewServer(conf *pa.Config) *Server {
 		s.registerProjectRoutes(r)
 	})
 
+	s.router.Route("/v1/media", func(r chi.Router) {
+		s.registerMediaRoutes(r)
+	})
+
+	s.router.Route("/v1/images", func(r chi.Router) {
+		s.registerImageRoutes(r)
+	})
+
+	s.router.Route("/v1/admin/backups", func(r chi.Router) {
+		s.registerBackupRoutes(r)
+	})
+
+	s.router.Route("/v1/admin/trash", func(r chi.Router) {
+		s.registerTrashRoutes(r)
+	})
+
+	s.router.Route("/v1/admin/audit", func(r chi.Router) {
+		s.registerAuditRoutes(r)
+	})
+
+	s.router.Route("/v1/admin/spam", func(r chi.Router) {
+		s.registerSpamRoutes(r)
+	})
+
+	s.router.Route("/v1/admin/bans", func(r chi.Router) {
+		s.registerBanRoutes(r)
+	})
+
 	// register router to server with registered routes.
 	s.server.Handler = s.router
 
@@ -131,11 +197,22 @@ func (s *Server) Open() error {
 		return fmt.Errorf("github client secret not set")
 	}
 
+	// validate the rate limit policies.
+	for _, policy := range s.rateLimitPolicies() {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("rate limit policy %s %s: %w", policy.Method, policy.Path, err)
+		}
+	}
+
 	// open the secure cookie implementation.
 	if err := s.openSecureCookie(); err != nil {
 		return err
 	}
 
+	if err := s.openTrustedProxies(); err != nil {
+		return err
+	}
+
 	s.gtihubRepoJob()
 
 	// register github cron job.
@@ -143,6 +220,27 @@ func (s *Server) Open() error {
 		return err
 	}
 
+	// register backup cron job.
+	if s.BackupService != nil && s.conf.Backup.Schedule != "" {
+		if err := s.RegisterCronJon(s.conf.Backup.Schedule, s.backupJob); err != nil {
+			return err
+		}
+	}
+
+	// register trash purge cron job.
+	schedule := s.conf.Trash.Schedule
+	if schedule == "" {
+		schedule = DefaultTrashSchedule
+	}
+	if err := s.RegisterCronJon(schedule, s.purgeTrashJob); err != nil {
+		return err
+	}
+
+	// register export purge cron job.
+	if err := s.RegisterCronJon(DefaultExportPurgeSchedule, s.purgeExportsJob); err != nil {
+		return err
+	}
+
 	// open cronjob.
 	s.openCronJob()
 
@@ -178,7 +276,9 @@ func (s *Server) Close() error {
 	return s.server.Shutdown(cancelCtx)
 }
 
-// openSecureCookie uses the keys under the config and checks their existance.
+// openSecureCookie uses the keys under the config and checks their existance, the previous keys
+// keep decoding the cookies and cursors signed before a rotation. It also validates the session
+// cookie attributes.
 func (s *Server) openSecureCookie() error {
 	// ensure keys are set.
 	if s.conf.HTTP.BlockKey == "" {
@@ -187,14 +287,73 @@ func (s *Server) openSecureCookie() error {
 		return fmt.Errorf("hash key isnt set")
 	}
 
-	s.sc = securecookie.New([]byte(s.conf.HTTP.HashKey), []byte(s.conf.HTTP.BlockKey))
-	s.sc.SetSerializer(securecookie.JSONEncoder{}) // use the json encoder.
+	keyPairs := [][]byte{[]byte(s.conf.HTTP.HashKey), []byte(s.conf.HTTP.BlockKey)}
+	for i, keys := range s.conf.HTTP.PreviousKeys {
+		if keys.HashKey == "" || keys.BlockKey == "" {
+			return fmt.Errorf("previous keys %d: hash key and block key must be set", i)
+		}
+		keyPairs = append(keyPairs, []byte(keys.HashKey), []byte(keys.BlockKey))
+	}
+
+	cookieCfg := s.conf.HTTP.Cookie
+	s.codecs = securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range s.codecs {
+		sc := codec.(*securecookie.SecureCookie)
+		sc.SetSerializer(securecookie.JSONEncoder{}) // use the json encoder.
+		if cookieCfg.MaxAge > 0 {
+			sc.MaxAge(int(cookieCfg.MaxAge.Seconds()))
+		}
+	}
+
+	sameSite, err := parseSameSite(cookieCfg.SameSite)
+	if err != nil {
+		return err
+	}
+
+	secure := s.UseTLS()
+	if cookieCfg.Secure != nil {
+		secure = *cookieCfg.Secure
+	}
+
+	s.cookie = http.Cookie{
+		Name:     cookieCfg.NamePrefix + pa.SessionCookieName,
+		Path:     "/",
+		Domain:   cookieCfg.Domain,
+		MaxAge:   int(cookieCfg.MaxAge.Seconds()),
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+
+	// browsers drop the cookies breaking these rules.
+	switch {
+	case sameSite == http.SameSiteNoneMode && !secure:
+		return fmt.Errorf("cookie: same-site none requires a secure cookie")
+	case strings.HasPrefix(s.cookie.Name, "__Secure-") && !secure:
+		return fmt.Errorf("cookie: the __Secure- prefix requires a secure cookie")
+	case strings.HasPrefix(s.cookie.Name, "__Host-") && (!secure || s.cookie.Domain != ""):
+		return fmt.Errorf("cookie: the __Host- prefix requires a secure cookie without domain")
+	}
 	return nil
 }
 
+// parseSameSite returns the SameSite mode named v, lax by default.
+func parseSameSite(v string) (http.SameSite, error) {
+	switch strings.ToLower(v) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("cookie: unknown same-site mode: %s", v)
+	}
+}
+
 // cronJob ------------------------------------------------------------------
 
-// openCronJob creates a new cron job with cron.DefaultLogger.
+// openCronJob starts the cron job scheduler, logging through cronLogger.
 func (s *Server) openCronJob() {
 	s.cron.Start()
 }
@@ -205,12 +364,17 @@ func (s *Server) RegisterCronJon(spec string, cmd func()) error {
 	return err
 }
 
-// RunDebugServer runs a debug server on port 8000
-// blocking function.
-func RunDebugServer() error {
-	s := http.NewServeMux()
-	s.Handle("/metrics", promhttp.Handler())
-	return http.ListenAndServe(":8000", s)
+// URL returns the base URL of the api, ie: "https://api.patrickarvatu.com".
+func (s *Server) URL() string {
+	if s.UseTLS() {
+		return "https://" + s.Domain
+	}
+
+	host, port, _ := net.SplitHostPort(s.Addr)
+	if host == "" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
 }
 
 // NewOAuthConfig returns an oauth2.0 config object to start the oauth2.0 authorization flow.
@@ -245,13 +409,13 @@ func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request) {
 // getSession returns a pa.Session from r.
 // returns an empty session on error.
 func (s *Server) getSession(r *http.Request) (pa.Session, error) {
-	c, err := r.Cookie(pa.SessionCookieName)
+	c, err := r.Cookie(s.cookie.Name)
 	if err != nil { // simply return an empty session if cookie isnt present.
 		return pa.Session{}, nil
 	}
 
 	var ses pa.Session
-	if err := s.sc.Decode(pa.SessionCookieName, c.Value, &ses); err != nil {
+	if err := securecookie.DecodeMulti(pa.SessionCookieName, c.Value, &ses, s.codecs...); err != nil {
 		return pa.Session{}, err
 	}
 	return ses, nil
@@ -259,19 +423,14 @@ func (s *Server) getSession(r *http.Request) (pa.Session, error) {
 
 // setSession sets ses on w using the securecookie service.
 func (s *Server) setSession(w http.ResponseWriter, ses pa.Session) error {
-	v, err := s.sc.Encode(pa.SessionCookieName, ses)
+	v, err := securecookie.EncodeMulti(pa.SessionCookieName, ses, s.codecs...)
 	if err != nil {
 		return err
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     pa.SessionCookieName,
-		Value:    v,
-		Path:     "/",
-		Secure:   s.UseTLS(),
-		Domain:   "localhost", // pass cookie to all sub domains including frontend and api.
-		HttpOnly: true,
-	})
+	c := s.cookie
+	c.Value = v
+	http.SetCookie(w, &c)
 	return nil
 }
 
@@ -286,6 +445,17 @@ func (s *Server) jsonResponseTypeMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// clientMiddleware attaches the ip and user agent of the request to its context, see clientIP.
+func (s *Server) clientMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := pa.NewContextWithClient(r.Context(), pa.Client{
+			IP:        s.clientIP(r),
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // authentificateMiddleware authentificates a requests based on api key or cookie.
 func (s *Server) authentificateMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -308,6 +478,15 @@ func (s *Server) authentificateMiddleware(next http.Handler) http.Handler {
 				return
 			}
 
+			// fully banned users cant use their api key.
+			if ban, err := s.findFullBan(r.Context(), users[0].ID); err != nil {
+				SendError(w, r, err)
+				return
+			} else if ban != nil {
+				SendError(w, r, banError(ban))
+				return
+			}
+
 			// set auth user to ctx and dispatch next handler.
 			users[0].IsAdmin = users[0].Email == s.conf.Github.AdminUserEmail // try to set admin.
 			r = r.WithContext(pa.NewContextWithUser(r.Context(), users[0]))
@@ -320,7 +499,22 @@ func (s *Server) authentificateMiddleware(next http.Handler) http.Handler {
 		// if we have a user id under session we fetch user.
 		if ses.UserID != 0 {
 			if user, err := s.UserService.FindUserByID(r.Context(), ses.UserID); err != nil {
-				log.Printf("FindUserByID: id=%v err=%s", ses.UserID, err)
+				pa.LoggerFromContext(r.Context()).Warn("FindUserByID failed.", "userID", ses.UserID, "err", err)
+			} else if !user.SessionsRevokedAt.IsZero() && !ses.CreatedAt.After(user.SessionsRevokedAt) {
+				// the session was revoked, drop it.
+				if err := s.setSession(w, pa.Session{}); err != nil {
+					pa.LoggerFromContext(r.Context()).Error("setSession failed.", "err", err)
+				}
+			} else if ban, err := s.findFullBan(r.Context(), user.ID); err != nil {
+				SendError(w, r, err)
+				return
+			} else if ban != nil {
+				// logged in during the ban, drop the session.
+				if err := s.setSession(w, pa.Session{}); err != nil {
+					pa.LoggerFromContext(r.Context()).Error("setSession failed.", "err", err)
+				}
+				SendError(w, r, banError(ban))
+				return
 			} else { // user found, ok.
 				user.IsAdmin = user.Email == s.conf.Github.AdminUserEmail // try to set admin.
 				r = r.WithContext(pa.NewContextWithUser(r.Context(), user))
@@ -375,10 +569,11 @@ func (s *Server) requireNoAuthMiddleware(next http.Handler) http.Handler {
 // HandleCommentEvent handels the pa.EventTopicNewComment -> ./event.go.
 // sends an email to all subscribers.
 func (s *Server) HandleCommentEvent(ctx context.Context, hand pa.SubscriptionService, event pa.Event) error {
-	log.Println("[DEBUG] HandleCommentEvent is running.")
+	logger := pa.LoggerFromContext(ctx).With("handler", "HandleCommentEvent")
+	logger.Debug("handling event.")
 	var payload pa.CommentPayload
 	if err := json.Unmarshal(event.Payload.([]byte), &payload); err != nil {
-		log.Println("[UnMarshalError] err: ", err.Error())
+		logger.Error("Unmarshal failed.", "err", err)
 		return err
 	}
 
@@ -388,7 +583,7 @@ func (s *Server) HandleCommentEvent(ctx context.Context, hand pa.SubscriptionSer
 		Payload: payload,
 	})
 	if err != nil {
-		log.Println("[FindSubscriptions] err: ", err.Error())
+		logger.Error("FindSubscriptions failed.", "err", err)
 		return err
 	} else if len(subs) == 0 { // no subscriptions.
 		return fmt.Errorf("no subscriptions found")
@@ -399,7 +594,15 @@ func (s *Server) HandleCommentEvent(ctx context.Context, hand pa.SubscriptionSer
 	for _, sub := range subs {
 		usr, err := s.UserService.FindUserByID(ctx, sub.UserID)
 		if err != nil {
-			log.Println("[FindUserByID] err: ", err.Error())
+			logger.Error("FindUserByID failed.", "err", err)
+			continue
+		}
+
+		// fully banned users arent notified.
+		if ban, err := s.findFullBan(ctx, usr.ID); err != nil {
+			logger.Error("FindActiveBan failed.", "err", err)
+			continue
+		} else if ban != nil {
 			continue
 		}
 
@@ -413,15 +616,15 @@ func (s *Server) HandleCommentEvent(ctx context.Context, hand pa.SubscriptionSer
 	if len(to) != 0 {
 		subBlog, err := s.SubBlogService.FindSubBlogByID(ctx, payload.SubBlogID)
 		if err != nil {
-			log.Println("[FindSubBlogByID] err: ", err.Error())
+			logger.Error("FindSubBlogByID failed.", "err", err)
 			return err
 		}
 
-		if err := s.EmailService.SendEmail(to,
-			fmt.Sprintf("There's been a new comment on %s, go check it out! %s", subBlog.Title, s.conf.HTTP.FrontendURL+"/sub-blog/"+fmt.Sprint(subBlog.ID)),
+		if err := s.EmailService.SendEmail(ctx, to,
+			fmt.Sprintf("There's been a new comment on %s, go check it out! %s", subBlog.Title, s.conf.HTTP.FrontendURL+"/sub-blog/"+subBlog.Slug),
 			fmt.Sprintf("New Comment On %s", subBlog.Title),
 		); err != nil {
-			log.Println("[SendEmail] err: ", err.Error())
+			logger.Error("SendEmail failed.", "err", err)
 			return err
 		}
 	}
@@ -431,10 +634,11 @@ func (s *Server) HandleCommentEvent(ctx context.Context, hand pa.SubscriptionSer
 // HandleSubBlogtEvent handels the pa.EventTopicNewSubBlog -> ./event.go.
 // sends an email to all subscribers.
 func (s *Server) HandleSubBlogEvent(ctx context.Context, hand pa.SubscriptionService, event pa.Event) error {
-	log.Println("[DEBUG] HandleSubBlogEvent is running.")
+	logger := pa.LoggerFromContext(ctx).With("handler", "HandleSubBlogEvent")
+	logger.Debug("handling event.")
 	var payload pa.SubBlogPayload
 	if err := json.Unmarshal(event.Payload.([]byte), &payload); err != nil {
-		log.Println("[UnMarshalError] err: ", err.Error())
+		logger.Error("Unmarshal failed.", "err", err)
 		return err
 	}
 
@@ -444,7 +648,7 @@ func (s *Server) HandleSubBlogEvent(ctx context.Context, hand pa.SubscriptionSer
 		Payload: payload,
 	})
 	if err != nil {
-		log.Println("[FindSubscriptions] err: ", err.Error())
+		logger.Error("FindSubscriptions failed.", "err", err)
 		return err
 	} else if len(subs) == 0 { // no subscriptions.
 		return fmt.Errorf("no subscriptions found")
@@ -455,7 +659,15 @@ func (s *Server) HandleSubBlogEvent(ctx context.Context, hand pa.SubscriptionSer
 	for _, sub := range subs {
 		usr, err := s.UserService.FindUserByID(ctx, sub.UserID)
 		if err != nil {
-			log.Println("[FindUserByID] err: ", err.Error())
+			logger.Error("FindUserByID failed.", "err", err)
+			continue
+		}
+
+		// fully banned users arent notified.
+		if ban, err := s.findFullBan(ctx, usr.ID); err != nil {
+			logger.Error("FindActiveBan failed.", "err", err)
+			continue
+		} else if ban != nil {
 			continue
 		}
 
@@ -469,17 +681,134 @@ func (s *Server) HandleSubBlogEvent(ctx context.Context, hand pa.SubscriptionSer
 	if len(to) != 0 {
 		blog, err := s.BlogService.FindBlogByID(ctx, payload.BlogID)
 		if err != nil {
-			log.Println("[FindBlogByID] err: ", err.Error())
+			logger.Error("FindBlogByID failed.", "err", err)
 			return err
 		}
 
-		if err := s.EmailService.SendEmail(to,
-			fmt.Sprintf("There's been a new article on %s, go check it out! %s", blog.Title, s.conf.HTTP.FrontendURL+"/blog/"+fmt.Sprint(blog.ID)),
+		if err := s.EmailService.SendEmail(ctx, to,
+			fmt.Sprintf("There's been a new article on %s, go check it out! %s", blog.Title, s.conf.HTTP.FrontendURL+"/blog/"+blog.Slug),
 			fmt.Sprintf("New Article On %s", blog.Title),
 		); err != nil {
-			log.Println("[SendEmail] err: ", err.Error())
+			logger.Error("SendEmail failed.", "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleUserExportEvent handels the pa.EventTopicUserExport -> ./event.go.
+// stores the data export of the user and emails him the download link.
+func (s *Server) HandleUserExportEvent(ctx context.Context, hand pa.SubscriptionService, event pa.Event) error {
+	logger := pa.LoggerFromContext(ctx).With("handler", "HandleUserExportEvent")
+	logger.Debug("handling event.")
+	var payload pa.UserExportPayload
+	if err := json.Unmarshal(event.Payload.([]byte), &payload); err != nil {
+		logger.Error("Unmarshal failed.", "err", err)
+		return err
+	}
+
+	user, err := s.UserService.FindUserByID(ctx, payload.UserID)
+	if err != nil {
+		logger.Error("FindUserByID failed.", "err", err)
+		return err
+	}
+	// the export holds the held comments of the user too, which are only visible to the admin user.
+	adminCtx := pa.NewContextWithUser(ctx, &pa.User{IsAdmin: true})
+
+	comments, _, err := s.CommentService.FindComments(adminCtx, pa.CommentFilter{UserID: &user.ID})
+	if err != nil {
+		logger.Error("FindComments failed.", "err", err)
+		return err
+	}
+
+	// collect the subscriptions of each topic.
+	var subs []*pa.Subscription
+	for _, topic := range []string{pa.EventTopicNewSubBlog, pa.EventTopicNewComment} {
+		topic := topic
+		topicSubs, _, err := hand.FindSubscriptions(adminCtx, pa.SubscriptionFilter{
+			UserID: &user.ID,
+			Topic:  &topic,
+		})
+		if err != nil {
+			logger.Error("FindSubscriptions failed.", "err", err)
 			return err
 		}
+		subs = append(subs, topicSubs...)
+	}
+
+	export, err := newUserExport(user, comments, subs)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ExportsFileSystem.CreateFile(adminCtx, userExportPath(user.ID), bytes.NewReader(export)); err != nil {
+		logger.Error("CreateFile failed.", "err", err)
+		return err
+	}
+
+	// nowhere to send the link to, the export can still be downloaded.
+	if user.Email == "" {
+		return nil
+	}
+
+	if err := s.EmailService.SendEmail(ctx, []string{user.Email},
+		fmt.Sprintf("Your data export is ready, download it within %d days: %s", int(DefaultExportTTL.Hours()/24), s.URL()+"/v1/users/"+strconv.Itoa(user.ID)+"/export/download"),
+		"Your Data Export",
+	); err != nil {
+		logger.Error("SendEmail failed.", "err", err)
+		return err
+	}
+	return nil
+}
+
+// HandleUserEraseEvent handels the pa.EventTopicUserErase -> ./event.go.
+// erases the user with the requested mode, removes his data export and emails him a confirmation.
+// once the user is erased the event is never retried, the confirmation is best effort.
+func (s *Server) HandleUserEraseEvent(ctx context.Context, hand pa.SubscriptionService, event pa.Event) error {
+	logger := pa.LoggerFromContext(ctx).With("handler", "HandleUserEraseEvent")
+	logger.Debug("handling event.")
+	var payload pa.UserErasePayload
+	if err := json.Unmarshal(event.Payload.([]byte), &payload); err != nil {
+		logger.Error("Unmarshal failed.", "err", err)
+		return err
+	}
+
+	user, err := s.UserService.FindUserByID(ctx, payload.UserID)
+	if pa.ErrorCode(err) == pa.ENOTFOUND { // already erased.
+		logger.Warn("user already erased.", "userID", payload.UserID)
+		return nil
+	} else if err != nil {
+		logger.Error("FindUserByID failed.", "err", err)
+		return err
+	}
+	userCtx := pa.NewContextWithUser(ctx, user)
+
+	switch payload.Mode {
+	case pa.ErasureModeAnonymize:
+		err = s.UserService.AnonymizeUser(userCtx, user.ID)
+	default:
+		err = s.UserService.DeleteUser(userCtx, user.ID)
+	}
+	if err != nil {
+		logger.Error("EraseUser failed.", "err", err)
+		return err
+	}
+
+	adminCtx := pa.NewContextWithUser(ctx, &pa.User{IsAdmin: true})
+	if err := s.ExportsFileSystem.DeleteFile(adminCtx, userExportPath(user.ID)); err != nil && pa.ErrorCode(err) != pa.ENOTFOUND {
+		logger.Error("DeleteFile failed.", "err", err)
+	}
+
+	if user.Email == "" {
+		return nil
+	}
+
+	if err := s.EmailService.SendEmail(ctx, []string{user.Email},
+		"Your account and its data have been erased.",
+		"Your Account Was Erased",
+	); err != nil {
+		// the user is gone, a retry couldnt find the address again.
+		logger.Error("SendEmail failed.", "err", err)
 	}
 	return nil
 }
@@ -488,21 +817,38 @@ func (s *Server) HandleSubBlogEvent(ctx context.Context, hand pa.SubscriptionSer
 
 // gtihubRepoJob represents an hourly job to sync system project state with github project state.
 func (s *Server) gtihubRepoJob() {
-	log.Println("[INFO] Running github repo job.")
+	logger := slog.Default().With("job", "githubRepos")
+	logger.Info("running job.")
+	start := time.Now()
+
+	n, err := s.syncGithubRepos(logger)
+	githubSyncDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		githubSyncCounter.WithLabelValues("failure").Inc()
+		return
+	}
+
+	githubSyncCounter.WithLabelValues("success").Inc()
+	githubSyncLastSuccess.SetToCurrentTime()
+	githubSyncProjects.Set(float64(n))
+}
+
+// syncGithubRepos syncs the projects with the github repos and returns the number of synced projects.
+func (s *Server) syncGithubRepos(logger *slog.Logger) (int, error) {
 	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
 
 	// get current projects.
 	currentProjects, _, err := s.ProjectService.FindProjects(adminCtx, pa.ProjectFilter{})
 	if err != nil {
-		log.Println("[FindProjects] err: ", err.Error())
-		return
+		logger.Error("FindProjects failed.", "err", err)
+		return 0, err
 	}
 
 	// get projects from github.
 	ghProjects, err := s.getRepos()
 	if err != nil {
-		log.Println("[getRepos] err: ", err.Error())
-		return
+		logger.Error("getRepos failed.", "err", err)
+		return 0, err
 	}
 
 	// used to check for project existance.
@@ -517,8 +863,8 @@ func (s *Server) gtihubRepoJob() {
 		// project not found in new map, delete of project on github -> delete project here.
 		if _, ok := ghProjectMap[v.Name]; !ok {
 			if err := s.ProjectService.DeleteProject(adminCtx, v.Name); err != nil {
-				log.Println("[DeleteProjects] err: ", err.Error())
-				return
+				logger.Error("DeleteProjects failed.", "err", err)
+				return 0, err
 			}
 		}
 	}
@@ -527,9 +873,12 @@ func (s *Server) gtihubRepoJob() {
 	for _, v := range ghProjects {
 		// after purging non existing projects we update or create remainibg projects.
 		if err := s.ProjectService.CreateOrUpdateProject(adminCtx, v); err != nil {
-			return
+			logger.Error("CreateOrUpdateProject failed.", "err", err)
+			return 0, err
 		}
 	}
+
+	return len(ghProjects), nil
 }
 
 // getRepos returns a list of projects from the github api.
@@ -576,18 +925,32 @@ func (s *Server) getRepos() ([]*pa.Project, error) {
 	return projects, nil
 }
 
-// https://github.com/go-chi/chi/blob/master/_examples/fileserver/main.go
-func (s *Server) registerFileServer(r chi.Router, path string, root http.FileSystem) {
-	if strings.ContainsAny(path, "{}*") {
-		panic("[Error] FileServer path does not allow any url params")
+// serveFile streams the file at path from fileService.
+func serveFile(w http.ResponseWriter, r *http.Request, fileService pa.FileService, path string) {
+	f, info, err := fileService.OpenFile(r.Context(), path)
+	if err != nil {
+		SendError(w, r, err)
+		return
 	}
+	defer f.Close()
 
-	path += "/*"
+	// conditional request.
+	if !info.ModTime.IsZero() {
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !info.ModTime.Truncate(time.Second).After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
 
-	r.Get(path, func(w http.ResponseWriter, r *http.Request) {
-		rctx := chi.RouteContext(r.Context())
-		pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
-		fs := http.StripPrefix(pathPrefix, http.FileServer(root))
-		fs.ServeHTTP(w, r)
-	})
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	setFileHeaders(w, path, contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.WriteHeader(http.StatusOK)
+
+	io.Copy(w, f)
 }
@@ -1 +1,224 @@
 package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/fs"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+const adminEmail = "lamb@lambels.com"
+
+// NewTestConfig returns the config of the test servers, its keys are only meant for tests.
+func NewTestConfig() *pa.Config {
+	conf := &pa.Config{}
+	conf.Github.AdminUserEmail = adminEmail
+	conf.HTTP.FrontendURL = "http://localhost:3000"
+	conf.HTTP.HashKey = "0123456789abcdef0123456789abcdef"
+	conf.HTTP.BlockKey = "abcdef0123456789abcdef0123456789"
+	return conf
+}
+
+// MustOpenServer returns a server on top of a temp sqlite database, requests are served through
+// Server.ServeHTTP. conf defaults to NewTestConfig.
+func MustOpenServer(tb testing.TB, conf *pa.Config) (*pahttp.Server, *sqlite.DB) {
+	tb.Helper()
+
+	if conf == nil {
+		conf = NewTestConfig()
+	}
+
+	db := sqlite.NewDB(filepath.Join(tb.TempDir(), "db"))
+	if err := db.Open(); err != nil {
+		tb.Fatalf("open: %s", err.Error())
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	s := pahttp.NewServer(conf)
+	s.AuthService = sqlite.NewAuthService(db)
+	s.UserService = sqlite.NewUserService(db)
+	s.BlogService = sqlite.NewBlogService(db)
+	s.SubBlogService = sqlite.NewSubBlogService(db)
+	s.CommentService = sqlite.NewCommentService(db)
+	s.SubscriptionService = sqlite.NewSubscriptionService(db)
+	s.ProjectService = sqlite.NewProjectService(db)
+	s.MediaService = sqlite.NewMediaService(db)
+	s.TrashService = sqlite.NewTrashService(db)
+	s.AuditService = sqlite.NewAuditService(db)
+	s.SpamService = sqlite.NewSpamService(db)
+	s.BanService = sqlite.NewBanService(db)
+	s.MediaFileSystem = fs.NewFileService(filepath.Join(tb.TempDir(), "media"))
+	s.ImagesFileSystem = fs.NewFileService(filepath.Join(tb.TempDir(), "images"))
+	s.ExportsFileSystem = fs.NewFileService(filepath.Join(tb.TempDir(), "exports"))
+
+	if err := s.OpenSecureCookie(); err != nil {
+		tb.Fatal(err)
+	} else if err := s.OpenTrustedProxies(); err != nil {
+		tb.Fatal(err)
+	}
+	return s, db
+}
+
+// MustCreateUser creates user and returns a context authentificated as user.
+func MustCreateUser(tb testing.TB, db *sqlite.DB, user *pa.User) context.Context {
+	tb.Helper()
+	if err := sqlite.NewUserService(db).CreateUser(context.Background(), user); err != nil {
+		tb.Fatal(err)
+	}
+	user.IsAdmin = user.Email == adminEmail
+	return pa.NewContextWithUser(context.Background(), user)
+}
+
+// MustLogin returns the session cookie of a session of the user with id: userID.
+func MustLogin(tb testing.TB, s *pahttp.Server, userID int, csrfToken string) *http.Cookie {
+	tb.Helper()
+	return MustLoginAt(tb, s, userID, csrfToken, time.Now())
+}
+
+// MustLoginAt returns the session cookie of a session of the user with id: userID created at createdAt.
+func MustLoginAt(tb testing.TB, s *pahttp.Server, userID int, csrfToken string, createdAt time.Time) *http.Cookie {
+	tb.Helper()
+
+	w := httptest.NewRecorder()
+	if err := s.SetSession(w, pa.Session{
+		UserID:    userID,
+		CreatedAt: createdAt,
+		CSRFToken: csrfToken,
+	}); err != nil {
+		tb.Fatal(err)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		tb.Fatalf("cookies=%v", cookies)
+	}
+	return cookies[0]
+}
+
+// MustFindAPIKey returns the current api key of the user authentificated by usrCtx.
+func MustFindAPIKey(tb testing.TB, s *pahttp.Server, usrCtx context.Context) string {
+	tb.Helper()
+
+	user, err := s.UserService.FindUserByID(usrCtx, pa.UserIDFromContext(usrCtx))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return user.APIKey
+}
+
+// Serve serves r through s and returns the response.
+func Serve(s *pahttp.Server, r *http.Request) *http.Response {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w.Result()
+}
+
+func TestOpenSecureCookie(t *testing.T) {
+	t.Run("Ok Open Call (Rotated Keys)", func(t *testing.T) {
+		old, _ := MustOpenServer(t, nil)
+		cookie := MustLogin(t, old, 1, "token")
+
+		// the keys get rotated, the old keys keep decoding.
+		conf := NewTestConfig()
+		conf.HTTP.PreviousKeys = append(conf.HTTP.PreviousKeys, struct {
+			HashKey  string `mapstructure:"hash-key"`
+			BlockKey string `mapstructure:"block-key"`
+		}{
+			HashKey:  conf.HTTP.HashKey,
+			BlockKey: conf.HTTP.BlockKey,
+		})
+		conf.HTTP.HashKey = "fedcba9876543210fedcba9876543210"
+		conf.HTTP.BlockKey = "9876543210fedcba9876543210fedcba"
+		rotated, _ := MustOpenServer(t, conf)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(cookie)
+		if ses, err := rotated.GetSession(r); err != nil {
+			t.Fatal(err)
+		} else if ses.UserID != 1 || ses.CSRFToken != "token" {
+			t.Fatalf("session=%+v", ses)
+		}
+
+		// new cookies are signed with the current keys only.
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(MustLogin(t, rotated, 2, "token"))
+		if _, err := old.GetSession(r); err == nil {
+			t.Fatal("err == nil")
+		}
+
+		// dropping the previous keys invalidates the old cookies.
+		conf.HTTP.PreviousKeys = nil
+		dropped, _ := MustOpenServer(t, conf)
+
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(cookie)
+		if _, err := dropped.GetSession(r); err == nil {
+			t.Fatal("err == nil")
+		}
+	})
+
+	t.Run("Ok Open Call (Host Prefix)", func(t *testing.T) {
+		conf := NewTestConfig()
+		conf.HTTP.Cookie.NamePrefix = "__Host-"
+		conf.HTTP.Cookie.SameSite = "none"
+		conf.HTTP.Cookie.Secure = NewBoolPointer(true)
+		s, _ := MustOpenServer(t, conf)
+
+		cookie := MustLogin(t, s, 1, "")
+		if cookie.Name != "__Host-"+pa.SessionCookieName || !cookie.Secure || !cookie.HttpOnly || cookie.Domain != "" {
+			t.Fatalf("cookie=%+v", cookie)
+		} else if cookie.SameSite != http.SameSiteNoneMode || cookie.Path != "/" {
+			t.Fatalf("cookie=%+v", cookie)
+		}
+	})
+
+	for name, configure := range map[string]func(conf *pa.Config){
+		"Same Site None Without Secure": func(conf *pa.Config) {
+			conf.HTTP.Cookie.SameSite = "none"
+		},
+		"Secure Prefix Without Secure": func(conf *pa.Config) {
+			conf.HTTP.Cookie.NamePrefix = "__Secure-"
+		},
+		"Host Prefix Without Secure": func(conf *pa.Config) {
+			conf.HTTP.Cookie.NamePrefix = "__Host-"
+			conf.HTTP.Cookie.Secure = NewBoolPointer(false)
+		},
+		"Host Prefix With Domain": func(conf *pa.Config) {
+			conf.HTTP.Cookie.NamePrefix = "__Host-"
+			conf.HTTP.Cookie.Secure = NewBoolPointer(true)
+			conf.HTTP.Cookie.Domain = "patrickarvatu.com"
+		},
+		"Unknown Same Site": func(conf *pa.Config) {
+			conf.HTTP.Cookie.SameSite = "sometimes"
+		},
+		"Previous Keys Without Block Key": func(conf *pa.Config) {
+			conf.HTTP.PreviousKeys = append(conf.HTTP.PreviousKeys, struct {
+				HashKey  string `mapstructure:"hash-key"`
+				BlockKey string `mapstructure:"block-key"`
+			}{
+				HashKey: conf.HTTP.HashKey,
+			})
+		},
+	} {
+		configure := configure
+		t.Run("Bad Open Call ("+name+")", func(t *testing.T) {
+			conf := NewTestConfig()
+			configure(conf)
+
+			if err := pahttp.NewServer(conf).OpenSecureCookie(); err == nil {
+				t.Fatal("err == nil")
+			}
+		})
+	}
+}
+
+func NewBoolPointer(b bool) *bool {
+	return &b
+}
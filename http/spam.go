@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/go-chi/chi/v5"
+)
+
+// registerSpamRoutes registers the spam routes under r.
+func (s *Server) registerSpamRoutes(r chi.Router) {
+	r.Use(s.adminAuthMiddleware)
+
+	r.Get("/rules", s.handleGetSpamRules)
+	r.Post("/rules", s.handleCreateSpamRule)
+	r.Delete("/rules/{ruleID}", s.handleDeleteSpamRule)
+
+	r.Get("/held", s.handleGetHeldComments)
+	r.Post("/held/{commentID}/approve", s.handleApproveComment)
+	r.Delete("/held/{commentID}", s.handleRejectComment)
+}
+
+// handleGetSpamRules handels GET '/admin/spam/rules'
+// returns the banned words and regular expressions, oldest first.
+func (s *Server) handleGetSpamRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.SpamService.FindSpamRules(r.Context())
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, getSpamRulesResponse{
+		N:     len(rules),
+		Rules: rules,
+	})
+}
+
+// handleCreateSpamRule handels POST '/admin/spam/rules'
+// creates a spam rule with the request body and responds with the created rule.
+func (s *Server) handleCreateSpamRule(w http.ResponseWriter, r *http.Request) {
+	var rule pa.SpamRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid JSON body"))
+		return
+	}
+
+	if err := s.SpamService.CreateSpamRule(r.Context(), &rule); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	SendJSON(w, rule)
+}
+
+// handleDeleteSpamRule handels DELETE '/admin/spam/rules/{ruleID}'
+// permanently deletes the spam rule pointed to by ruleID.
+func (s *Server) handleDeleteSpamRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "ruleID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	if err := s.SpamService.DeleteSpamRule(r.Context(), id); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetHeldComments handels GET '/admin/spam/held'
+// returns the comments held by the spam filter, oldest first.
+func (s *Server) handleGetHeldComments(w http.ResponseWriter, r *http.Request) {
+	filter := pa.CommentFilter{
+		IncludeUser: true,
+		Limit:       20,
+	}
+	v := pa.CommentStatusHeld
+	filter.Status = &v
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil {
+			SendError(w, r, pa.Errorf(pa.EINVALID, "invalid offset format"))
+			return
+		}
+		filter.Offset = offset
+	}
+
+	comments, n, err := s.CommentService.FindComments(r.Context(), filter)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, getCommentsResponse{
+		N:        n,
+		Comments: comments,
+	})
+}
+
+// handleApproveComment handels POST '/admin/spam/held/{commentID}/approve'
+// approves the held comment pointed to by commentID and pushes a pa.EventTopicNewComment -> ./event.go.
+func (s *Server) handleApproveComment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "commentID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	comment, err := s.CommentService.FindCommentByID(r.Context(), id)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	} else if comment.Status != pa.CommentStatusHeld {
+		SendError(w, r, pa.Errorf(pa.ECONFLICT, "comment isnt held."))
+		return
+	}
+
+	v := pa.CommentStatusApproved
+	if comment, err = s.CommentService.UpdateComment(r.Context(), id, pa.CommentUpdate{Status: &v}); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	if err := s.EventService.Push(r.Context(), pa.Event{
+		Topic: pa.EventTopicNewComment,
+		Payload: pa.CommentPayload{
+			SubBlogID: comment.SubBlogID,
+		},
+	}); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, comment)
+}
+
+// handleRejectComment handels DELETE '/admin/spam/held/{commentID}'
+// moves the held comment pointed to by commentID to the trash.
+func (s *Server) handleRejectComment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "commentID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	if comment, err := s.CommentService.FindCommentByID(r.Context(), id); err != nil {
+		SendError(w, r, err)
+		return
+	} else if comment.Status != pa.CommentStatusHeld {
+		SendError(w, r, pa.Errorf(pa.ECONFLICT, "comment isnt held."))
+		return
+	}
+
+	if err := s.CommentService.DeleteComment(r.Context(), id); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
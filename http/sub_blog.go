@@ -14,7 +14,7 @@ import (
 // registerBlogRoutes registers the sub blog routes under r.
 func (s *Server) registerSubBlogRoutes(r chi.Router) {
 	r.Get("/", s.handleGetSubBlogs)
-	r.Get("/{subBlogID}", s.handleGetSubBlog)
+	r.Get("/{subBlogIDOrSlug}", s.handleGetSubBlog)
 	r.Get("/{subBlogID}/comments", s.handleGetComments)
 
 	r.Route("/", func(r chi.Router) {
@@ -30,6 +30,8 @@ func (s *Server) registerSubBlogRoutes(r chi.Router) {
 
 // handleGetSubBlogs handels GET '/sub-blogs/', '/blogs/{blogID}/sub-blogs'
 // looks for blogID and over writes it with anything passed in the body.
+// the "include" query param (comments) selects the loaded relations.
+// the "sortBy", "order", "createdAfter", "createdBefore" and "titleContains" query params mirror the filter.
 func (s *Server) handleGetSubBlogs(w http.ResponseWriter, r *http.Request) {
 	var filter pa.SubBlogFilter
 
@@ -47,23 +49,40 @@ func (s *Server) handleGetSubBlogs(w http.ResponseWriter, r *http.Request) {
 	// get filter params from:
 	switch r.Header.Get("Content-Type") {
 	case "application/json":
-		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
-			SendError(w, r, pa.Errorf(pa.EINVALID, "invalid JSON body"))
+		cursor, err := s.decodeListBody(r, &filter)
+		if err != nil {
+			SendError(w, r, err)
 			return
 		}
+		filter.Cursor = cursor
 
 	default:
-		var offset int
-		var err error
-		if v := r.URL.Query().Get("offset"); v != "" {
-			if offset, err = strconv.Atoi(v); err != nil {
-				SendError(w, r, pa.Errorf(pa.EINVALID, "invalid offset format"))
-				return
-			}
+		offset, cursor, err := s.parseListQuery(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		include, err := parseInclude(r, "comments")
+		if err != nil {
+			SendError(w, r, err)
+			return
 		}
 
+		spec, err := parseQuerySpec(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		filter.QuerySpec = spec
 		filter.Offset = offset
+		filter.Cursor = cursor
 		filter.Limit = 20
+		if v := r.URL.Query().Get("titleContains"); v != "" {
+			filter.TitleContains = &v
+		}
+		filter.IncludeComments = include["comments"]
 	}
 
 	// fetch data from database.
@@ -73,21 +92,49 @@ func (s *Server) handleGetSubBlogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	page, err := s.newPageResponse(filter.Cursor, filter.SortBy, filter.Offset, n, len(subBlogs), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: subBlogs[i].CreatedAt, ID: subBlogs[i].ID}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
 	SendJSON(w, getSubBlogsResponse{
-		N:        n,
-		SubBlogs: subBlogs,
+		N:            n,
+		SubBlogs:     subBlogs,
+		pageResponse: page,
 	})
 }
 
-// handleGetSubBlog handels GET '/sub-blogs/{subBlogID}'
-// looks for sub blog with id: subBlogID.
+// handleGetSubBlog handels GET '/sub-blogs/{subBlogIDOrSlug}'
+// checks if subBlogIDOrSlug is an integer, if not returns sub blog with slug: subBlogIDOrSlug
+// else returns sub blog with id: subBlogIDOrSlug.
+// redirects with 301 to the current slug if subBlogIDOrSlug is an old slug of the sub blog.
 func (s *Server) handleGetSubBlog(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "subBlogID"))
-	if err != nil {
-		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+	param := chi.URLParam(r, "subBlogIDOrSlug")
+
+	id, err := strconv.Atoi(param)
+	if err != nil { // we have a slug.
+		// fetch sub blog from database.
+		subBlog, err := s.SubBlogService.FindSubBlogBySlug(r.Context(), param)
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+
+		// the sub blog got renamed, point to the current slug.
+		if subBlog.Slug != param {
+			redirectToSlug(w, r, param, subBlog.Slug)
+			return
+		}
+
+		// send response.
+		SendJSON(w, subBlog)
 		return
 	}
 
+	// we have a integer.
 	// fetch sub blog from database.
 	subBlog, err := s.SubBlogService.FindSubBlogByID(r.Context(), id)
 	if err != nil {
@@ -193,7 +240,7 @@ func (s *Server) handleUpdateSubBlog(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleDeleteSubBlog handels DELETE '/sub-blogs/{subBlogID}'
-// permanently deletes the sub blog pointed to by subBlogID.
+// moves the sub blog pointed to by subBlogID to the trash.
 func (s *Server) handleDeleteSubBlog(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "subBlogID"))
 	if err != nil {
@@ -20,29 +20,34 @@ func (s *Server) registerSubscriptionRoutes(r chi.Router) {
 }
 
 // handleGetSubscriptions handels GET '/subscriptions/'
-// retrieves subscriptions based on request body.
+// retrieves subscriptions based on request body or the "topic", "offset" and "cursor" query params.
+// the subscriptions of both topics are retrieved if no topic is passed, the cursors page through a
+// single topic.
 func (s *Server) handleGetSubscriptions(w http.ResponseWriter, r *http.Request) {
 	var filter pa.SubscriptionFilter
 
 	// get filter params from:
-	switch w.Header().Get("Content-Type") {
+	switch r.Header.Get("Content-Type") {
 	case "application/json":
-		if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		cursor, err := s.decodeListBody(r, &filter)
+		if err != nil {
 			SendError(w, r, err)
 			return
 		}
+		filter.Cursor = cursor
 
 	default:
-		var offset int
-		var err error
-		if v := r.URL.Query().Get("offset"); v != "" {
-			if offset, err = strconv.Atoi(v); err != nil {
-				SendError(w, r, pa.Errorf(pa.EINVALID, "invalid offset format"))
-				return
-			}
+		offset, cursor, err := s.parseListQuery(r)
+		if err != nil {
+			SendError(w, r, err)
+			return
 		}
 
+		if v := r.URL.Query().Get("topic"); v != "" {
+			filter.Topic = &v
+		}
 		filter.Offset = offset
+		filter.Cursor = cursor
 		filter.Limit = 20
 	}
 
@@ -52,7 +57,12 @@ func (s *Server) handleGetSubscriptions(w http.ResponseWriter, r *http.Request)
 
 	resp := new(getSubscriptionsResponse)
 	// we have no specific topic -> get both
-	if *filter.Topic == "" {
+	if filter.Topic == nil || *filter.Topic == "" {
+		if filter.Cursor != nil {
+			SendError(w, r, pa.Errorf(pa.EINVALID, "cursor requires a topic."))
+			return
+		}
+
 		// subscription filter with new sub blog topic.
 		v := pa.EventTopicNewSubBlog
 		filter.Topic = &v
@@ -83,6 +93,15 @@ func (s *Server) handleGetSubscriptions(w http.ResponseWriter, r *http.Request)
 
 		resp.serializeIn(subscriptions...)
 		resp.N = n
+
+		page, err := s.newPageResponse(filter.Cursor, "", filter.Offset, n, len(subscriptions), func(i int) pa.Cursor {
+			return pa.Cursor{CreatedAt: subscriptions[i].CreatedAt, ID: subscriptions[i].ID}
+		})
+		if err != nil {
+			SendError(w, r, err)
+			return
+		}
+		resp.pageResponse = page
 	}
 
 	// send response.
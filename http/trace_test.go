@@ -0,0 +1,123 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceMiddleware(t *testing.T) {
+	ended := MustRecordSpans(t)
+	s, db := MustOpenServer(t, nil)
+
+	t.Run("Ok Get Call (Route Pattern)", func(t *testing.T) {
+		n := len(ended())
+
+		resp := Serve(s, httptest.NewRequest(http.MethodGet, "/v1/blogs/some-slug", nil))
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		span := MustFindSpan(t, ended()[n:], trace.SpanKindServer)
+		if span.Name() != "GET /v1/blogs/{blogIDOrSlug}" {
+			t.Fatalf("name=%v", span.Name())
+		} else if span.Status().Code == codes.Error {
+			t.Fatalf("status=%v", span.Status())
+		} else if v := MustSpanAttribute(t, span, "http.route"); v.AsString() != "/v1/blogs/{blogIDOrSlug}" {
+			t.Fatalf("route=%v", v.AsString())
+		} else if v := MustSpanAttribute(t, span, "http.status_code"); v.AsInt64() != http.StatusNotFound {
+			t.Fatalf("status code=%v", v.AsInt64())
+		}
+	})
+
+	t.Run("Ok Get Call (Incoming Trace)", func(t *testing.T) {
+		n := len(ended())
+
+		// the span continues the trace of the caller.
+		r := httptest.NewRequest(http.MethodGet, "/v1/blogs/", nil)
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		Serve(s, r)
+
+		span := MustFindSpan(t, ended()[n:], trace.SpanKindServer)
+		if id := span.SpanContext().TraceID().String(); id != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("trace id=%v", id)
+		} else if id := span.Parent().SpanID().String(); id != "00f067aa0ba902b7" {
+			t.Fatalf("parent id=%v", id)
+		}
+	})
+
+	t.Run("Bad Get Call (Internal Error)", func(t *testing.T) {
+		n := len(ended())
+
+		// the closed database fails the request.
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+		resp := Serve(s, httptest.NewRequest(http.MethodGet, "/v1/blogs/1", nil))
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("status=%v", resp.StatusCode)
+		}
+
+		span := MustFindSpan(t, ended()[n:], trace.SpanKindServer)
+		if span.Name() != "GET /v1/blogs/{blogIDOrSlug}" {
+			t.Fatalf("name=%v", span.Name())
+		} else if span.Status().Code != codes.Error {
+			t.Fatalf("status=%v", span.Status())
+		}
+	})
+}
+
+var (
+	spanRecorder     = tracetest.NewSpanRecorder()
+	spanRecorderOnce sync.Once
+)
+
+// MustRecordSpans records the spans of the package, the tracers delegate to the first global tracer
+// provider only so the provider is set once for all the tests.
+func MustRecordSpans(tb testing.TB) func() []sdktrace.ReadOnlySpan {
+	tb.Helper()
+
+	spanRecorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return spanRecorder.Ended
+}
+
+// MustFindSpan returns the single span of kind: kind in spans.
+func MustFindSpan(tb testing.TB, spans []sdktrace.ReadOnlySpan, kind trace.SpanKind) sdktrace.ReadOnlySpan {
+	tb.Helper()
+
+	var found []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.SpanKind() == kind {
+			found = append(found, span)
+		}
+	}
+	if len(found) != 1 {
+		tb.Fatalf("spans=%v", len(found))
+	}
+	return found[0]
+}
+
+// MustSpanAttribute returns the value of the attribute key of span.
+func MustSpanAttribute(tb testing.TB, span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	tb.Helper()
+
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	tb.Fatalf("no attribute: %v", key)
+	return attribute.Value{}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/go-chi/chi/v5"
+)
+
+// registerTrashRoutes registers the trash routes under r.
+func (s *Server) registerTrashRoutes(r chi.Router) {
+	r.Use(s.adminAuthMiddleware)
+
+	r.Get("/", s.handleGetTrash)
+
+	r.Post("/{type}/{id}/restore", s.handleRestoreTrash)
+}
+
+// handleGetTrash handels GET '/admin/trash/'
+// returns the deleted blogs, sub blogs and comments, latest deleted first.
+// the "type" query param (blog, subBlog, comment) filters on the type of the objects, the pages are
+// read with the "offset" or the "cursor" query params.
+func (s *Server) handleGetTrash(w http.ResponseWriter, r *http.Request) {
+	filter := pa.TrashFilter{
+		Limit: 20,
+	}
+
+	if v := r.URL.Query().Get("type"); v != "" {
+		filter.Type = &v
+	}
+	offset, cursor, err := s.parseListQuery(r)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	filter.Offset, filter.Cursor = offset, cursor
+
+	items, n, err := s.TrashService.FindTrash(r.Context(), filter)
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	page, err := s.newPageResponse(filter.Cursor, "", filter.Offset, n, len(items), func(i int) pa.Cursor {
+		return pa.Cursor{CreatedAt: items[i].DeletedAt, ID: items[i].ID, Type: items[i].Type}
+	})
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	SendJSON(w, getTrashResponse{
+		N:            n,
+		Items:        items,
+		pageResponse: page,
+	})
+}
+
+// handleRestoreTrash handels POST '/admin/trash/{type}/{id}/restore'
+// restores the deleted object of type with id.
+func (s *Server) handleRestoreTrash(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	if err := s.TrashService.Restore(r.Context(), chi.URLParam(r, "type"), id); err != nil {
+		SendError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// purgeTrashJob represents a scheduled job permanently deleting the objects which outlived the trash retention.
+func (s *Server) purgeTrashJob() {
+	logger := slog.Default().With("job", "trashPurge")
+	logger.Info("running job.")
+	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	retention := s.conf.Trash.Retention
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+
+	n, err := s.TrashService.Purge(adminCtx, time.Now().Add(-retention))
+	if err != nil {
+		logger.Error("Purge failed.", "err", err)
+		return
+	}
+	logger.Info("purged the trash.", "n", n)
+}
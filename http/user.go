@@ -1,10 +1,17 @@
 package http
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/go-chi/chi/v5"
@@ -15,6 +22,8 @@ func (s *Server) registerUserRoutes(r chi.Router) {
 	r.Get("/{userID}", s.handleGetUser)
 	r.Get("/{userID}/profile", s.handleUserProfile)
 	r.Patch("/{userID}/refresh-api-key", s.handleRefreshApiKey)
+	r.Get("/{userID}/export", s.handleExportUser)
+	r.Get("/{userID}/export/download", s.handleDownloadUserExport)
 	r.Delete("/{userID}", s.handleDeleteUser)
 }
 
@@ -159,8 +168,105 @@ func (s *Server) handleRefreshApiKey(w http.ResponseWriter, r *http.Request) {
 	SendJSON(w, user)
 }
 
+// handleExportUser handels GET '/users/{userID}/export'.
+// queues the export of the data of the user pointed to by userID, the user gets emailed a download link
+// once the export is ready.
+func (s *Server) handleExportUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	if pa.UserIDFromContext(r.Context()) != id {
+		SendError(w, r, pa.Errorf(pa.EUNAUTHORIZED, "user not authorized"))
+		return
+	}
+
+	if err := s.publishNewEvent(r.Context(), pa.Event{
+		Topic:   pa.EventTopicUserExport,
+		Payload: pa.UserExportPayload{UserID: id},
+	}); err != nil {
+		SendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleDownloadUserExport handels GET '/users/{userID}/export/download'.
+// sends the last export of the user pointed to by userID as a ZIP attachment, exports expire after
+// DefaultExportTTL.
+func (s *Server) handleDownloadUserExport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid id format"))
+		return
+	}
+
+	if pa.UserIDFromContext(r.Context()) != id {
+		SendError(w, r, pa.Errorf(pa.EUNAUTHORIZED, "user not authorized"))
+		return
+	}
+
+	f, info, err := s.ExportsFileSystem.OpenFile(r.Context(), userExportPath(id))
+	if err != nil {
+		SendError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	if time.Since(info.ModTime) > DefaultExportTTL {
+		SendError(w, r, pa.Errorf(pa.ENOTFOUND, "export expired."))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="export.zip"`)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.WriteHeader(http.StatusOK)
+
+	io.Copy(w, f)
+}
+
+// purgeExportsJob represents a scheduled job deleting the data exports which outlived DefaultExportTTL.
+func (s *Server) purgeExportsJob() {
+	logger := slog.Default().With("job", "exportPurge")
+	logger.Info("running job.")
+	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	n, err := s.purgeExports(adminCtx, time.Now().Add(-DefaultExportTTL))
+	if err != nil {
+		logger.Error("purgeExports failed.", "err", err)
+		return
+	}
+	logger.Info("purged the exports.", "n", n)
+}
+
+// purgeExports deletes the data exports created before t and returns the number of deleted exports.
+func (s *Server) purgeExports(ctx context.Context, t time.Time) (n int, err error) {
+	files, err := s.ExportsFileSystem.List(ctx, "/")
+	if err != nil {
+		return 0, err
+	}
+
+	for _, file := range files {
+		if !file.ModTime.Before(t) {
+			continue
+		}
+
+		if err := s.ExportsFileSystem.DeleteFile(ctx, file.Path); err != nil && pa.ErrorCode(err) != pa.ENOTFOUND {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
 // handleDeleteUser handels DELETE '/users/{userID}'.
-// permanently deletes the user pointed to by userID and clears the session.
+// queues the erasure of the user pointed to by userID and clears the session. the "mode" query param
+// picks between deleting the comments of the user ("delete", the default) or keeping them under the
+// deleted user tombstone ("anonymize").
 func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "userID"))
 	if err != nil {
@@ -168,13 +274,72 @@ func (s *Server) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// delete user from database and clear session.
-	if err := s.UserService.DeleteUser(r.Context(), id); err != nil {
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = pa.ErasureModeDelete
+	case pa.ErasureModeDelete, pa.ErasureModeAnonymize:
+	default:
+		SendError(w, r, pa.Errorf(pa.EINVALID, "invalid erasure mode: %s.", mode))
+		return
+	}
+
+	if pa.UserIDFromContext(r.Context()) != id {
+		SendError(w, r, pa.Errorf(pa.EUNAUTHORIZED, "user not authorized"))
+		return
+	}
+
+	// queue the erasure and clear session.
+	if err := s.publishNewEvent(r.Context(), pa.Event{
+		Topic:   pa.EventTopicUserErase,
+		Payload: pa.UserErasePayload{UserID: id, Mode: mode},
+	}); err != nil {
 		SendError(w, r, err)
 		return
 	} else if err := s.setSession(w, pa.Session{}); err != nil {
 		SendError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// userExportPath returns the path of the export of the user with id in the exports file system.
+func userExportPath(id int) string {
+	return "/" + strconv.Itoa(id) + ".zip"
+}
+
+// newUserExport returns a ZIP archive holding the JSON encoded profile, auths, comments and subscriptions
+// of user. The OAuth tokens of the auths are never encoded.
+func newUserExport(user *pa.User, comments []*pa.Comment, subs []*pa.Subscription) ([]byte, error) {
+	profile := *user
+	profile.Auths = nil
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, file := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"profile.json", profile},
+		{"auths.json", user.Auths},
+		{"comments.json", comments},
+		{"subscriptions.json", subs},
+	} {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(file.v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
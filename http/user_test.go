@@ -1 +1,177 @@
 package http_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	pahttp "github.com/Lambels/patrickarvatu.com/http"
+	"github.com/Lambels/patrickarvatu.com/mock"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+	tmock "github.com/stretchr/testify/mock"
+)
+
+func TestHandleUserExportEvent(t *testing.T) {
+	t.Run("Ok Export Call (Held Comments)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		adminUsrCtx := MustCreateUser(t, db, &pa.User{Name: "Lambels", Email: adminEmail})
+		usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe"}) // no email, no link sent.
+		user := pa.UserFromContext(usrCtx)
+		subBlog := MustCreateSubBlog(t, db, adminUsrCtx)
+
+		commentService := sqlite.NewCommentService(db)
+		approved := &pa.Comment{SubBlogID: subBlog.ID, Content: "approved comment"}
+		held := &pa.Comment{SubBlogID: subBlog.ID, Content: "held comment"}
+		for _, comment := range []*pa.Comment{approved, held} {
+			if err := commentService.CreateComment(usrCtx, comment); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		status := pa.CommentStatusHeld
+		if _, err := commentService.UpdateComment(adminUsrCtx, held.ID, pa.CommentUpdate{Status: &status}); err != nil {
+			t.Fatal(err)
+		}
+
+		event := MustNewEvent(t, pa.EventTopicUserExport, pa.UserExportPayload{UserID: user.ID})
+		if err := s.HandleUserExportEvent(context.Background(), s.SubscriptionService, event); err != nil {
+			t.Fatal(err)
+		}
+
+		var comments []*pa.Comment
+		MustReadExportFile(t, s, "comments.json", &comments)
+		if len(comments) != 2 || comments[0].ID != approved.ID || comments[1].ID != held.ID {
+			t.Fatalf("comments=%+v", comments)
+		} else if comments[1].Status != pa.CommentStatusHeld {
+			t.Fatalf("status=%v", comments[1].Status)
+		}
+	})
+}
+
+func TestPurgeExports(t *testing.T) {
+	s, _ := MustOpenServer(t, nil)
+	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	MustCreateFile(t, s.ExportsFileSystem, "/1.zip", []byte("export"))
+	MustCreateFile(t, s.ExportsFileSystem, "/2.zip", []byte("export"))
+
+	// the exports created since are kept.
+	if n, err := s.PurgeExports(adminCtx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatalf("n=%v", n)
+	}
+
+	if n, err := s.PurgeExports(adminCtx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("n=%v", n)
+	} else if files, err := s.ExportsFileSystem.List(adminCtx, "/"); err != nil {
+		t.Fatal(err)
+	} else if len(files) != 0 {
+		t.Fatalf("files=%v", files)
+	}
+}
+
+func TestHandleUserEraseEvent(t *testing.T) {
+	t.Run("Ok Erase Call (Email Failure)", func(t *testing.T) {
+		s, db := MustOpenServer(t, nil)
+
+		emailService := &mock.EmailService{}
+		emailService.On("SendEmail", tmock.Anything, []string{"jhon@doe.com"}, tmock.Anything, tmock.Anything).Return(errors.New("smtp down"))
+		s.EmailService = emailService
+
+		usrCtx := MustCreateUser(t, db, &pa.User{Name: "Jhon Doe", Email: "jhon@doe.com"})
+		user := pa.UserFromContext(usrCtx)
+
+		// the user is erased, the failed confirmation doesent get the event retried.
+		event := MustNewEvent(t, pa.EventTopicUserErase, pa.UserErasePayload{UserID: user.ID, Mode: pa.ErasureModeDelete})
+		if err := s.HandleUserEraseEvent(context.Background(), s.SubscriptionService, event); err != nil {
+			t.Fatal(err)
+		}
+		emailService.AssertNumberOfCalls(t, "SendEmail", 1)
+
+		if _, err := s.UserService.FindUserByID(context.Background(), user.ID); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatalf("err=%v", err)
+		}
+
+		// a redelivered event finds nothing left to erase.
+		if err := s.HandleUserEraseEvent(context.Background(), s.SubscriptionService, event); err != nil {
+			t.Fatal(err)
+		}
+		emailService.AssertNumberOfCalls(t, "SendEmail", 1)
+	})
+}
+
+// MustCreateSubBlog creates a blog with a sub blog and returns the sub blog.
+func MustCreateSubBlog(tb testing.TB, db *sqlite.DB, adminUsrCtx context.Context) *pa.SubBlog {
+	tb.Helper()
+
+	blog := &pa.Blog{Title: "Epic Blog", Description: "Honestly the best blog ever."}
+	if err := sqlite.NewBlogService(db).CreateBlog(adminUsrCtx, blog); err != nil {
+		tb.Fatal(err)
+	}
+
+	subBlog := &pa.SubBlog{BlogID: blog.ID, Title: "First Chapter", Content: "Once upon a time."}
+	if err := sqlite.NewSubBlogService(db).CreateSubBlog(adminUsrCtx, subBlog); err != nil {
+		tb.Fatal(err)
+	}
+	return subBlog
+}
+
+// MustNewEvent returns an event carrying the JSON encoded payload, as delivered by the event service.
+func MustNewEvent(tb testing.TB, topic string, payload interface{}) pa.Event {
+	tb.Helper()
+
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return pa.Event{Topic: topic, Payload: buf}
+}
+
+// MustReadExportFile decodes the file with name in the only data export stored by s into v.
+func MustReadExportFile(tb testing.TB, s *pahttp.Server, name string, v interface{}) {
+	tb.Helper()
+
+	adminCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+	files, err := s.ExportsFileSystem.List(adminCtx, "/")
+	if err != nil {
+		tb.Fatal(err)
+	} else if len(files) != 1 {
+		tb.Fatalf("files=%v", files)
+	}
+
+	rc, _, err := s.ExportsFileSystem.OpenFile(adminCtx, files[0].Path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer rc.Close()
+
+	buf, err := io.ReadAll(rc)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	f, err := zr.Open(name)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		tb.Fatal(err)
+	}
+}
@@ -0,0 +1,334 @@
+// Package imaging processes uploaded images before they get stored in the media library.
+//
+// Every image gets decoded, which validates the real format independently of any client supplied
+// header, and re-encoded which drops all metadata (EXIF, XMP, ICC profiles, GIF comments). JPEG orientation
+// is applied to the pixels before the metadata is dropped so photos keep their intended rotation.
+//
+// The dimensions are checked against the Limits before decoding, a small file declaring huge dimensions
+// would otherwise allocate gigabytes.
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/chai2010/webp"
+	"golang.org/x/image/draw"
+)
+
+// quality settings of the lossy encoders.
+const (
+	JPEGQuality = 85
+	WebPQuality = 80
+)
+
+// Limits bounds the dimensions of the processed images, zero values fall back to DefaultLimits.
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+
+	// MaxPixels bounds width * height, summed over the frames of animated gifs.
+	MaxPixels int
+}
+
+// DefaultLimits allow 48 megapixel photos.
+var DefaultLimits = Limits{
+	MaxWidth:  16384,
+	MaxHeight: 16384,
+	MaxPixels: 50_000_000,
+}
+
+// check returns EINVALID if a width x height image of frames frames exceeds the limits.
+func (l Limits) check(width, height, frames int) error {
+	if l.MaxWidth == 0 {
+		l.MaxWidth = DefaultLimits.MaxWidth
+	}
+	if l.MaxHeight == 0 {
+		l.MaxHeight = DefaultLimits.MaxHeight
+	}
+	if l.MaxPixels == 0 {
+		l.MaxPixels = DefaultLimits.MaxPixels
+	}
+
+	if width > l.MaxWidth || height > l.MaxHeight {
+		return pa.Errorf(pa.EINVALID, "image exceeds %dx%d pixels.", l.MaxWidth, l.MaxHeight)
+	}
+	// the width and height are bounded and there cant be more frames then bytes, the product cant overflow.
+	if width*height*frames > l.MaxPixels {
+		return pa.Errorf(pa.EINVALID, "image exceeds %d pixels.", l.MaxPixels)
+	}
+	return nil
+}
+
+// Size represents a variant size, variants are scaled down to Width keeping the aspect ratio.
+type Size struct {
+	Label string
+	Width int
+}
+
+// Sizes are the variant sizes generated for each image. Sizes wider then the image are skipped,
+// images are never scaled up.
+var Sizes = []Size{
+	{Label: "thumbnail", Width: 320},
+	{Label: "medium", Width: 768},
+	{Label: "large", Width: 1600},
+}
+
+// formats maps the supported mime types to the extension used when storing them.
+var formats = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Image represents a processed image.
+type Image struct {
+	MimeType string
+	Ext      string
+	Width    int
+	Height   int
+
+	// the original image without metadata.
+	Content []byte
+
+	// resized variants of the image, each size gets encoded in the original format and WebP.
+	Variants []*Variant
+}
+
+// Variant represents a resized rendition of an image.
+type Variant struct {
+	Label    string
+	MimeType string
+	Ext      string
+	Width    int
+	Height   int
+	Content  []byte
+}
+
+// Process validates, strips and generates the variants of content.
+// returns EINVALID if content isnt a supported image or exceeds limits.
+func Process(content []byte, limits Limits) (*Image, error) {
+	// sniff the format, the header of the request cant be trusted.
+	mimeType := http.DetectContentType(content)
+	ext, ok := formats[mimeType]
+	if !ok {
+		return nil, pa.Errorf(pa.EINVALID, "unsupported image format: %s.", mimeType)
+	}
+
+	// only the header is read, check the dimensions before allocating the pixels.
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, pa.Errorf(pa.EINVALID, "invalid image: %v.", err)
+	}
+	frames := 1
+	if mimeType == "image/gif" {
+		if frames, err = gifFrames(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := limits.check(cfg.Width, cfg.Height, frames); err != nil {
+		return nil, err
+	}
+
+	img := &Image{
+		MimeType: mimeType,
+		Ext:      ext,
+	}
+
+	// decoding the whole image makes sure the content matches the sniffed format.
+	var src image.Image
+	if mimeType == "image/gif" {
+		g, err := gif.DecodeAll(bytes.NewReader(content))
+		if err != nil {
+			return nil, pa.Errorf(pa.EINVALID, "invalid image: %v.", err)
+		}
+		src = g.Image[0]
+
+		// re-encoding keeps the frames and drops the comment and application extensions (XMP).
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, g); err != nil {
+			return nil, err
+		}
+		img.Content = buf.Bytes()
+	} else if src, _, err = image.Decode(bytes.NewReader(content)); err != nil {
+		return nil, pa.Errorf(pa.EINVALID, "invalid image: %v.", err)
+	}
+
+	switch mimeType {
+	case "image/gif":
+		// re-encoded above.
+
+	case "image/jpeg":
+		src = orient(src, jpegOrientation(content))
+		fallthrough
+
+	default:
+		if img.Content, err = encode(src, mimeType); err != nil {
+			return nil, err
+		}
+	}
+
+	bounds := src.Bounds()
+	img.Width, img.Height = bounds.Dx(), bounds.Dy()
+
+	// variants of gifs are stills of the first frame, encode them as png.
+	variantType, variantExt := mimeType, ext
+	if mimeType == "image/gif" {
+		variantType, variantExt = "image/png", ".png"
+	}
+
+	for _, size := range Sizes {
+		if size.Width > img.Width {
+			continue
+		}
+
+		resized := resize(src, size.Width)
+		bounds := resized.Bounds()
+
+		types := []string{variantType}
+		if variantType != "image/webp" {
+			types = append(types, "image/webp")
+		}
+
+		for _, typ := range types {
+			buf, err := encode(resized, typ)
+			if err != nil {
+				return nil, err
+			}
+
+			v := &Variant{
+				Label:    size.Label,
+				MimeType: typ,
+				Ext:      variantExt,
+				Width:    bounds.Dx(),
+				Height:   bounds.Dy(),
+				Content:  buf,
+			}
+			if typ == "image/webp" {
+				v.Ext = ".webp"
+			}
+			img.Variants = append(img.Variants, v)
+		}
+	}
+
+	// images smaller then the smallest size still get a WebP rendition.
+	if len(img.Variants) == 0 && mimeType != "image/webp" {
+		buf, err := encode(src, "image/webp")
+		if err != nil {
+			return nil, err
+		}
+
+		img.Variants = append(img.Variants, &Variant{
+			Label:    Sizes[0].Label,
+			MimeType: "image/webp",
+			Ext:      ".webp",
+			Width:    img.Width,
+			Height:   img.Height,
+			Content:  buf,
+		})
+	}
+
+	return img, nil
+}
+
+// gifFrames counts the frames of the gif in content without decoding them.
+// returns EINVALID if content isnt a well formed gif.
+func gifFrames(content []byte) (int, error) {
+	invalid := pa.Errorf(pa.EINVALID, "invalid image: malformed gif.")
+
+	// header and logical screen descriptor.
+	if len(content) < 13 {
+		return 0, invalid
+	}
+	i := 13
+	if flags := content[10]; flags&0x80 != 0 { // global color table.
+		i += 3 << (flags&0x07 + 1)
+	}
+
+	// skipSubBlocks moves i past a sequence of data sub blocks.
+	skipSubBlocks := func() bool {
+		for i < len(content) {
+			n := int(content[i])
+			i += n + 1
+			if n == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	var frames int
+	for i < len(content) {
+		switch content[i] {
+		case 0x21: // extension: introducer, label, sub blocks.
+			i += 2
+			if !skipSubBlocks() {
+				return 0, invalid
+			}
+
+		case 0x2C: // image descriptor.
+			if i+10 > len(content) {
+				return 0, invalid
+			}
+			flags := content[i+9]
+			i += 10
+			if flags&0x80 != 0 { // local color table.
+				i += 3 << (flags&0x07 + 1)
+			}
+			i++ // lzw minimum code size.
+			if !skipSubBlocks() {
+				return 0, invalid
+			}
+			frames++
+
+		case 0x3B: // trailer.
+			return frames, nil
+
+		default:
+			return 0, invalid
+		}
+	}
+
+	// decoders accept a missing trailer.
+	return frames, nil
+}
+
+// encode encodes m in the format of mimeType.
+func encode(m image.Image, mimeType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch mimeType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, m, &jpeg.Options{Quality: JPEGQuality})
+	case "image/png":
+		err = png.Encode(&buf, m)
+	case "image/gif":
+		err = gif.Encode(&buf, m, nil)
+	case "image/webp":
+		err = webp.Encode(&buf, m, &webp.Options{Quality: WebPQuality})
+	default:
+		return nil, pa.Errorf(pa.EINVALID, "unsupported image format: %s.", mimeType)
+	}
+
+	return buf.Bytes(), err
+}
+
+// resize scales m down to width keeping the aspect ratio.
+func resize(m image.Image, width int) image.Image {
+	bounds := m.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height == 0 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), m, bounds, draw.Over, nil)
+	return dst
+}
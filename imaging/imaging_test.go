@@ -0,0 +1,246 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/imaging"
+)
+
+func TestProcess(t *testing.T) {
+	t.Run("Ok Process Call", func(t *testing.T) {
+		img, err := imaging.Process(MustEncodePNG(t, 1000, 500), imaging.DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if img.MimeType != "image/png" || img.Ext != ".png" {
+			t.Fatalf("format=%v %v", img.MimeType, img.Ext)
+		} else if img.Width != 1000 || img.Height != 500 {
+			t.Fatalf("size=%vx%v", img.Width, img.Height)
+		}
+
+		// thumbnail and medium in png and webp, large is wider then the image.
+		want := []struct {
+			label    string
+			mimeType string
+			width    int
+		}{
+			{"thumbnail", "image/png", 320},
+			{"thumbnail", "image/webp", 320},
+			{"medium", "image/png", 768},
+			{"medium", "image/webp", 768},
+		}
+		if len(img.Variants) != len(want) {
+			t.Fatalf("got %v variants", len(img.Variants))
+		}
+		for i, v := range img.Variants {
+			if v.Label != want[i].label || v.MimeType != want[i].mimeType || v.Width != want[i].width {
+				t.Fatalf("variant %v: %v %v %v", i, v.Label, v.MimeType, v.Width)
+			} else if v.Height != want[i].width/2 {
+				t.Fatalf("variant %v: height=%v", i, v.Height)
+			}
+
+			// variants must decode to their advertised size.
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(v.Content))
+			if err != nil {
+				t.Fatal(err)
+			} else if cfg.Width != v.Width || cfg.Height != v.Height {
+				t.Fatalf("variant %v: decoded %vx%v", i, cfg.Width, cfg.Height)
+			}
+		}
+	})
+
+	t.Run("Ok Process Call (Small Image)", func(t *testing.T) {
+		img, err := imaging.Process(MustEncodePNG(t, 100, 100), imaging.DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// only a webp rendition at the original size.
+		if len(img.Variants) != 1 || img.Variants[0].MimeType != "image/webp" || img.Variants[0].Width != 100 {
+			t.Fatal("expected a single webp variant")
+		}
+	})
+
+	t.Run("Ok Process Call (EXIF)", func(t *testing.T) {
+		// orientation 6: the pixels are stored rotated 90 degrees counter clockwise.
+		content := MustAddOrientation(t, MustEncodeJPEG(t, 400, 200), 6)
+
+		img, err := imaging.Process(content, imaging.DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if img.Width != 200 || img.Height != 400 {
+			t.Fatalf("orientation not applied: %vx%v", img.Width, img.Height)
+		}
+
+		// the exif segment must be gone.
+		if bytes.Contains(img.Content, []byte("Exif\x00\x00")) {
+			t.Fatal("exif not stripped")
+		}
+	})
+
+	t.Run("Ok Process Call (GIF)", func(t *testing.T) {
+		content := MustAddGIFComment(t, MustEncodeGIF(t, 400, 200, 2), "secret")
+
+		img, err := imaging.Process(content, imaging.DefaultLimits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the comment must be gone, the animation kept.
+		if bytes.Contains(img.Content, []byte("secret")) {
+			t.Fatal("gif comment not stripped")
+		}
+		g, err := gif.DecodeAll(bytes.NewReader(img.Content))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(g.Image) != 2 {
+			t.Fatalf("got %v frames", len(g.Image))
+		}
+	})
+
+	t.Run("Bad Process Call (Decompression Bomb)", func(t *testing.T) {
+		// a 10x10 png declaring 100000x100000 pixels.
+		content := MustSetPNGSize(t, MustEncodePNG(t, 10, 10), 100000, 100000)
+		if _, err := imaging.Process(content, imaging.DefaultLimits); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// within the width and height but over the pixels.
+		limits := imaging.Limits{MaxPixels: 1000}
+		if _, err := imaging.Process(MustEncodePNG(t, 100, 100), limits); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// the frames of gifs add up.
+		if _, err := imaging.Process(MustEncodeGIF(t, 20, 20, 3), limits); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+		if _, err := imaging.Process(MustEncodeGIF(t, 20, 20, 2), limits); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Bad Process Call (Invalid)", func(t *testing.T) {
+		// not an image.
+		if _, err := imaging.Process([]byte("<html></html>"), imaging.DefaultLimits); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// png signature with broken content.
+		content := MustEncodePNG(t, 10, 10)
+		if _, err := imaging.Process(content[:len(content)/2], imaging.DefaultLimits); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+}
+
+func MustEncodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, NewGradient(width, height)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// MustEncodeGIF encodes an animated gif of frames frames.
+func MustEncodeGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), NewGradient(width, height), image.Point{}, draw.Src)
+		g.Image, g.Delay = append(g.Image, frame), append(g.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// MustAddGIFComment inserts a comment extension holding comment before the trailer of content.
+func MustAddGIFComment(t *testing.T, content []byte, comment string) []byte {
+	t.Helper()
+	if content[len(content)-1] != 0x3B {
+		t.Fatal("missing gif trailer")
+	}
+
+	out := append([]byte{}, content[:len(content)-1]...)
+	out = append(out, 0x21, 0xFE, byte(len(comment)))
+	out = append(out, comment...)
+	return append(out, 0x00, 0x3B)
+}
+
+// MustSetPNGSize rewrites the dimensions declared in the IHDR chunk of content.
+func MustSetPNGSize(t *testing.T, content []byte, width, height uint32) []byte {
+	t.Helper()
+	out := append([]byte{}, content...)
+
+	// signature (8), length (4), "IHDR" (4), width (4), height (4) ... crc after the 13 byte data.
+	if string(out[12:16]) != "IHDR" {
+		t.Fatal("missing IHDR chunk")
+	}
+	binary.BigEndian.PutUint32(out[16:], width)
+	binary.BigEndian.PutUint32(out[20:], height)
+	binary.BigEndian.PutUint32(out[29:], crc32.ChecksumIEEE(out[12:29]))
+	return out
+}
+
+func MustEncodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, NewGradient(width, height), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// MustAddOrientation inserts an EXIF segment holding orientation after the SOI marker of content.
+func MustAddOrientation(t *testing.T, content []byte, orientation uint16) []byte {
+	t.Helper()
+
+	// little endian tiff header with a single IFD entry.
+	tiff := []byte{'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00, 0x01, 0x00}
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], 0x0112) // orientation tag.
+	binary.LittleEndian.PutUint16(entry[2:], 3)      // SHORT.
+	binary.LittleEndian.PutUint32(entry[4:], 1)      // count.
+	binary.LittleEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0) // no next IFD.
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, content[:2]...)
+	out = append(out, segment...)
+	return append(out, content[2:]...)
+}
+
+func NewGradient(width, height int) image.Image {
+	m := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return m
+}
@@ -0,0 +1,64 @@
+// Package inmem implements the pa services which keep their state in the memory of the process,
+// the state is lost on restarts and isnt shared between instances.
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *RateLimiter object implements set interface.
+var _ pa.RateLimiter = (*RateLimiter)(nil)
+
+// SweepInterval is the interval at which the full buckets are dropped from memory.
+const SweepInterval = time.Minute
+
+// RateLimiter represents a store of token buckets held in memory.
+type RateLimiter struct {
+	mu sync.Mutex
+
+	// theoretical arrival time of each bucket, see pa.RateLimitPolicy.Take.
+	buckets   map[string]time.Time
+	lastSweep time.Time
+
+	// Now returns the current time, configurable for tests.
+	Now func() time.Time
+}
+
+// NewRateLimiter returns a new empty instance of RateLimiter.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[string]time.Time),
+		Now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of policy under key, missing buckets start full.
+func (l *RateLimiter) Allow(ctx context.Context, key string, policy pa.RateLimitPolicy) (*pa.RateLimit, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.Now()
+	l.sweep(now)
+
+	tat, limit := policy.Take(now, l.buckets[key])
+	l.buckets[key] = tat
+	return limit, nil
+}
+
+// sweep drops the buckets which refilled since they were last used, a missing bucket is a full bucket.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < SweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, tat := range l.buckets {
+		if !tat.After(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
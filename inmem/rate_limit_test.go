@@ -0,0 +1,58 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/inmem"
+)
+
+func TestRateLimiter(t *testing.T) {
+	policy := pa.RateLimitPolicy{Path: "/", Limit: 2, Period: time.Second, Burst: 3}
+
+	t.Run("Ok Allow Call", func(t *testing.T) {
+		now := time.Date(2022, time.January, 8, 0, 0, 0, 0, time.UTC)
+		l := inmem.NewRateLimiter()
+		l.Now = func() time.Time { return now }
+
+		// the bucket starts full.
+		for want := 2; want >= 0; want-- {
+			if limit, err := l.Allow(context.Background(), "key", policy); err != nil {
+				t.Fatal(err)
+			} else if !limit.Allowed || limit.Remaining != want || limit.Limit != 3 {
+				t.Fatalf("limit=%+v", limit)
+			}
+		}
+
+		// other keys have their own bucket.
+		if limit, err := l.Allow(context.Background(), "other", policy); err != nil {
+			t.Fatal(err)
+		} else if !limit.Allowed {
+			t.Fatalf("limit=%+v", limit)
+		}
+
+		// a token refills every 500ms.
+		if limit, err := l.Allow(context.Background(), "key", policy); err != nil {
+			t.Fatal(err)
+		} else if limit.Allowed || limit.RetryAfter != 500*time.Millisecond || limit.Reset != 1500*time.Millisecond {
+			t.Fatalf("limit=%+v", limit)
+		}
+
+		now = now.Add(500 * time.Millisecond)
+		if limit, err := l.Allow(context.Background(), "key", policy); err != nil {
+			t.Fatal(err)
+		} else if !limit.Allowed || limit.Remaining != 0 {
+			t.Fatalf("limit=%+v", limit)
+		}
+
+		// the bucket doesent overflow.
+		now = now.Add(time.Hour)
+		if limit, err := l.Allow(context.Background(), "key", policy); err != nil {
+			t.Fatal(err)
+		} else if !limit.Allowed || limit.Remaining != 2 {
+			t.Fatalf("limit=%+v", limit)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package pa
+
+import (
+	"context"
+	"time"
+)
+
+// media reference types represent the objects which can reference a media.
+const (
+	MediaRefBlog    = "blog"     // the image of a blog, a blog references at most one media.
+	MediaRefSubBlog = "sub_blog" // media used in the content of a sub blog.
+	MediaRefProject = "project"  // the image of a project, a project references at most one media.
+)
+
+// Media represents a file in the media library. Media is content addressed, uploading the same content
+// twice results in the same media.
+type Media struct {
+	// the pk of the media.
+	ID int `json:"id"`
+
+	// the sha256 hex digest of the content.
+	Hash string `json:"hash"`
+
+	// the name of the file in the media file system, derived from the hash, ie: "<hash>.png".
+	Name string `json:"name"`
+
+	// descriptive fields of the content.
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	AltText  string `json:"altText"`
+
+	// the number of blogs, sub blogs and projects referencing the media.
+	RefCount int `json:"refCount"`
+
+	// resized renditions of the media, ordered by width.
+	Variants []*MediaVariant `json:"variants"`
+
+	// timestamp.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MediaVariant represents a resized rendition of a media. Variant names are derived from the hash
+// of the media and the width of the variant, ie: "<hash>-320w.webp", so they can be cached forever and
+// used in srcset attributes.
+type MediaVariant struct {
+	// the size of the variant, ie: "thumbnail", "medium", "large".
+	Label string `json:"label"`
+
+	// the name of the file in the media file system.
+	Name string `json:"name"`
+
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// Path returns the path of the variant in the media file system.
+func (v *MediaVariant) Path() string {
+	return "/" + v.Name
+}
+
+// MediaRef represents a reference from an object to a media, ie: {Type: MediaRefBlog, ID: 1}
+// represents the blog with id 1.
+type MediaRef struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+// Validate performs basic validation on the media.
+// returns EINVALID if any error is found.
+func (m *Media) Validate() error {
+	if m.Hash == "" {
+		return Errorf(EINVALID, "hash is a required field.")
+	}
+	if m.Name == "" {
+		return Errorf(EINVALID, "name is a required field.")
+	}
+	if m.MimeType == "" {
+		return Errorf(EINVALID, "mime type is a required field.")
+	}
+	if m.Size <= 0 {
+		return Errorf(EINVALID, "media must have content.")
+	}
+	for _, v := range m.Variants {
+		if v.Name == "" || v.MimeType == "" {
+			return Errorf(EINVALID, "variants must have a name and mime type.")
+		}
+	}
+	return nil
+}
+
+// Path returns the path of the media in the media file system.
+func (m *Media) Path() string {
+	return "/" + m.Name
+}
+
+// Validate performs basic validation on the media reference.
+// returns EINVALID if any error is found.
+func (r MediaRef) Validate() error {
+	switch r.Type {
+	case MediaRefBlog, MediaRefSubBlog, MediaRefProject:
+	default:
+		return Errorf(EINVALID, "unknown reference type: %q.", r.Type)
+	}
+	if r.ID == 0 {
+		return Errorf(EINVALID, "reference id is a required field.")
+	}
+	return nil
+}
+
+// MediaService represents a service which manages the media library.
+type MediaService interface {
+	// FindMediaByID returns a media based on the id.
+	// returns ENOTFOUND if the media doesent exist.
+	FindMediaByID(ctx context.Context, id int) (*Media, error)
+
+	// FindMedia returns a range of media and the length of the range. If filter
+	// is specified FindMedia will apply the filter to return set response.
+	FindMedia(ctx context.Context, filter MediaFilter) ([]*Media, int, error)
+
+	// CreateMedia creates a media and its variants. The content of the media and its variants should
+	// already be stored under their Path() in the media file system.
+	// returns ECONFLICT if a media with the same hash exists.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
+	CreateMedia(ctx context.Context, media *Media) error
+
+	// UpdateMedia updates a media based on the update field.
+	// returns ENOTFOUND if the media doesent exist.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
+	UpdateMedia(ctx context.Context, id int, update MediaUpdate) (*Media, error)
+
+	// DeleteMedia permanently deletes a media.
+	// returns ENOTFOUND if the media doesent exist.
+	// returns ECONFLICT if the media is still referenced.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
+	DeleteMedia(ctx context.Context, id int) error
+
+	// CreateMediaRef references the media with id: id from ref. Blogs and projects reference
+	// at most one media, their previous reference gets replaced.
+	// returns ENOTFOUND if the media or the referencing object doesent exist.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
+	CreateMediaRef(ctx context.Context, id int, ref MediaRef) error
+
+	// DeleteMediaRef removes the reference from ref to the media with id: id.
+	// returns ENOTFOUND if the reference doesent exist.
+	// returns EUNAUTHORIZED if used by anyone other then the adim user.
+	DeleteMediaRef(ctx context.Context, id int, ref MediaRef) error
+}
+
+// MediaFilter represents a filter used by FindMedia to filter the response.
+type MediaFilter struct {
+	// fields to filter on.
+	ID   *int    `json:"id"`
+	Hash *string `json:"hash"`
+
+	// filter media referenced by the object.
+	BlogID    *int `json:"blogID"`
+	SubBlogID *int `json:"subBlogID"`
+	ProjectID *int `json:"projectID"`
+
+	// restrictions on the result set, used for pagination and set limits.
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+}
+
+// MediaUpdate represents an update used by UpdateMedia to update a media.
+type MediaUpdate struct {
+	// fields which can be updated.
+	AltText *string `json:"altText"`
+}
@@ -0,0 +1,49 @@
+package pa
+
+import (
+	"context"
+	"time"
+)
+
+// Migration represents a schema migration of a database. Migrations are embedded in the binary as
+// up / down sql file pairs, ie: "00000004.sql" and "00000004.down.sql", and applied in name order.
+type Migration struct {
+	// the name of the up file, ie: "00000004.sql".
+	Name string `json:"name"`
+
+	// the sha256 hex digest of the up file, recorded when the migration is applied.
+	Checksum string `json:"checksum"`
+
+	// Reversible reports whether the migration has a down file.
+	Reversible bool `json:"reversible"`
+
+	// Applied reports whether the migration is applied to the database.
+	Applied bool `json:"applied"`
+
+	// the time the migration was applied, zero for migrations applied before timestamps were recorded.
+	AppliedAt time.Time `json:"appliedAt"`
+
+	// Modified reports whether the up file changed since the migration was applied.
+	Modified bool `json:"modified"`
+
+	// Unknown reports whether the migration is applied to the database but isnt embedded in the binary,
+	// meaning the schema is newer than the binary.
+	Unknown bool `json:"unknown"`
+}
+
+// MigrationService represents a service which manages the schema migrations of a database.
+type MigrationService interface {
+	// Migrations returns the embedded migrations followed by the unknown applied migrations, in name order.
+	Migrations(ctx context.Context) ([]*Migration, error)
+
+	// MigrateUp applies the next n pending migrations, n <= 0 applies all of them.
+	// dryRun returns the migrations which would be applied without applying them.
+	// returns ECONFLICT if the schema is newer than the binary or an applied migration was modified.
+	MigrateUp(ctx context.Context, n int, dryRun bool) ([]*Migration, error)
+
+	// MigrateDown reverts the last n applied migrations, latest first.
+	// dryRun returns the migrations which would be reverted without reverting them.
+	// returns ECONFLICT if the schema is newer than the binary or an applied migration was modified.
+	// returns EINVALID if one of the migrations isnt reversible.
+	MigrateDown(ctx context.Context, n int, dryRun bool) ([]*Migration, error)
+}
@@ -32,3 +32,8 @@ func (_m *EventService) Push(ctx context.Context, event pa.Event) error {
 func (_m *EventService) RegisterHandler(topic string, handler pa.EventHandler) {
 	_m.Called(topic, handler)
 }
+
+// RegisterSubscriptionsHandler provides a mock function with given fields: hand
+func (_m *EventService) RegisterSubscriptionsHandler(hand pa.SubscriptionService) {
+	_m.Called(hand)
+}
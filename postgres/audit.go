@@ -0,0 +1,285 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *AuditService object implements set interface.
+var _ pa.AuditService = (*AuditService)(nil)
+
+// AuditService represents a service used to read the audit log, the entries are recorded by the
+// other services inside the transaction of the audited change.
+type AuditService struct {
+	db *DB
+}
+
+// NewAuditService returns a new instance of AuditService attached to db.
+func NewAuditService(db *DB) *AuditService {
+	return &AuditService{
+		db: db,
+	}
+}
+
+// FindAuditEntries returns a range of audit entries based on filter, latest first by default.
+// returns EINVALID if the query spec of the filter is invalid.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *AuditService) FindAuditEntries(ctx context.Context, filter pa.AuditFilter) ([]*pa.AuditEntry, int, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	return findAuditEntries(ctx, tx, filter)
+}
+
+// auditSortFields maps the sortable fields of pa.AuditFilter to their column.
+var auditSortFields = map[string]string{
+	"id":        "id",
+	"createdAt": "created_at",
+}
+
+func findAuditEntries(ctx context.Context, tx *Tx, filter pa.AuditFilter) (_ []*pa.AuditEntry, n int, err error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, 0, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	// build where and args statement method.
+	// not vulnerable to sql injection attack.
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	if v := filter.ActorID; v != nil {
+		where = append(where, "actor_id = ?")
+		args = append(args, *v)
+	}
+	if v := filter.Action; v != nil {
+		where = append(where, "action = ?")
+		args = append(args, *v)
+	}
+	if v := filter.TargetType; v != nil {
+		where = append(where, "target_type = ?")
+		args = append(args, *v)
+	}
+	if v := filter.TargetID; v != nil {
+		where = append(where, "target_id = ?")
+		args = append(args, *v)
+	}
+	if v := filter.BeforeID; v != nil {
+		where = append(where, "id < ?")
+		args = append(args, *v)
+	}
+
+	where, args, order, err := formatQuerySpec(filter.QuerySpec, auditSortFields, "id DESC", where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	offset := filter.Offset
+	if v := filter.Cursor; v != nil {
+		if filter.SortBy != "" {
+			return nil, 0, pa.Errorf(pa.EINVALID, "sorting cant be combined with a cursor.")
+		}
+		where, args, order = formatLatestCursor(v, where, args)
+		offset = 0
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			actor_id,
+			action,
+			target_type,
+			target_id,
+			diff,
+			ip,
+			user_agent,
+			created_at,
+			COUNT(*) OVER()
+		FROM audit_entries
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+FormatLimitOffset(filter.Limit, offset)+`
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, n, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	entries := []*pa.AuditEntry{}
+	for rows.Next() {
+		var entry pa.AuditEntry
+		var diff string
+
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&diff,
+			&entry.IP,
+			&entry.UserAgent,
+			(*NullTime)(&entry.CreatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+		entry.Diff = []byte(diff)
+
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	return entries, n, nil
+}
+
+// recordAudit records the action of the user under ctx on the target inside tx, the entry is only kept
+// if tx commits. before and after are the states of the target around the action, nil for created or
+// deleted targets. Changes which leave the target as it was arent recorded.
+func recordAudit(ctx context.Context, tx *Tx, action, targetType string, targetID int, before, after interface{}) error {
+	diff, err := pa.NewAuditDiff(before, after)
+	if err != nil {
+		return err
+	} else if before != nil && after != nil && string(diff) == "{}" {
+		return nil
+	}
+	client := pa.ClientFromContext(ctx)
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_entries (
+			actor_id,
+			action,
+			target_type,
+			target_id,
+			diff,
+			ip,
+			user_agent,
+			created_at
+		)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		pa.UserIDFromContext(ctx),
+		action,
+		targetType,
+		targetID,
+		string(diff),
+		client.IP,
+		client.UserAgent,
+		(*NullTime)(&tx.now),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// userAuditTargets maps the audited target types holding the data of a user to the table of the targets.
+var userAuditTargets = map[string]string{
+	pa.AuditTargetComment: "comments",
+	pa.AuditTargetBan:     "bans",
+}
+
+// recordErasureAudit records the erasure of the user with id: id by action, it runs before the user and the
+// targets of the user get deleted. The entry only holds the action and the target, the data and origin of the
+// erased user arent kept: the origins of the entries recorded by the user and the diffs of the entries on the
+// user and on its targets get erased as well.
+func recordErasureAudit(ctx context.Context, tx *Tx, action string, id int) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE audit_entries SET ip = '', user_agent = '' WHERE actor_id = ?`, id); err != nil {
+		return err
+	} else if _, err := tx.ExecContext(ctx, `UPDATE audit_entries SET diff = '{}' WHERE target_type = ? AND target_id = ?`, pa.AuditTargetUser, id); err != nil {
+		return err
+	}
+
+	for targetType, table := range userAuditTargets {
+		targetIDs, err := findUserAuditTargets(ctx, tx, targetType, table, id)
+		if err != nil {
+			return err
+		}
+
+		for _, targetID := range targetIDs {
+			if _, err := tx.ExecContext(ctx, `UPDATE audit_entries SET diff = '{}' WHERE target_type = ? AND target_id = ?`, targetType, targetID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return recordAudit(pa.NewContextWithClient(ctx, pa.Client{}), tx, action, pa.AuditTargetUser, id, nil, nil)
+}
+
+// findUserAuditTargets returns the ids of the targets of type targetType of the user with id: userID, the ones
+// still in table and the ones only the audit log remembers.
+func findUserAuditTargets(ctx context.Context, tx *Tx, targetType, table string, userID int) ([]int, error) {
+	ids := make(map[int]struct{})
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM `+table+` WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// the deleted targets left their user id in the diff of their entries.
+	rows, err = tx.QueryContext(ctx, `SELECT target_id, diff FROM audit_entries WHERE target_type = ?`, targetType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var diff string
+		if err := rows.Scan(&id, &diff); err != nil {
+			return nil, err
+		}
+
+		var fields map[string]struct {
+			Before json.RawMessage `json:"before"`
+			After  json.RawMessage `json:"after"`
+		}
+		if err := json.Unmarshal([]byte(diff), &fields); err != nil {
+			return nil, err
+		}
+		if change := fields["userID"]; string(change.Before) == strconv.Itoa(userID) || string(change.After) == strconv.Itoa(userID) {
+			ids[id] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	targetIDs := make([]int, 0, len(ids))
+	for id := range ids {
+		targetIDs = append(targetIDs, id)
+	}
+	return targetIDs, nil
+}
@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *BanService object implements set interface.
+var _ pa.BanService = (*BanService)(nil)
+
+// BanService represents a service used to manage the bans.
+type BanService struct {
+	db *DB
+}
+
+// NewBanService returns a new instance of BanService attached to db.
+func NewBanService(db *DB) *BanService {
+	return &BanService{
+		db: db,
+	}
+}
+
+// FindBans returns a range of bans based on filter, latest first.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *BanService) FindBans(ctx context.Context, filter pa.BanFilter) ([]*pa.Ban, int, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	return findBans(ctx, tx, filter)
+}
+
+// FindActiveBan returns the active ban of the user with the widest scope.
+// returns ENOTFOUND if the user isnt banned.
+func (s *BanService) FindActiveBan(ctx context.Context, userID int) (*pa.Ban, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findActiveBan(ctx, tx, userID)
+}
+
+// CreateBan bans a user, full bans also revoke the sessions and the api key of the user.
+// returns ENOTFOUND if the user doesent exist.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *BanService) CreateBan(ctx context.Context, ban *pa.Ban) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createBan(ctx, tx, ban); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBan lifts the ban with id.
+// returns ENOTFOUND if the ban doesent exist.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *BanService) DeleteBan(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteBan(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func findBans(ctx context.Context, tx *Tx, filter pa.BanFilter) (_ []*pa.Ban, n int, err error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, 0, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	// build where and args statement method.
+	// not vulnerable to sql injection attack.
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	if v := filter.UserID; v != nil {
+		where, args = append(where, "user_id = ?"), append(args, *v)
+	}
+	if v := filter.Active; v != nil {
+		if *v {
+			where = append(where, "(expires_at IS NULL OR expires_at > ?)")
+		} else {
+			where = append(where, "expires_at <= ?")
+		}
+		args = append(args, (*NullTime)(&tx.now))
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	order, offset := "created_at DESC, id DESC", filter.Offset
+	if v := filter.Cursor; v != nil {
+		where, args, order = formatLatestCursor(v, where, args)
+		offset = 0
+	}
+
+	bans, n, err := queryBans(ctx, tx, where, args, order, FormatLimitOffset(filter.Limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(bans)-1; i < j; i, j = i+1, j-1 {
+			bans[i], bans[j] = bans[j], bans[i]
+		}
+	}
+
+	return bans, n, nil
+}
+
+// queryBans returns the bans matching all the where conditions in order.
+func queryBans(ctx context.Context, tx *Tx, where []string, args []interface{}, order, limitOffset string) (_ []*pa.Ban, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			user_id,
+			reason,
+			scope,
+			expires_at,
+			created_at,
+			COUNT(*) OVER()
+		FROM bans
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+limitOffset+`
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	bans := []*pa.Ban{}
+	for rows.Next() {
+		var ban pa.Ban
+		var expiresAt time.Time
+
+		if err := rows.Scan(
+			&ban.ID,
+			&ban.UserID,
+			&ban.Reason,
+			&ban.Scope,
+			(*NullTime)(&expiresAt),
+			(*NullTime)(&ban.CreatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		// permanent bans dont expire.
+		if !expiresAt.IsZero() {
+			ban.ExpiresAt = &expiresAt
+		}
+
+		bans = append(bans, &ban)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return bans, n, nil
+}
+
+func findActiveBan(ctx context.Context, tx *Tx, userID int) (*pa.Ban, error) {
+	// full bans first, then permanent bans, then the latest expiry.
+	bans, _, err := queryBans(ctx, tx,
+		[]string{"user_id = ?", "(expires_at IS NULL OR expires_at > ?)"},
+		[]interface{}{userID, (*NullTime)(&tx.now)},
+		"scope = '"+pa.BanScopeFull+"' DESC, expires_at IS NULL DESC, expires_at DESC",
+		"LIMIT 1",
+	)
+	if err != nil {
+		return nil, err
+	} else if len(bans) == 0 {
+		return nil, pa.Errorf(pa.ENOTFOUND, "ban not found.")
+	}
+
+	return bans[0], nil
+}
+
+func createBan(ctx context.Context, tx *Tx, ban *pa.Ban) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	ban.CreatedAt = tx.now
+
+	if err := ban.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := findUserByID(ctx, tx, ban.UserID); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO bans (
+			user_id,
+			reason,
+			scope,
+			expires_at,
+			created_at
+		)
+		VALUES(?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		ban.UserID,
+		ban.Reason,
+		ban.Scope,
+		(*NullTime)(ban.ExpiresAt),
+		(*NullTime)(&ban.CreatedAt),
+	).Scan(&ban.ID); err != nil {
+		return err
+	}
+
+	// revoke the sessions and the api key of fully banned users.
+	if ban.Scope == pa.BanScopeFull {
+		apiKey, err := generateAPIKey()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET api_key = ?,
+			    sessions_revoked_at = ?
+			WHERE id = ?
+		`,
+			apiKey,
+			(*NullTime)(&tx.now),
+			ban.UserID,
+		); err != nil {
+			return err
+		}
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetBan, ban.ID, nil, ban)
+}
+
+func deleteBan(ctx context.Context, tx *Tx, id int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	bans, _, err := queryBans(ctx, tx, []string{"id = ?"}, []interface{}{id}, "id ASC", "")
+	if err != nil {
+		return err
+	} else if len(bans) == 0 {
+		return pa.Errorf(pa.ENOTFOUND, "ban not found.")
+	}
+	ban := bans[0]
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM bans WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetBan, id, ban, nil)
+}
@@ -0,0 +1,541 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *BlogService object implements set interface.
+var _ pa.BlogService = (*BlogService)(nil)
+
+// BlogService represents a service used to manage blogs.
+type BlogService struct {
+	db *DB
+}
+
+// NewBlogService returns a new instance of BlogService attached to db.
+func NewBlogService(db *DB) *BlogService {
+	return &BlogService{
+		db: db,
+	}
+}
+
+// FindBlogByID returns a blog based on id.
+// returns ENOTFOUND if the blog doesent exist.
+func (s *BlogService) FindBlogByID(ctx context.Context, id int) (*pa.Blog, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blog, err := findBlogByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+
+	} else if err = attachToBlogs(ctx, tx, []*pa.Blog{blog}, true, true); err != nil { // attach the whole blog series.
+		return nil, err
+	}
+
+	return blog, nil
+}
+
+// FindBlogBySlug returns a blog based on the current slug or any slug from the blogs slug history.
+// returns ENOTFOUND if the blog doesent exist.
+func (s *BlogService) FindBlogBySlug(ctx context.Context, slug string) (*pa.Blog, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blog, err := findBlogBySlug(ctx, tx, slug)
+	if err != nil {
+		return nil, err
+
+	} else if err = attachToBlogs(ctx, tx, []*pa.Blog{blog}, true, true); err != nil { // attach the whole blog series.
+		return nil, err
+	}
+
+	return blog, nil
+}
+
+// FindBlogs returns a range of blog based on filter.
+func (s *BlogService) FindBlogs(ctx context.Context, filter pa.BlogFilter) ([]*pa.Blog, int, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	blogs, n, err := findBlogs(ctx, tx, filter)
+	if err != nil {
+		return blogs, n, err
+	}
+
+	// attach only the included relations, each one loaded for all the blogs at once.
+	if err := attachToBlogs(ctx, tx, blogs, filter.IncludeSubBlogs || filter.IncludeComments, filter.IncludeComments); err != nil {
+		return blogs, n, err
+	}
+
+	return blogs, n, nil
+}
+
+// CreateBlog creates a new blog.
+// returns EUNAUTHORIZED if the user trying to create the blog isnt the admin user.
+func (s *BlogService) CreateBlog(ctx context.Context, blog *pa.Blog) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// create blog and dont attach anything as the blog doesent have anything to populate on creation.
+	if err := createBlog(ctx, tx, blog); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateBlog updates blog with id: id.
+// returns EUNAUTHORIZED if the user trying to update the blog isnt the admin user.
+// returns ENOTFOUND if the blog doesent exist.
+func (s *BlogService) UpdateBlog(ctx context.Context, id int, update pa.BlogUpdate) (*pa.Blog, error) {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// update and attach sub blogs and comments.
+	blog, err := updateBlog(ctx, tx, id, update)
+	if err != nil {
+		return nil, err
+	} else if err := attachToBlogs(ctx, tx, []*pa.Blog{blog}, true, true); err != nil {
+		return nil, err
+	}
+
+	return blog, tx.Commit()
+}
+
+// ReorderSubBlogs sets the positions of the sub blogs of the blog with id: id to the order of subBlogIDs.
+// returns EUNAUTHORIZED if the user trying to reorder the blog isnt the admin user.
+// returns ENOTFOUND if the blog doesent exist.
+// returns EINVALID if subBlogIDs doesent hold every sub blog of the blog exactly once.
+func (s *BlogService) ReorderSubBlogs(ctx context.Context, id int, subBlogIDs []int) (*pa.Blog, error) {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := reorderSubBlogs(ctx, tx, id, subBlogIDs); err != nil {
+		return nil, err
+	}
+
+	// fetch and attach the reordered sub blogs and their comments.
+	blog, err := findBlogByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	} else if err := attachToBlogs(ctx, tx, []*pa.Blog{blog}, true, true); err != nil {
+		return nil, err
+	}
+
+	return blog, tx.Commit()
+}
+
+// DeleteBlog permanently deletes the blog specified by id.
+// returns EUNAUTHORIZED if the user trying to delete the blog isnt the admin user.
+// returns ENOTFOUND if the blog doesent exist.
+func (s *BlogService) DeleteBlog(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteBlog(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func findBlogByID(ctx context.Context, tx *Tx, id int) (*pa.Blog, error) {
+	filter := pa.BlogFilter{
+		ID: &id,
+	}
+
+	blogs, _, err := findBlogs(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(blogs) == 0 {
+		return nil, pa.Errorf(pa.ENOTFOUND, "blog not found.")
+	}
+
+	return blogs[0], nil
+}
+
+// findBlogBySlug looks for the blog currently owning slug and falls back on the slug history.
+func findBlogBySlug(ctx context.Context, tx *Tx, slug string) (*pa.Blog, error) {
+	filter := pa.BlogFilter{
+		Slug: &slug,
+	}
+
+	blogs, _, err := findBlogs(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(blogs) != 0 {
+		return blogs[0], nil
+	}
+
+	// slug might belong to a renamed blog.
+	id, err := findIDBySlugHistory(ctx, tx, blogSlugTable, slug)
+	if pa.ErrorCode(err) == pa.ENOTFOUND {
+		return nil, pa.Errorf(pa.ENOTFOUND, "blog not found.")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return findBlogByID(ctx, tx, id)
+}
+
+// blogSortFields maps the sortable fields of pa.BlogFilter to their column.
+var blogSortFields = map[string]string{
+	"id":        "id",
+	"title":     "title",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+func findBlogs(ctx context.Context, tx *Tx, filter pa.BlogFilter) (_ []*pa.Blog, n int, err error) {
+	// build where and args statement method.
+	// not vulnerable to sql injection attack.
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	if v := filter.ID; v != nil {
+		where = append(where, "id = ?")
+		args = append(args, *v)
+	}
+	if v := filter.Title; v != nil {
+		cond, arg := formatContains("title", *v)
+		where, args = append(where, cond), append(args, arg)
+	}
+	if v := filter.TitleContains; v != nil {
+		cond, arg := formatContains("title", *v)
+		where, args = append(where, cond), append(args, arg)
+	}
+	if v := filter.Slug; v != nil {
+		where = append(where, "slug = ?")
+		args = append(args, *v)
+	}
+
+	where, args, order, err := formatQuerySpec(filter.QuerySpec, blogSortFields, "id ASC", where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	offset := filter.Offset
+	if v := filter.Cursor; v != nil {
+		if filter.SortBy != "" {
+			return nil, 0, pa.Errorf(pa.EINVALID, "sorting cant be combined with a cursor.")
+		}
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
+
+	blogs, n, err := queryBlogs(ctx, tx, where, args, order, FormatLimitOffset(filter.Limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(blogs)-1; i < j; i, j = i+1, j-1 {
+			blogs[i], blogs[j] = blogs[j], blogs[i]
+		}
+	}
+
+	return blogs, n, nil
+}
+
+// queryBlogs returns the blogs matching all the where conditions in order.
+func queryBlogs(ctx context.Context, tx *Tx, where []string, args []interface{}, order, limitOffset string) (_ []*pa.Blog, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			slug,
+			title,
+			description,
+			created_at,
+			updated_at,
+			COUNT(*) OVER()
+		FROM blogs
+		WHERE `+liveBlogs+` AND `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+limitOffset+`
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, n, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	blogs := []*pa.Blog{}
+	for rows.Next() {
+		var blog pa.Blog
+
+		if err := rows.Scan(
+			&blog.ID,
+			&blog.Slug,
+			&blog.Title,
+			&blog.Description,
+			(*NullTime)(&blog.CreatedAt),
+			(*NullTime)(&blog.UpdatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		blogs = append(blogs, &blog)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return blogs, n, nil
+}
+
+func createBlog(ctx context.Context, tx *Tx, blog *pa.Blog) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	blog.CreatedAt = tx.now
+	blog.UpdatedAt = blog.CreatedAt
+
+	if err := blog.Validate(); err != nil {
+		return err
+	}
+
+	slug, err := uniqueSlug(ctx, tx, blogSlugTable, blog.Title, 0)
+	if err != nil {
+		return err
+	}
+	blog.Slug = slug
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO blogs (
+			slug,
+			title,
+			description,
+			created_at,
+			updated_at
+		)
+		VALUES(?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		blog.Slug,
+		blog.Title,
+		blog.Description,
+		(*NullTime)(&blog.CreatedAt),
+		(*NullTime)(&blog.UpdatedAt),
+	).Scan(&blog.ID); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetBlog, blog.ID, nil, blog)
+}
+
+func updateBlog(ctx context.Context, tx *Tx, id int, update pa.BlogUpdate) (*pa.Blog, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	blog, err := findBlogByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *blog
+
+	title := blog.Title
+	if v := update.Title; v != nil {
+		blog.Title = *v
+	}
+	if v := update.Description; v != nil {
+		blog.Description = *v
+	}
+
+	if err := blog.Validate(); err != nil {
+		return blog, err
+	}
+
+	// a new title gets a new slug, the old slug is kept in the history to redirect from.
+	if blog.Title != title {
+		slug, err := uniqueSlug(ctx, tx, blogSlugTable, blog.Title, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if slug != blog.Slug {
+			if err := renameSlug(ctx, tx, blogSlugTable, id, blog.Slug, slug); err != nil {
+				return nil, err
+			}
+			blog.Slug = slug
+		}
+	}
+
+	blog.UpdatedAt = tx.now
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE blogs
+		SET slug		= ?,
+			title 		= ?,
+			description = ?,
+			updated_at	= ?
+		WHERE id = ?
+	`,
+		blog.Slug,
+		blog.Title,
+		blog.Description,
+		(*NullTime)(&blog.UpdatedAt),
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, pa.AuditActionUpdate, pa.AuditTargetBlog, id, &before, blog); err != nil {
+		return nil, err
+	}
+
+	return blog, nil
+}
+
+func deleteBlog(ctx context.Context, tx *Tx, id int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	blog, err := findBlogByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// the sub blogs and comments are hidden along the blog.
+	if _, err := tx.ExecContext(ctx, `UPDATE blogs SET deleted_at = ? WHERE id = ?`, (*NullTime)(&tx.now), id); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetBlog, id, blog, nil)
+}
+
+func reorderSubBlogs(ctx context.Context, tx *Tx, id int, subBlogIDs []int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	if _, err := findBlogByID(ctx, tx, id); err != nil {
+		return err
+	}
+
+	filter := pa.SubBlogFilter{
+		BlogID: &id,
+	}
+	subBlogs, _, err := findSubBlogs(ctx, tx, filter)
+	if err != nil {
+		return err
+	}
+
+	// validate that subBlogIDs is a permutation of the sub blogs of the blog.
+	if len(subBlogIDs) != len(subBlogs) {
+		return pa.Errorf(pa.EINVALID, "order must contain all %d sub blogs of the blog.", len(subBlogs))
+	}
+
+	order := make([]int, len(subBlogs))
+	remaining := make(map[int]struct{}, len(subBlogs))
+	for i, subBlog := range subBlogs {
+		order[i] = subBlog.ID
+		remaining[subBlog.ID] = struct{}{}
+	}
+	for _, subBlogID := range subBlogIDs {
+		if _, ok := remaining[subBlogID]; !ok {
+			return pa.Errorf(pa.EINVALID, "sub blog %d isnt part of the blog or is duplicated.", subBlogID)
+		}
+		delete(remaining, subBlogID)
+	}
+
+	for i, subBlogID := range subBlogIDs {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE sub_blogs
+			SET position = ?
+			WHERE id = ?
+		`,
+			i+1,
+			subBlogID,
+		); err != nil {
+			return err
+		}
+	}
+
+	before, after := map[string][]int{"subBlogIDs": order}, map[string][]int{"subBlogIDs": subBlogIDs}
+	return recordAudit(ctx, tx, pa.AuditActionReorder, pa.AuditTargetBlog, id, before, after)
+}
+
+// attachToBlogs attaches the image and the included relations to blogs. Every relation is loaded
+// for all the blogs with a single query keyed by the parent ids.
+func attachToBlogs(ctx context.Context, tx *Tx, blogs []*pa.Blog, subBlogs, comments bool) error {
+	if err := attachImageToBlogs(ctx, tx, blogs); err != nil {
+		return err
+	} else if !subBlogs {
+		return nil
+	}
+
+	if err := attachSubBlogsToBlogs(ctx, tx, blogs); err != nil {
+		return err
+	}
+
+	var all []*pa.SubBlog
+	for _, blog := range blogs {
+		all = append(all, blog.SubBlogs...)
+	}
+	return attachToSubBlogs(ctx, tx, all, comments)
+}
+
+// attachSubBlogsToBlogs attaches the sub blogs of each blog, the navigation of the sub blogs is built
+// from their neighbours.
+func attachSubBlogsToBlogs(ctx context.Context, tx *Tx, blogs []*pa.Blog) error {
+	ids := make([]int, len(blogs))
+	for i, blog := range blogs {
+		ids[i] = blog.ID
+	}
+
+	cond, args := formatIn("blog_id", ids)
+	subBlogs, _, err := querySubBlogs(ctx, tx, []string{cond}, args, "blog_id ASC, position ASC", "")
+	if err != nil {
+		return err
+	}
+
+	// sub blogs are ordered by blog and position so the navigation can be built from the neighbours.
+	byBlog := make(map[int][]*pa.SubBlog)
+	for _, subBlog := range subBlogs {
+		siblings := byBlog[subBlog.BlogID]
+		if i := len(siblings); i > 0 {
+			subBlog.Prev = newSubBlogSummary(siblings[i-1])
+			siblings[i-1].Next = newSubBlogSummary(subBlog)
+		}
+		byBlog[subBlog.BlogID] = append(siblings, subBlog)
+	}
+
+	for _, blog := range blogs {
+		blog.SubBlogs = append(blog.SubBlogs[:0], byBlog[blog.ID]...)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/Lambels/patrickarvatu.com/conformance"
+	"github.com/Lambels/patrickarvatu.com/postgres"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) *conformance.Services {
+		db := MustOpenTempDB(t)
+		t.Cleanup(func() { MustCloseDB(t, db) })
+
+		return &conformance.Services{
+			AuthService:         postgres.NewAuthService(db),
+			UserService:         postgres.NewUserService(db),
+			BlogService:         postgres.NewBlogService(db),
+			SubBlogService:      postgres.NewSubBlogService(db),
+			CommentService:      postgres.NewCommentService(db),
+			SubscriptionService: postgres.NewSubscriptionService(db),
+			ProjectService:      postgres.NewProjectService(db),
+			MediaService:        postgres.NewMediaService(db),
+			TrashService:        postgres.NewTrashService(db),
+			AuditService:        postgres.NewAuditService(db),
+			SpamService:         postgres.NewSpamService(db),
+			BanService:          postgres.NewBanService(db),
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/postgres"
+)
+
+func TestMigrations(t *testing.T) {
+	db := MustOpenTempDB(t)
+	defer MustCloseDB(t, db)
+
+	migrations, err := db.Migrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, migration := range migrations {
+		if !migration.Applied || migration.AppliedAt.IsZero() || migration.Modified || migration.Unknown {
+			t.Fatalf("migration=%+v", migration)
+		} else if !migration.Reversible || len(migration.Checksum) != 64 {
+			t.Fatalf("migration=%+v", migration)
+		}
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	db := MustOpenTempDB(t)
+	defer MustCloseDB(t, db)
+
+	t.Run("Ok Dry Run Call", func(t *testing.T) {
+		if reverted, err := db.MigrateDown(context.Background(), 1, true); err != nil {
+			t.Fatal(err)
+		} else if len(reverted) != 1 || reverted[0].Name != "00000007.sql" {
+			t.Fatalf("reverted=%v", reverted)
+		}
+		MustCountPending(t, db, 0)
+	})
+
+	t.Run("Ok Down All Call", func(t *testing.T) {
+		migrations, err := db.Migrations(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every down file reverts its up file.
+		if _, err := db.MigrateDown(context.Background(), len(migrations), false); err != nil {
+			t.Fatal(err)
+		}
+		MustCountPending(t, db, len(migrations))
+
+		if _, err := db.MigrateUp(context.Background(), 0, false); err != nil {
+			t.Fatal(err)
+		}
+		MustCountPending(t, db, 0)
+	})
+
+	t.Run("Bad Down Call (Invalid N)", func(t *testing.T) {
+		if _, err := db.MigrateDown(context.Background(), 0, false); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+}
+
+func TestMigrateUp(t *testing.T) {
+	t.Run("Bad Up Call (Modified)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		tx := db.MustBeginTX(context.Background(), nil)
+		if _, err := tx.Exec(`UPDATE migrations SET checksum = 'edited'`); err != nil {
+			t.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.MigrateUp(context.Background(), 0, false); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatal("err != ECONFLICT")
+		}
+	})
+
+	t.Run("Bad Open Call (Newer Schema)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		tx := db.MustBeginTX(context.Background(), nil)
+		if _, err := tx.Exec(`INSERT INTO migrations (name, checksum) VALUES ('migration/99999999.sql', 'x')`); err != nil {
+			t.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		MustCloseDB(t, db)
+
+		reopened := postgres.NewDB(db.DSN)
+		if err := reopened.Open(); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatalf("err=%v", err)
+		}
+		reopened.Close()
+	})
+}
+
+func MustCountPending(t *testing.T, db *postgres.DB, want int) {
+	t.Helper()
+
+	migrations, err := db.Migrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	for _, migration := range migrations {
+		if !migration.Applied {
+			n++
+		}
+	}
+	if n != want {
+		t.Fatalf("pending=%v want=%v", n, want)
+	}
+}
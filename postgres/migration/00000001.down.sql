@@ -0,0 +1,17 @@
+DROP TABLE media_variants;
+DROP TABLE project_media;
+DROP TABLE sub_blog_media;
+DROP TABLE blog_media;
+DROP TABLE media;
+DROP TABLE projects_topics;
+DROP TABLE topics_description;
+DROP TABLE projects;
+DROP TABLE sub_blog_subscriptions;
+DROP TABLE blog_subscriptions;
+DROP TABLE comments;
+DROP TABLE sub_blog_slugs;
+DROP TABLE blog_slugs;
+DROP TABLE sub_blogs;
+DROP TABLE blogs;
+DROP TABLE auths;
+DROP TABLE users;
@@ -0,0 +1,142 @@
+-- schema equivalent to the sqlite migrations up to 00000007.sql.
+CREATE TABLE users (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL,
+    email       TEXT UNIQUE,
+    api_key     TEXT NOT NULL UNIQUE,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE auths (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    source          TEXT NOT NULL,
+    source_id       TEXT NOT NULL,
+    access_token    TEXT NOT NULL,
+    refresh_token   TEXT NOT NULL,
+    expiry          TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL,
+
+    UNIQUE(user_id, source),  -- one source per user
+    UNIQUE(source, source_id)
+);
+
+CREATE TABLE blogs (
+    id          SERIAL PRIMARY KEY,
+    slug        TEXT NOT NULL UNIQUE,
+    title       TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE sub_blogs (
+    id          SERIAL PRIMARY KEY,
+    blog_id     INTEGER NOT NULL REFERENCES blogs (id) ON DELETE CASCADE,
+    slug        TEXT NOT NULL UNIQUE,
+    title       TEXT NOT NULL,
+    position    INTEGER NOT NULL DEFAULT 0,
+    content     TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX sub_blogs_blog_id_position_idx ON sub_blogs (blog_id, position);
+
+-- slug history, old slugs keep resolving to the renamed row.
+CREATE TABLE blog_slugs (
+    slug        TEXT PRIMARY KEY,
+    blog_id     INTEGER NOT NULL REFERENCES blogs (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE sub_blog_slugs (
+    slug        TEXT PRIMARY KEY,
+    sub_blog_id INTEGER NOT NULL REFERENCES sub_blogs (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE comments (
+    id          SERIAL PRIMARY KEY,
+    sub_blog_id INTEGER NOT NULL REFERENCES sub_blogs (id) ON DELETE CASCADE,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    content     TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE blog_subscriptions (
+    id          SERIAL PRIMARY KEY,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blog_id     INTEGER NOT NULL REFERENCES blogs (id) ON DELETE CASCADE
+);
+
+CREATE TABLE sub_blog_subscriptions (
+    id          SERIAL PRIMARY KEY,
+    sub_blog_id INTEGER NOT NULL REFERENCES sub_blogs (id) ON DELETE CASCADE,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE projects (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL UNIQUE,
+    description TEXT,
+    html_url    TEXT NOT NULL
+);
+
+CREATE TABLE topics_description (
+    id      SERIAL PRIMARY KEY,
+    content TEXT NOT NULL UNIQUE
+);
+
+-- many 2 many relation.
+CREATE TABLE projects_topics (
+    project_id              INTEGER NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    topic_description_id    INTEGER NOT NULL REFERENCES topics_description (id) ON DELETE CASCADE
+);
+
+-- media library, files are content addressed by the sha256 of their content.
+CREATE TABLE media (
+    id          SERIAL PRIMARY KEY,
+    hash        TEXT NOT NULL UNIQUE,
+    name        TEXT NOT NULL,
+    mime_type   TEXT NOT NULL,
+    size        BIGINT NOT NULL,
+    width       INTEGER NOT NULL,
+    height      INTEGER NOT NULL,
+    alt_text    TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+-- references to media, media cant be deleted while referenced.
+-- blogs and projects reference at most one media (their image).
+CREATE TABLE blog_media (
+    blog_id     INTEGER NOT NULL UNIQUE REFERENCES blogs (id) ON DELETE CASCADE,
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE RESTRICT
+);
+
+CREATE TABLE sub_blog_media (
+    sub_blog_id INTEGER NOT NULL REFERENCES sub_blogs (id) ON DELETE CASCADE,
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE RESTRICT,
+
+    UNIQUE(sub_blog_id, media_id)
+);
+
+CREATE TABLE project_media (
+    project_id  INTEGER NOT NULL UNIQUE REFERENCES projects (id) ON DELETE CASCADE,
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE RESTRICT
+);
+
+-- resized renditions of media, removed with their media.
+CREATE TABLE media_variants (
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE CASCADE,
+    label       TEXT NOT NULL,
+    name        TEXT NOT NULL UNIQUE,
+    mime_type   TEXT NOT NULL,
+    size        BIGINT NOT NULL,
+    width       INTEGER NOT NULL,
+    height      INTEGER NOT NULL
+);
+
+CREATE INDEX media_variants_media_id_idx ON media_variants (media_id);
@@ -0,0 +1,8 @@
+-- the trash is purged, deleted rows would come back otherwise.
+DELETE FROM comments WHERE deleted_at IS NOT NULL;
+DELETE FROM sub_blogs WHERE deleted_at IS NOT NULL;
+DELETE FROM blogs WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE comments DROP COLUMN deleted_at;
+ALTER TABLE sub_blogs DROP COLUMN deleted_at;
+ALTER TABLE blogs DROP COLUMN deleted_at;
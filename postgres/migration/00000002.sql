@@ -0,0 +1,5 @@
+-- soft deletes, deleted rows stay in the trash until restored or purged. sub blogs and comments
+-- are hidden along their deleted parents.
+ALTER TABLE blogs ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE sub_blogs ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMPTZ;
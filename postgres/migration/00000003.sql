@@ -0,0 +1,15 @@
+-- audit log of the privileged and destructive actions, entries outlive their actors and targets.
+CREATE TABLE audit_entries (
+    id          SERIAL PRIMARY KEY,
+    actor_id    INTEGER NOT NULL, -- 0 for internal actions.
+    action      TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id   INTEGER NOT NULL,
+    diff        TEXT NOT NULL,
+    ip          TEXT NOT NULL,
+    user_agent  TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX audit_entries_target_idx ON audit_entries (target_type, target_id);
+CREATE INDEX audit_entries_actor_id_idx ON audit_entries (actor_id);
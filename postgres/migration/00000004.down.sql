@@ -0,0 +1,2 @@
+-- the comments of anonymized users go along the tombstone.
+DELETE FROM users WHERE id = -1;
@@ -0,0 +1,4 @@
+-- the deleted user tombstone owns the comments of anonymized users, its id never collides with
+-- the generated ids.
+INSERT INTO users (id, name, api_key, created_at, updated_at)
+VALUES (-1, 'deleted user', md5(random()::text || clock_timestamp()::text), NOW(), NOW());
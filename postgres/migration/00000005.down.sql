@@ -0,0 +1,6 @@
+DROP TABLE spam_rules;
+
+-- held comments were never approved.
+DELETE FROM comments WHERE status = 'held';
+
+ALTER TABLE comments DROP COLUMN status;
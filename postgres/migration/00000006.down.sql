@@ -0,0 +1,4 @@
+DROP INDEX bans_user_id_idx;
+DROP TABLE bans;
+
+ALTER TABLE users DROP COLUMN sessions_revoked_at;
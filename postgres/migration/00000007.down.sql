@@ -0,0 +1,3 @@
+ALTER TABLE sub_blog_subscriptions DROP COLUMN created_at;
+ALTER TABLE blog_subscriptions DROP COLUMN created_at;
+ALTER TABLE projects DROP COLUMN created_at;
@@ -0,0 +1,9 @@
+-- the projects and the subscriptions keep their creation time, the cursors page through them by it.
+-- the rows created before get the time of the migration.
+ALTER TABLE projects ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE blog_subscriptions ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE sub_blog_subscriptions ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+ALTER TABLE projects ALTER COLUMN created_at DROP DEFAULT;
+ALTER TABLE blog_subscriptions ALTER COLUMN created_at DROP DEFAULT;
+ALTER TABLE sub_blog_subscriptions ALTER COLUMN created_at DROP DEFAULT;
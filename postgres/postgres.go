@@ -0,0 +1,436 @@
+// Package postgres implements the services of the pa package on top of PostgreSQL.
+// Queries are written with `?` placeholders, like in the sqlite package, and are rebound to
+// postgres `$N` placeholders by Tx.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer spans the transactions and their queries.
+var tracer = otel.Tracer("github.com/Lambels/patrickarvatu.com/postgres")
+
+var (
+	userCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_users",
+		Help:        "total number of db users",
+		ConstLabels: prometheus.Labels{"driver": "postgres"},
+	})
+
+	blogCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_blogs",
+		Help:        "total number of blogs",
+		ConstLabels: prometheus.Labels{"driver": "postgres"},
+	})
+
+	subBlogCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_sub_blogs",
+		Help:        "total number of sub blogs",
+		ConstLabels: prometheus.Labels{"driver": "postgres"},
+	})
+
+	commentCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_comments",
+		Help:        "total number of comments",
+		ConstLabels: prometheus.Labels{"driver": "postgres"},
+	})
+
+	subscriptionCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name:        "db_subscriptions",
+		Help:        "combined total of total number of blog subscriptions and sub blog subscriptions",
+		ConstLabels: prometheus.Labels{"driver": "postgres"},
+	})
+)
+
+type DB struct {
+	db     *sql.DB
+	ctx    context.Context
+	cancel func()
+
+	DSN string
+
+	// AutoMigrate applies the pending migrations and starts monitoring on Open, defaults to true.
+	// when disabled the schema is left to a MigrationService, ie: the migrations command.
+	AutoMigrate bool
+
+	Now func() time.Time
+}
+
+// Tx wraps sql.Tx rebinding the `?` placeholders of every query.
+type Tx struct {
+	*sql.Tx
+	now  time.Time
+	span trace.Span // spans the transaction, the parent of the query spans.
+}
+
+func NewDB(dsn string) *DB {
+	db := &DB{
+		DSN:         dsn,
+		AutoMigrate: true,
+		Now:         time.Now,
+	}
+
+	db.ctx, db.cancel = context.WithCancel(context.Background())
+	return db
+}
+
+func (db *DB) Open() (err error) {
+	if db.DSN == "" {
+		return fmt.Errorf("dsn required")
+	}
+
+	if db.db, err = sql.Open("postgres", db.DSN); err != nil {
+		return err
+	}
+
+	if err := db.db.PingContext(db.ctx); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+
+	if !db.AutoMigrate {
+		return nil
+	}
+
+	if _, err := db.MigrateUp(db.ctx, 0, false); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	go db.monitor()
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	db.cancel() // cancel ctx
+
+	return db.db.Close()
+}
+
+func (db *DB) BeginTX(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	ctx, span := tracer.Start(ctx, "postgres.Tx",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, attribute.Bool("db.read_only", opts != nil && opts.ReadOnly)),
+	)
+
+	tx, err := db.db.BeginTx(ctx, opts)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+
+	return &Tx{
+		Tx:   tx,
+		now:  db.Now().UTC().Truncate(time.Second),
+		span: span,
+	}, nil
+}
+
+// testing only
+func (db *DB) MustBeginTX(ctx context.Context, opts *sql.TxOptions) *Tx {
+	tx, err := db.BeginTX(ctx, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return tx
+}
+
+// Commit commits the transaction and ends its span.
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	endSpan(tx.span, err)
+	return err
+}
+
+// Rollback aborts the transaction and ends its span, the rollback deferred after a commit only
+// returns sql.ErrTxDone.
+func (tx *Tx) Rollback() error {
+	err := tx.Tx.Rollback()
+	if err != sql.ErrTxDone {
+		endSpan(tx.span, err)
+	}
+	return err
+}
+
+// ExecContext executes query after rebinding its placeholders, in a span under the span of the transaction.
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query = Rebind(query)
+
+	ctx, span := tx.startQuery(ctx, query)
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+// QueryContext runs query after rebinding its placeholders, in a span under the span of the transaction.
+// the span ends before the rows are read.
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query = Rebind(query)
+
+	ctx, span := tx.startQuery(ctx, query)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs query after rebinding its placeholders, in a span under the span of the transaction.
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query = Rebind(query)
+
+	ctx, span := tx.startQuery(ctx, query)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// startQuery starts the span of query, named after its operation, ie: "SELECT".
+func (tx *Tx) startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	// drop the comment lines and the indentation, the migrations start with comments.
+	var lines []string
+	for _, line := range strings.Split(query, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			lines = append(lines, line)
+		}
+	}
+	query = strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+	op, _, _ := strings.Cut(query, " ")
+
+	return tracer.Start(trace.ContextWithSpan(ctx, tx.span), strings.ToUpper(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBStatement(query)),
+	)
+}
+
+// endSpan ends span, marking it as failed by err. sql.ErrNoRows isnt a failure.
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (db *DB) monitor() {
+	ticker := time.NewTicker(time.Second * 10)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.ctx.Done(): // kill gorutine / stop monitoring when context is canceled
+			return
+
+		case <-ticker.C: // each tick represents one monitoring rutine
+		}
+
+		if err := db.update(db.ctx); err != nil {
+			slog.Error("update stats failed.", "err", err)
+		}
+	}
+}
+
+func (db *DB) update(ctx context.Context) error {
+	tx, err := db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users;`).Scan(&n); err != nil {
+		return fmt.Errorf("user count: %w", err)
+	}
+	userCountGauge.Set(float64(n))
+
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM blogs WHERE `+liveBlogs).Scan(&n); err != nil {
+		return fmt.Errorf("blog count: %w", err)
+	}
+	blogCountGauge.Set(float64(n))
+
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sub_blogs WHERE `+liveSubBlogs).Scan(&n); err != nil {
+		return fmt.Errorf("sub_blog count: %w", err)
+	}
+	subBlogCountGauge.Set(float64(n))
+
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE `+liveComments).Scan(&n); err != nil {
+		return fmt.Errorf("comment count: %w", err)
+	}
+	commentCountGauge.Set(float64(n))
+
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM blog_subscriptions;`).Scan(&n); err != nil {
+		return fmt.Errorf("blog subscription count: %w", err)
+	}
+	blogSubscriptions := n
+
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sub_blog_subscriptions;`).Scan(&n); err != nil {
+		return fmt.Errorf("sub blog subscrtiption count: %w", err)
+	}
+	subscriptionCountGauge.Set(float64(blogSubscriptions + n))
+
+	return nil
+}
+
+// NullTime scans and stores TIMESTAMPTZ columns, the zero time is stored as NULL.
+type NullTime time.Time
+
+func (n *NullTime) Scan(value interface{}) error {
+	if value == nil {
+		*(*time.Time)(n) = time.Time{}
+		return nil
+	} else if value, ok := value.(time.Time); ok {
+		*(*time.Time)(n) = value.UTC()
+		return nil
+	}
+	return fmt.Errorf("NullTime: cannot scan to time.Time: %T", value)
+}
+
+func (n *NullTime) Value() (driver.Value, error) {
+	if n == nil || (*time.Time)(n).IsZero() {
+		return nil, nil
+	}
+	return (*time.Time)(n).UTC(), nil
+}
+
+// Rebind replaces the `?` placeholders of query with postgres positional placeholders,
+// ie: "a = ? AND b = ?" becomes "a = $1 AND b = $2". Placeholders inside quoted strings are kept.
+func Rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n, quote := 0, byte(0)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0: // inside a quoted string or identifier.
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+func FormatLimitOffset(limit, offset int) string {
+	if limit > 0 && offset > 0 {
+		return fmt.Sprintf(`LIMIT %d OFFSET %d`, limit, offset)
+	} else if limit > 0 {
+		return fmt.Sprintf(`LIMIT %d`, limit)
+	} else if offset > 0 {
+		return fmt.Sprintf(`OFFSET %d`, offset)
+	}
+	return ""
+}
+
+// formatIn returns the "column IN (?, ...)" condition matching any of the ids and its args,
+// used by the batched loaders to fetch the related objects of many parents in one query.
+func formatIn(column string, ids []int) (string, []interface{}) {
+	seen := make(map[int]struct{}, len(ids))
+	marks, args := make([]string, 0, len(ids)), make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		marks, args = append(marks, "?"), append(args, id)
+	}
+
+	if len(args) == 0 {
+		return "1 = 0", nil // matches nothing.
+	}
+	return column + " IN (" + strings.Join(marks, ", ") + ")", args
+}
+
+// conditions matching the blogs, sub blogs and comments which arent in the trash, sub blogs and
+// comments are hidden along their deleted parents.
+const (
+	liveBlogs    = "deleted_at IS NULL"
+	liveSubBlogs = "deleted_at IS NULL AND blog_id IN (SELECT id FROM blogs WHERE " + liveBlogs + ")"
+	liveComments = "deleted_at IS NULL AND sub_blog_id IN (SELECT id FROM sub_blogs WHERE " + liveSubBlogs + ")"
+)
+
+// formatCursor appends the keyset condition of cursor to where and args and returns the order reading
+// the range from the cursor on. A range read before the cursor comes in reverse.
+func formatCursor(cursor *pa.Cursor, where []string, args []interface{}) ([]string, []interface{}, string) {
+	if cursor.Before {
+		return append(where, "(created_at, id) < (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at DESC, id DESC"
+	}
+	return append(where, "(created_at, id) > (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at ASC, id ASC"
+}
+
+// formatLatestCursor is formatCursor for the ranges listing the latest objects first, the range read
+// from the cursor on holds the older objects.
+func formatLatestCursor(cursor *pa.Cursor, where []string, args []interface{}) ([]string, []interface{}, string) {
+	if cursor.Before {
+		return append(where, "(created_at, id) > (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at ASC, id ASC"
+	}
+	return append(where, "(created_at, id) < (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at DESC, id DESC"
+}
+
+// formatQuerySpec validates spec, appends its creation time range to where and args and returns the order
+// it sorts by, def if it doesnt sort by any field. fields whitelists the sortable fields of the filter mapped
+// to their column, the field names passed by the caller never reach the query.
+// returns EINVALID if spec is invalid or sorts by a field outside of fields.
+func formatQuerySpec(spec pa.QuerySpec, fields map[string]string, def string, where []string, args []interface{}) ([]string, []interface{}, string, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, nil, "", err
+	}
+
+	if v := spec.CreatedAfter; v != nil {
+		where, args = append(where, "created_at > ?"), append(args, (*NullTime)(v))
+	}
+	if v := spec.CreatedBefore; v != nil {
+		where, args = append(where, "created_at < ?"), append(args, (*NullTime)(v))
+	}
+
+	if spec.SortBy == "" {
+		return where, args, def, nil
+	}
+	column, ok := fields[spec.SortBy]
+	if !ok {
+		return nil, nil, "", pa.Errorf(pa.EINVALID, "cant sort by %q.", spec.SortBy)
+	}
+
+	// the id breaks the ties so the pages stay stable.
+	direction := "ASC"
+	if spec.Order == pa.OrderDesc {
+		direction = "DESC"
+	}
+	if column == "id" {
+		return where, args, "id " + direction, nil
+	}
+	return where, args, column + " " + direction + ", id " + direction, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// formatContains returns the case insensitive condition matching the rows where column contains v,
+// the wildcards in v are matched literally.
+func formatContains(column, v string) (string, interface{}) {
+	return column + ` ILIKE ? ESCAPE '\'`, "%" + likeEscaper.Replace(v) + "%"
+}
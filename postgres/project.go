@@ -0,0 +1,549 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *ProjectService object implements set interface.
+var _ pa.ProjectService = (*ProjectService)(nil)
+
+// ProjectService represents a service used to manage projects.
+type ProjectService struct {
+	db *DB
+}
+
+// NewProjectService returns a new instance of ProjectService attached to db.
+func NewProjectService(db *DB) *ProjectService {
+	return &ProjectService{
+		db: db,
+	}
+}
+
+func (s *ProjectService) FindProjectByID(ctx context.Context, id int) (*pa.Project, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	proj, err := findProjectByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+
+	} else if err = attachToProjects(ctx, tx, []*pa.Project{proj}); err != nil { // attach topics and image.
+		return nil, err
+	}
+
+	return proj, nil
+}
+
+func (s *ProjectService) FindProjectByName(ctx context.Context, name string) (*pa.Project, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	proj, err := findProjectByName(ctx, tx, name)
+	if err != nil {
+		return nil, err
+
+	} else if err = attachToProjects(ctx, tx, []*pa.Project{proj}); err != nil { // attach topics and image.
+		return nil, err
+	}
+
+	return proj, nil
+}
+
+func (s *ProjectService) FindProjects(ctx context.Context, filter pa.ProjectFilter) ([]*pa.Project, int, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	projects, n, err := findProjects(ctx, tx, filter)
+	if err != nil {
+		return projects, n, err
+	}
+
+	// attach topics and image of all the projects at once.
+	if err := attachToProjects(ctx, tx, projects); err != nil {
+		return projects, n, err
+	}
+
+	return projects, n, err
+}
+
+func (s *ProjectService) CreateOrUpdateProject(ctx context.Context, project *pa.Project) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	proj, err := findProjectByName(ctx, tx, project.Name)
+
+	switch pa.ErrorCode(err) {
+	case pa.ENOTFOUND: // (project doesent exist)
+		if err := createProject(ctx, tx, project); err != nil {
+			return err
+		}
+
+	case "": // nil error ErrorCode is an empty string. (project exists)
+		if err := updateProject(ctx, tx, proj.ID, project); err != nil {
+			return err
+		}
+
+	default: // error code pa.EINTERNAL, could be replaced by `case pa.EINTERNAL:` yet this code is more idiomatic.
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *ProjectService) DeleteProject(ctx context.Context, name string) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteProject(ctx, tx, name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// projects interface functions ---------------------------------------------------------
+
+func findProjectByID(ctx context.Context, tx *Tx, id int) (*pa.Project, error) {
+	filter := pa.ProjectFilter{
+		ID: &id,
+	}
+
+	projects, _, err := findProjects(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(projects) == 0 {
+		return nil, pa.Errorf(pa.ENOTFOUND, "project not found.")
+	}
+
+	return projects[0], nil
+}
+
+// findProjectByName is a helper function to interface with findProjects and returns ENOTFOUND
+// if project doesent exist.
+func findProjectByName(ctx context.Context, tx *Tx, name string) (*pa.Project, error) {
+	filter := pa.ProjectFilter{
+		Name: &name,
+	}
+
+	projects, _, err := findProjects(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(projects) == 0 {
+		return nil, pa.Errorf(pa.ENOTFOUND, "project not found.")
+	}
+
+	return projects[0], nil
+}
+
+// projectSortFields maps the sortable fields of pa.ProjectFilter to their column.
+var projectSortFields = map[string]string{
+	"id":        "id",
+	"name":      "name",
+	"createdAt": "created_at",
+}
+
+func findProjects(ctx context.Context, tx *Tx, filter pa.ProjectFilter) (_ []*pa.Project, n int, err error) {
+	// build where and args statement method.
+	// not vulnerable to sql injection attack.
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	if v := filter.ID; v != nil {
+		where = append(where, "id = ?")
+		args = append(args, *v)
+	}
+	if v := filter.Name; v != nil {
+		where = append(where, "name = ?")
+		args = append(args, *v)
+	}
+	if v := filter.NameContains; v != nil {
+		cond, arg := formatContains("name", *v)
+		where, args = append(where, cond), append(args, arg)
+	}
+
+	where, args, order, err := formatQuerySpec(filter.QuerySpec, projectSortFields, "id ASC", where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	offset := filter.Offset
+	if v := filter.Cursor; v != nil {
+		if filter.SortBy != "" {
+			return nil, 0, pa.Errorf(pa.EINVALID, "sorting cant be combined with a cursor.")
+		}
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			name,
+			description,
+			html_url,
+			created_at,
+			COUNT(*) OVER()
+		FROM projects
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+FormatLimitOffset(filter.Limit, offset)+`
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, n, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	projects := []*pa.Project{}
+	for rows.Next() {
+		var proj pa.Project
+
+		if err := rows.Scan(
+			&proj.ID,
+			&proj.Name,
+			&proj.Description,
+			&proj.HtmlURL,
+			(*NullTime)(&proj.CreatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		projects = append(projects, &proj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(projects)-1; i < j; i, j = i+1, j-1 {
+			projects[i], projects[j] = projects[j], projects[i]
+		}
+	}
+
+	return projects, n, nil
+}
+
+func createProject(ctx context.Context, tx *Tx, project *pa.Project) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
+	project.CreatedAt = tx.now
+
+	// create project.
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO projects (
+			name,
+			description,
+			html_url,
+			created_at
+		)
+		VALUES(?, ?, ?, ?)
+		RETURNING id
+	`,
+		project.Name,
+		project.Description,
+		project.HtmlURL,
+		(*NullTime)(&project.CreatedAt),
+	).Scan(&project.ID); err != nil {
+		return err
+	}
+
+	// handle topics.
+	for _, content := range project.Topics {
+		topic, err := findTopicByContent(ctx, tx, content)
+
+		switch pa.ErrorCode(err) {
+		case pa.ENOTFOUND: // new topic (need to create)
+			// create and assign new topic to topic var.
+			topic, err = createNewTopic(ctx, tx, content)
+			if err != nil {
+				return err
+			}
+
+		case "": // no error. (leave default value for topic)
+
+		default: // internall error. (return)
+			return err
+		}
+
+		// create link.
+		if err := createTopicLink(ctx, tx, &pa.TopicLink{
+			ProjectID: project.ID,
+			TopicID:   topic.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetProject, project.ID, nil, project)
+}
+
+func updateProject(ctx context.Context, tx *Tx, id int, project *pa.Project) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
+	currentProject, err := findProjectByID(ctx, tx, id)
+	if err != nil {
+		return err
+
+	} else if err := attachTopicsToProjects(ctx, tx, []*pa.Project{currentProject}); err != nil {
+		return err
+	}
+
+	// delete all topic links.
+	if len(currentProject.Topics) > 0 {
+		if err := deleteTopicLinkByProjectID(ctx, tx, currentProject.ID); err != nil {
+			return err
+		}
+	}
+
+	// update project.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE projects
+		SET name 		= ?,
+			description = ?,
+			html_url	= ?
+		WHERE id = ?
+	`,
+		project.Name,
+		project.Description,
+		project.HtmlURL,
+		id,
+	); err != nil {
+		return err
+	}
+	project.CreatedAt = currentProject.CreatedAt
+
+	// handle topics.
+	for _, content := range project.Topics {
+		var topic *pa.Topic
+		topic, err = findTopicByContent(ctx, tx, content)
+
+		switch pa.ErrorCode(err) {
+		case pa.ENOTFOUND: // new topic (need to create)
+			// create and assign new topic to topic var.
+			topic, err = createNewTopic(ctx, tx, content)
+			if err != nil {
+				return err
+			}
+
+		case "": // no error. (leave default value for topic)
+
+		default: // internall error. (return)
+			return err
+		}
+
+		// create link.
+		if err := createTopicLink(ctx, tx, &pa.TopicLink{
+			ProjectID: id,
+			TopicID:   topic.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// the update doesent touch the image.
+	after := *project
+	after.ID, after.Image = id, currentProject.Image
+	return recordAudit(ctx, tx, pa.AuditActionUpdate, pa.AuditTargetProject, id, currentProject, &after)
+}
+
+func deleteProject(ctx context.Context, tx *Tx, name string) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	project, err := findProjectByName(ctx, tx, name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE name = ?`, name); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetProject, project.ID, project, nil)
+}
+
+// topics: many 2 many interface functions ----------------------------------------------
+// to not be used directly (internal tools).
+
+func createNewTopic(ctx context.Context, tx *Tx, content string) (*pa.Topic, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	if content == "" {
+		return nil, pa.Errorf(pa.EINVALID, "content must not be empty.")
+	}
+
+	var id int
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO topics_description (
+			content
+		)
+		VALUES(?)
+		RETURNING id
+	`,
+		content,
+	).Scan(&id); err != nil {
+		return nil, err
+	}
+
+	// set id from database to topic obj.
+	return &pa.Topic{
+		ID:      id,
+		Content: content,
+	}, nil
+}
+
+func findTopicByContent(ctx context.Context, tx *Tx, content string) (*pa.Topic, error) {
+	// use more compact `QueryRow` format for result sets with at most one row expected.
+	var topic pa.Topic
+	if err := tx.QueryRowContext(ctx, `
+		SELECT
+			id,
+			content
+		FROM topics_description
+		WHERE content = ?
+	`,
+		content,
+	).Scan(&topic.ID, &topic.Content); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, pa.Errorf(pa.ENOTFOUND, "topic not found.")
+		}
+		return nil, err
+	}
+
+	return &topic, nil
+}
+
+// findTopicsByProjectIDs returns the topics linked to each of the project ids, keyed by project id.
+func findTopicsByProjectIDs(ctx context.Context, tx *Tx, ids []int) (map[int][]*pa.Topic, error) {
+	cond, args := formatIn("projects_topics.project_id", ids)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			projects_topics.project_id,
+			topics_description.id,
+			topics_description.content
+		FROM projects_topics
+		JOIN topics_description ON topics_description.id = projects_topics.topic_description_id
+		WHERE `+cond+`
+		ORDER BY projects_topics.project_id ASC
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	topics := make(map[int][]*pa.Topic)
+	for rows.Next() {
+		var topic pa.Topic
+		var projectID int
+
+		if err := rows.Scan(&projectID, &topic.ID, &topic.Content); err != nil {
+			return nil, err
+		}
+
+		topics[projectID] = append(topics[projectID], &topic)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return topics, nil
+}
+
+func createTopicLink(ctx context.Context, tx *Tx, topicLink *pa.TopicLink) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO projects_topics (
+			project_id,
+			topic_description_id
+		)
+		VALUES(?, ?)
+	`,
+		topicLink.ProjectID,
+		topicLink.TopicID,
+	)
+	return err
+}
+
+func deleteTopicLinkByProjectID(ctx context.Context, tx *Tx, projID int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	_, err := tx.ExecContext(ctx, `DELETE FROM projects_topics WHERE project_id = ?`, projID)
+	return err
+}
+
+// attachToProjects attaches the topics and the image to projects, each loaded for all the projects at once.
+func attachToProjects(ctx context.Context, tx *Tx, projects []*pa.Project) error {
+	if err := attachTopicsToProjects(ctx, tx, projects); err != nil {
+		return err
+	}
+
+	return attachImageToProjects(ctx, tx, projects)
+}
+
+func attachTopicsToProjects(ctx context.Context, tx *Tx, projects []*pa.Project) error {
+	ids := make([]int, len(projects))
+	for i, project := range projects {
+		ids[i] = project.ID
+	}
+
+	topics, err := findTopicsByProjectIDs(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		for _, topic := range topics[project.ID] { // attach the content for each.
+			project.Topics = append(project.Topics, topic.Content)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,580 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *SubBlogService object implements set interface.
+var _ pa.SubBlogService = (*SubBlogService)(nil)
+
+// SubBlogService represents a service used to manage sub blogs.
+type SubBlogService struct {
+	db *DB
+}
+
+// NewSubBlogService returns a new instance of SubBlogService attached to db.
+func NewSubBlogService(db *DB) *SubBlogService {
+	return &SubBlogService{
+		db: db,
+	}
+}
+
+// FindSubBlogByID returns a sub blog based on id.
+// returns ENOTFOUND if the sub blog doesent exist.
+func (s *SubBlogService) FindSubBlogByID(ctx context.Context, id int) (*pa.SubBlog, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	subBlog, err := findSubBlogByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+
+	} else if err := attachNavigationToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}); err != nil {
+		return nil, err
+	} else if err := attachToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}, true); err != nil {
+		return nil, err
+	}
+
+	return subBlog, nil
+}
+
+// FindSubBlogBySlug returns a sub blog based on the current slug or any slug from the sub blogs slug history.
+// returns ENOTFOUND if the sub blog doesent exist.
+func (s *SubBlogService) FindSubBlogBySlug(ctx context.Context, slug string) (*pa.SubBlog, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	subBlog, err := findSubBlogBySlug(ctx, tx, slug)
+	if err != nil {
+		return nil, err
+
+	} else if err := attachNavigationToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}); err != nil {
+		return nil, err
+	} else if err := attachToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}, true); err != nil {
+		return nil, err
+	}
+
+	return subBlog, nil
+}
+
+// FindSubBlogs returns a range of sub blog based on filter.
+func (s *SubBlogService) FindSubBlogs(ctx context.Context, filter pa.SubBlogFilter) ([]*pa.SubBlog, int, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	subBlogs, n, err := findSubBlogs(ctx, tx, filter)
+	if err != nil {
+		return subBlogs, n, err
+	}
+
+	// attach the relations of all the sub blogs at once, the comments only if included.
+	if err := attachNavigationToSubBlogs(ctx, tx, subBlogs); err != nil {
+		return subBlogs, n, err
+	} else if err := attachToSubBlogs(ctx, tx, subBlogs, filter.IncludeComments); err != nil {
+		return subBlogs, n, err
+	}
+
+	return subBlogs, n, nil
+}
+
+// CreateSubBlog creates a new sub blog.
+// returns EUNAUTHORIZED if the user trying to create the sub blog isnt the admin user.
+func (s *SubBlogService) CreateSubBlog(ctx context.Context, subBlog *pa.SubBlog) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createSubBlog(ctx, tx, subBlog); err != nil {
+		return err
+
+	} else if err := attachNavigationToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}); err != nil {
+		return err
+	} else if err := attachToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}, false); err != nil {
+		return err
+	} // comments cant exist before the sub blog, only the navigation gets attached.
+
+	return tx.Commit()
+}
+
+// UpdateSubBlog updates sub blog with id: id.
+// returns EUNAUTHORIZED if the user trying to update the sub blog isnt the admin user.
+// returns ENOTFOUND if the sub blog doesent exist.
+func (s *SubBlogService) UpdateSubBlog(ctx context.Context, id int, update pa.SubBlogUpdate) (*pa.SubBlog, error) {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	subBlog, err := updateSubBlog(ctx, tx, id, update)
+	if err != nil {
+		return nil, err
+	} else if err := attachNavigationToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}); err != nil {
+		return nil, err
+	} else if err := attachToSubBlogs(ctx, tx, []*pa.SubBlog{subBlog}, true); err != nil {
+		return nil, err
+	}
+
+	return subBlog, tx.Commit()
+}
+
+// DeleteSubBlog permanently deletes the sub blog specified by id.
+// returns EUNAUTHORIZED if the user trying to delete the sub blog isnt the admin user.
+// returns ENOTFOUND if the sub blog doesent exist.
+func (s *SubBlogService) DeleteSubBlog(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteSubBlog(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func findSubBlogByID(ctx context.Context, tx *Tx, id int) (*pa.SubBlog, error) {
+	filter := pa.SubBlogFilter{
+		ID: &id,
+	}
+	subBlogs, _, err := findSubBlogs(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(subBlogs) == 0 {
+		return nil, pa.Errorf(pa.ENOTFOUND, "sub blog not found.")
+	}
+
+	return subBlogs[0], nil
+}
+
+// findSubBlogBySlug looks for the sub blog currently owning slug and falls back on the slug history.
+func findSubBlogBySlug(ctx context.Context, tx *Tx, slug string) (*pa.SubBlog, error) {
+	filter := pa.SubBlogFilter{
+		Slug: &slug,
+	}
+
+	subBlogs, _, err := findSubBlogs(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(subBlogs) != 0 {
+		return subBlogs[0], nil
+	}
+
+	// slug might belong to a renamed sub blog.
+	id, err := findIDBySlugHistory(ctx, tx, subBlogSlugTable, slug)
+	if pa.ErrorCode(err) == pa.ENOTFOUND {
+		return nil, pa.Errorf(pa.ENOTFOUND, "sub blog not found.")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return findSubBlogByID(ctx, tx, id)
+}
+
+// subBlogSortFields maps the sortable fields of pa.SubBlogFilter to their column.
+var subBlogSortFields = map[string]string{
+	"id":        "id",
+	"title":     "title",
+	"position":  "position",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+func findSubBlogs(ctx context.Context, tx *Tx, filter pa.SubBlogFilter) (_ []*pa.SubBlog, n int, err error) {
+	// build where and args statement method.
+	// not vulnerable to sql injection attack.
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	if v := filter.ID; v != nil {
+		where = append(where, "id = ?")
+		args = append(args, *v)
+	}
+	if v := filter.Title; v != nil {
+		where = append(where, "title = ?")
+		args = append(args, *v)
+	}
+	if v := filter.TitleContains; v != nil {
+		cond, arg := formatContains("title", *v)
+		where, args = append(where, cond), append(args, arg)
+	}
+	if v := filter.Slug; v != nil {
+		where = append(where, "slug = ?")
+		args = append(args, *v)
+	}
+	if v := filter.BlogID; v != nil {
+		where = append(where, "blog_id = ?")
+		args = append(args, *v)
+	}
+
+	where, args, order, err := formatQuerySpec(filter.QuerySpec, subBlogSortFields, "blog_id ASC, position ASC", where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	offset := filter.Offset
+	if v := filter.Cursor; v != nil {
+		if filter.SortBy != "" {
+			return nil, 0, pa.Errorf(pa.EINVALID, "sorting cant be combined with a cursor.")
+		}
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
+
+	subBlogs, n, err := querySubBlogs(ctx, tx, where, args, order, FormatLimitOffset(filter.Limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(subBlogs)-1; i < j; i, j = i+1, j-1 {
+			subBlogs[i], subBlogs[j] = subBlogs[j], subBlogs[i]
+		}
+	}
+
+	return subBlogs, n, nil
+}
+
+// querySubBlogs returns the sub blogs matching all the where conditions in order.
+func querySubBlogs(ctx context.Context, tx *Tx, where []string, args []interface{}, order, limitOffset string) (_ []*pa.SubBlog, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			slug,
+			title,
+			blog_id,
+			position,
+			content,
+			created_at,
+			updated_at,
+			COUNT(*) OVER()
+		FROM sub_blogs
+		WHERE `+liveSubBlogs+` AND `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+limitOffset+`
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, n, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	subBlogs := []*pa.SubBlog{}
+	for rows.Next() {
+		var subBlog pa.SubBlog
+
+		if err := rows.Scan(
+			&subBlog.ID,
+			&subBlog.Slug,
+			&subBlog.Title,
+			&subBlog.BlogID,
+			&subBlog.Position,
+			&subBlog.Content,
+			(*NullTime)(&subBlog.CreatedAt),
+			(*NullTime)(&subBlog.UpdatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		subBlogs = append(subBlogs, &subBlog)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return subBlogs, n, nil
+}
+
+func createSubBlog(ctx context.Context, tx *Tx, subBlog *pa.SubBlog) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	subBlog.CreatedAt = tx.now
+	subBlog.UpdatedAt = subBlog.CreatedAt
+
+	if err := subBlog.Validate(); err != nil {
+		return err
+	}
+
+	slug, err := uniqueSlug(ctx, tx, subBlogSlugTable, subBlog.Title, 0)
+	if err != nil {
+		return err
+	}
+	subBlog.Slug = slug
+
+	// place the sub blog in the blog series.
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sub_blogs WHERE blog_id = ? AND deleted_at IS NULL`, subBlog.BlogID).Scan(&n); err != nil {
+		return err
+	}
+
+	if subBlog.Position <= 0 || subBlog.Position > n {
+		// append at the end of the series.
+		subBlog.Position = n + 1
+	} else if _, err := tx.ExecContext(ctx, `
+		UPDATE sub_blogs
+		SET position = position + 1
+		WHERE blog_id = ? AND position >= ? AND deleted_at IS NULL
+	`,
+		subBlog.BlogID,
+		subBlog.Position,
+	); err != nil { // make room for the sub blog.
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO sub_blogs (
+			blog_id,
+			slug,
+			title,
+			position,
+			content,
+			created_at,
+			updated_at
+		)
+		VALUES(?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		subBlog.BlogID,
+		subBlog.Slug,
+		subBlog.Title,
+		subBlog.Position,
+		subBlog.Content,
+		(*NullTime)(&subBlog.CreatedAt),
+		(*NullTime)(&subBlog.UpdatedAt),
+	).Scan(&subBlog.ID); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetSubBlog, subBlog.ID, nil, subBlog)
+}
+
+func updateSubBlog(ctx context.Context, tx *Tx, id int, update pa.SubBlogUpdate) (*pa.SubBlog, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	subBlog, err := findSubBlogByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *subBlog
+
+	title := subBlog.Title
+	if v := update.Content; v != nil {
+		subBlog.Content = *v
+	}
+	if v := update.Title; v != nil {
+		subBlog.Title = *v
+	}
+
+	if err := subBlog.Validate(); err != nil {
+		return nil, err
+	}
+
+	// a new title gets a new slug, the old slug is kept in the history to redirect from.
+	if subBlog.Title != title {
+		slug, err := uniqueSlug(ctx, tx, subBlogSlugTable, subBlog.Title, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if slug != subBlog.Slug {
+			if err := renameSlug(ctx, tx, subBlogSlugTable, id, subBlog.Slug, slug); err != nil {
+				return nil, err
+			}
+			subBlog.Slug = slug
+		}
+	}
+
+	subBlog.UpdatedAt = tx.now
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sub_blogs
+		SET slug		= ?,
+			content		= ?,
+			title 		= ?,
+			updated_at 	= ?
+		WHERE id = ?	
+	`,
+		subBlog.Slug,
+		subBlog.Content,
+		subBlog.Title,
+		(*NullTime)(&subBlog.UpdatedAt),
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, pa.AuditActionUpdate, pa.AuditTargetSubBlog, id, &before, subBlog); err != nil {
+		return nil, err
+	}
+
+	return subBlog, nil
+}
+
+func deleteSubBlog(ctx context.Context, tx *Tx, id int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	subBlog, err := findSubBlogByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// the sub blog keeps its position to be restored to.
+	if _, err := tx.ExecContext(ctx, `UPDATE sub_blogs SET deleted_at = ? WHERE id = ?`, (*NullTime)(&tx.now), id); err != nil {
+		return err
+	}
+
+	// close the gap left in the blog series.
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sub_blogs
+		SET position = position - 1
+		WHERE blog_id = ? AND position > ? AND deleted_at IS NULL
+	`,
+		subBlog.BlogID,
+		subBlog.Position,
+	); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetSubBlog, id, subBlog, nil)
+}
+
+// attachToSubBlogs attaches the media and, if included, the comments to subBlogs. Every relation is
+// loaded for all the sub blogs with a single query keyed by the parent ids.
+func attachToSubBlogs(ctx context.Context, tx *Tx, subBlogs []*pa.SubBlog, comments bool) error {
+	if err := attachMediaToSubBlogs(ctx, tx, subBlogs); err != nil {
+		return err
+	} else if !comments {
+		return nil
+	}
+
+	return attachCommentsToSubBlogs(ctx, tx, subBlogs)
+}
+
+func attachCommentsToSubBlogs(ctx context.Context, tx *Tx, subBlogs []*pa.SubBlog) error {
+	ids := make([]int, len(subBlogs))
+	for i, subBlog := range subBlogs {
+		ids[i] = subBlog.ID
+	}
+
+	// held comments are left out of the sub blogs.
+	cond, args := formatIn("sub_blog_id", ids)
+	args = append(args, pa.CommentStatusApproved)
+	comments, _, err := queryComments(ctx, tx, []string{cond, "status = ?"}, args, "id ASC", "")
+	if err != nil {
+		return err
+	} // we dont care if there are no comments.
+
+	bySubBlog := make(map[int][]*pa.Comment)
+	for _, comment := range comments {
+		bySubBlog[comment.SubBlogID] = append(bySubBlog[comment.SubBlogID], comment)
+	}
+
+	// append found comments under each sub blog.
+	for _, subBlog := range subBlogs {
+		subBlog.Comments = append(subBlog.Comments, bySubBlog[subBlog.ID]...)
+	}
+	return nil
+}
+
+// attachNavigationToSubBlogs attaches the previous and next sub blog in the blog series to each sub blog.
+// the summaries of the whole series of every blog are loaded at once.
+func attachNavigationToSubBlogs(ctx context.Context, tx *Tx, subBlogs []*pa.SubBlog) error {
+	ids := make([]int, len(subBlogs))
+	for i, subBlog := range subBlogs {
+		ids[i] = subBlog.BlogID
+	}
+
+	cond, args := formatIn("blog_id", ids)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			slug,
+			title,
+			position,
+			blog_id
+		FROM sub_blogs
+		WHERE `+liveSubBlogs+` AND `+cond+`
+		ORDER BY blog_id ASC, position ASC
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	series := make(map[int][]*pa.SubBlogSummary)
+	for rows.Next() {
+		var summary pa.SubBlogSummary
+		var blogID int
+
+		if err := rows.Scan(
+			&summary.ID,
+			&summary.Slug,
+			&summary.Title,
+			&summary.Position,
+			&blogID,
+		); err != nil {
+			return err
+		}
+
+		series[blogID] = append(series[blogID], &summary)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// the neighbours are the closest positions on each side, positions may have gaps mid transaction.
+	for _, subBlog := range subBlogs {
+		subBlog.Prev, subBlog.Next = nil, nil
+		for _, summary := range series[subBlog.BlogID] {
+			if summary.Position < subBlog.Position {
+				subBlog.Prev = summary
+			} else if summary.Position > subBlog.Position {
+				subBlog.Next = summary
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// newSubBlogSummary returns the summary of subBlog.
+func newSubBlogSummary(subBlog *pa.SubBlog) *pa.SubBlogSummary {
+	return &pa.SubBlogSummary{
+		ID:       subBlog.ID,
+		Slug:     subBlog.Slug,
+		Title:    subBlog.Title,
+		Position: subBlog.Position,
+	}
+}
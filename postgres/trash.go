@@ -0,0 +1,299 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *TrashService object implements set interface.
+var _ pa.TrashService = (*TrashService)(nil)
+
+// TrashService represents a service used to manage the soft deleted blogs, sub blogs and comments.
+type TrashService struct {
+	db *DB
+}
+
+// NewTrashService returns a new instance of TrashService attached to db.
+func NewTrashService(db *DB) *TrashService {
+	return &TrashService{
+		db: db,
+	}
+}
+
+// FindTrash returns a range of deleted objects based on filter, latest deleted first.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *TrashService) FindTrash(ctx context.Context, filter pa.TrashFilter) ([]*pa.TrashItem, int, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	return findTrash(ctx, tx, filter)
+}
+
+// Restore restores the deleted object of type typ with the id.
+// returns ENOTFOUND if the object isnt in the trash.
+// returns ECONFLICT if the parent of the object is in the trash.
+// returns EINVALID if typ is unknown.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *TrashService) Restore(ctx context.Context, typ string, id int) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := restore(ctx, tx, typ, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge permanently deletes the objects deleted before t and returns the number of purged objects.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *TrashService) Purge(ctx context.Context, t time.Time) (int, error) {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := purge(ctx, tx, t)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+func findTrash(ctx context.Context, tx *Tx, filter pa.TrashFilter) (_ []*pa.TrashItem, n int, err error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, 0, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	// build where and args statement method.
+	// not vulnerable to sql injection attack.
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	if v := filter.Type; v != nil {
+		where = append(where, "type = ?")
+		args = append(args, *v)
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset, the type breaks the
+	// ties between the ids of the different types.
+	order, offset := "deleted_at DESC, id DESC, type DESC", filter.Offset
+	if v := filter.Cursor; v != nil {
+		cond := "(deleted_at, id, type) < (?, ?, ?)"
+		if v.Before {
+			cond, order = "(deleted_at, id, type) > (?, ?, ?)", "deleted_at ASC, id ASC, type ASC"
+		}
+		where, args = append(where, cond), append(args, (*NullTime)(&v.CreatedAt), v.ID, v.Type)
+		offset = 0
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			type,
+			id,
+			title,
+			deleted_at,
+			COUNT(*) OVER()
+		FROM (
+			SELECT '`+pa.TrashTypeBlog+`' AS type, id, title, deleted_at FROM blogs WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT '`+pa.TrashTypeSubBlog+`' AS type, id, title, deleted_at FROM sub_blogs WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT '`+pa.TrashTypeComment+`' AS type, id, content, deleted_at FROM comments WHERE deleted_at IS NOT NULL
+		) AS trash
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+FormatLimitOffset(filter.Limit, offset)+`
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, n, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	items := []*pa.TrashItem{}
+	for rows.Next() {
+		var item pa.TrashItem
+
+		if err := rows.Scan(
+			&item.Type,
+			&item.ID,
+			&item.Title,
+			(*NullTime)(&item.DeletedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	return items, n, nil
+}
+
+func restore(ctx context.Context, tx *Tx, typ string, id int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	var err error
+	switch typ {
+	case pa.TrashTypeBlog:
+		err = restoreBlog(ctx, tx, id)
+	case pa.TrashTypeSubBlog:
+		err = restoreSubBlog(ctx, tx, id)
+	case pa.TrashTypeComment:
+		err = restoreComment(ctx, tx, id)
+	default:
+		return pa.Errorf(pa.EINVALID, "unknown trash type: %q.", typ)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the trash types name the audit targets.
+	return recordAudit(ctx, tx, pa.AuditActionRestore, typ, id, nil, nil)
+}
+
+func restoreBlog(ctx context.Context, tx *Tx, id int) error {
+	result, err := tx.ExecContext(ctx, `UPDATE blogs SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return pa.Errorf(pa.ENOTFOUND, "blog isnt in the trash.")
+	}
+
+	return nil
+}
+
+func restoreSubBlog(ctx context.Context, tx *Tx, id int) error {
+	var blogID, position int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT blog_id, position FROM sub_blogs WHERE id = ? AND deleted_at IS NOT NULL
+	`,
+		id,
+	).Scan(&blogID, &position); err == sql.ErrNoRows {
+		return pa.Errorf(pa.ENOTFOUND, "sub blog isnt in the trash.")
+	} else if err != nil {
+		return err
+	}
+
+	if ok, err := isLive(ctx, tx, "blogs", liveBlogs, blogID); err != nil {
+		return err
+	} else if !ok {
+		return pa.Errorf(pa.ECONFLICT, "the blog of the sub blog is in the trash.")
+	}
+
+	// put the sub blog back at its position, the series might have shrunk in the meantime.
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sub_blogs WHERE blog_id = ? AND deleted_at IS NULL`, blogID).Scan(&n); err != nil {
+		return err
+	}
+
+	if position <= 0 || position > n {
+		position = n + 1
+	} else if _, err := tx.ExecContext(ctx, `
+		UPDATE sub_blogs
+		SET position = position + 1
+		WHERE blog_id = ? AND position >= ? AND deleted_at IS NULL
+	`,
+		blogID,
+		position,
+	); err != nil { // make room for the sub blog.
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE sub_blogs SET deleted_at = NULL, position = ? WHERE id = ?`, position, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func restoreComment(ctx context.Context, tx *Tx, id int) error {
+	var subBlogID int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT sub_blog_id FROM comments WHERE id = ? AND deleted_at IS NOT NULL
+	`,
+		id,
+	).Scan(&subBlogID); err == sql.ErrNoRows {
+		return pa.Errorf(pa.ENOTFOUND, "comment isnt in the trash.")
+	} else if err != nil {
+		return err
+	}
+
+	if ok, err := isLive(ctx, tx, "sub_blogs", liveSubBlogs, subBlogID); err != nil {
+		return err
+	} else if !ok {
+		return pa.Errorf(pa.ECONFLICT, "the sub blog of the comment is in the trash.")
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE comments SET deleted_at = NULL WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// isLive reports whether the row of table with the id matches the live condition.
+func isLive(ctx context.Context, tx *Tx, table, live string, id int) (bool, error) {
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table+` WHERE id = ? AND `+live, id).Scan(&n); err != nil {
+		return false, err
+	}
+
+	return n != 0, nil
+}
+
+func purge(ctx context.Context, tx *Tx, t time.Time) (n int, err error) {
+	if !pa.IsAdminContext(ctx) {
+		return 0, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	// children first, the rows underneath the purged ones go along them through the foreign keys.
+	for _, table := range []string{"comments", "sub_blogs", "blogs"} {
+		result, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE deleted_at < ?`, (*NullTime)(&t))
+		if err != nil {
+			return 0, err
+		}
+
+		purged, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		n += int(purged)
+	}
+
+	if n == 0 {
+		return 0, nil
+	}
+	return n, recordAudit(ctx, tx, pa.AuditActionPurge, pa.AuditTargetTrash, 0, nil, map[string]interface{}{"before": t, "purged": n})
+}
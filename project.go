@@ -1,6 +1,9 @@
 package pa
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Project represents a github api repo response simplified.
 // this is consumed by our frontend to avoid getting github rate-limited.
@@ -13,6 +16,12 @@ type Project struct {
 	Description string   `json:"description"`
 	Topics      []string `json:"topics"`
 	HtmlURL     string   `json:"html_url"`
+
+	// the image of the project from the media library.
+	Image *Media `json:"image"`
+
+	// timestamp.
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // TopicLink represents a link between a topic and a project.
@@ -52,6 +61,7 @@ type ProjectService interface {
 
 	// FindProjects returns a range of preojects and the length of the range. If filter
 	// is specified FindProjects will apply the filter to return set response.
+	// returns EINVALID if the query spec of the filter is invalid.
 	FindProjects(ctx context.Context, filter ProjectFilter) ([]*Project, int, error)
 
 	// CreateOrUpdateProject checks for existing id field on project or any duplicate name, if any
@@ -68,10 +78,15 @@ type ProjectService interface {
 // ProjectFilter represents a filter used by FindProjects to filter the response.
 type ProjectFilter struct {
 	// fields to filter on.
-	ID   *int    `json:"id"`
-	Name *string `json:"name"`
+	ID           *int    `json:"id"`
+	Name         *string `json:"name"`
+	NameContains *string `json:"nameContains"`
+
+	// ordering and creation time range, projects can be sorted by "id", "name" and "createdAt".
+	QuerySpec
 
 	// restrictions on the result set, used for pagination and set limits.
-	Offset int `json:"offset"`
-	Limit  int `json:"limit"`
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
 }
@@ -0,0 +1,35 @@
+package pa
+
+import "time"
+
+// sort orders of QuerySpec.Order.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// QuerySpec represents the ordering and the creation time range shared by the list filters.
+type QuerySpec struct {
+	// SortBy is one of the sortable fields of the filter (ie: "createdAt"), the result set keeps
+	// its default order if left empty. Sorting cant be combined with a cursor.
+	SortBy string `json:"sortBy"`
+	Order  string `json:"order"` // OrderAsc (default) or OrderDesc.
+
+	// exclusive bounds on the creation time.
+	CreatedAfter  *time.Time `json:"createdAfter"`
+	CreatedBefore *time.Time `json:"createdBefore"`
+}
+
+// Validate performs basic validation on the query spec, the sortable fields are checked by the
+// service running the query.
+// returns EINVALID if any error is found.
+func (q QuerySpec) Validate() error {
+	if q.Order != "" && q.Order != OrderAsc && q.Order != OrderDesc {
+		return Errorf(EINVALID, "order must be %q or %q.", OrderAsc, OrderDesc)
+	}
+	if q.CreatedAfter != nil && q.CreatedBefore != nil && !q.CreatedAfter.Before(*q.CreatedBefore) {
+		return Errorf(EINVALID, "created after must be before created before.")
+	}
+
+	return nil
+}
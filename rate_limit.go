@@ -0,0 +1,125 @@
+package pa
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// rate limit keys, identify whose bucket a request takes its token from.
+const (
+	// RateLimitKeyUser keys the buckets by the authentificated user, unauthentificated requests
+	// fall back to their ip.
+	RateLimitKeyUser = "user"
+
+	// RateLimitKeyAPIKey keys the buckets by the api key of the request, requests without an api
+	// key fall back to their ip.
+	RateLimitKeyAPIKey = "apiKey"
+
+	// RateLimitKeyIP keys the buckets by the ip of the request.
+	RateLimitKeyIP = "ip"
+)
+
+// RateLimitPolicy represents the token bucket applied to the requests of a route.
+type RateLimitPolicy struct {
+	// the requests the policy applies to, Path is a path prefix, ie: "/v1/comments" and an empty
+	// Method matches any method.
+	Path   string `mapstructure:"path"`
+	Method string `mapstructure:"method"`
+
+	// the key of the buckets, ie: RateLimitKeyUser -> ./rate_limit.go, default: RateLimitKeyUser.
+	Key string `mapstructure:"key"`
+
+	// Limit tokens refill evenly over Period, a bucket holds at most Burst tokens (Limit if 0).
+	Limit  int           `mapstructure:"limit"`
+	Period time.Duration `mapstructure:"period"`
+	Burst  int           `mapstructure:"burst"`
+}
+
+// Validate performs basic validation on RateLimitPolicy.
+// returns EINVALID if any error is found.
+func (p RateLimitPolicy) Validate() error {
+	if !strings.HasPrefix(p.Path, "/") {
+		return Errorf(EINVALID, "rate limit path must start with '/'.")
+	} else if p.Limit <= 0 || p.Period <= 0 {
+		return Errorf(EINVALID, "rate limit must have a positive limit and period.")
+	} else if p.Burst < 0 {
+		return Errorf(EINVALID, "rate limit burst cant be negative.")
+	}
+
+	switch p.Key {
+	case "", RateLimitKeyUser, RateLimitKeyAPIKey, RateLimitKeyIP:
+		return nil
+	default:
+		return Errorf(EINVALID, "unknown rate limit key: %s.", p.Key)
+	}
+}
+
+// Capacity returns the number of tokens a full bucket of the policy holds.
+func (p RateLimitPolicy) Capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// Interval returns the time it takes the policy to refill one token.
+func (p RateLimitPolicy) Interval() time.Duration {
+	return p.Period / time.Duration(p.Limit)
+}
+
+// Matches reports whether the policy applies to a request with method on path.
+func (p RateLimitPolicy) Matches(method, path string) bool {
+	if p.Method != "" && !strings.EqualFold(p.Method, method) {
+		return false
+	}
+	return path == p.Path || strings.HasPrefix(path, strings.TrimSuffix(p.Path, "/")+"/")
+}
+
+// Take takes a token at now from a bucket of the policy, tat is the theoretical arrival time of the
+// bucket: the time at which the bucket is full again, zero for missing buckets. Returns the new tat of
+// the bucket, unchanged if the request got rejected, and the state of the bucket.
+func (p RateLimitPolicy) Take(now, tat time.Time) (time.Time, *RateLimit) {
+	interval, capacity := p.Interval(), p.Capacity()
+	if tat.Before(now) {
+		tat = now
+	}
+
+	limit := &RateLimit{
+		Limit: capacity,
+	}
+
+	next := tat.Add(interval)
+	allowAt := next.Add(-time.Duration(capacity) * interval)
+	if now.Before(allowAt) {
+		limit.Reset = tat.Sub(now)
+		limit.RetryAfter = allowAt.Sub(now)
+		return tat, limit
+	}
+
+	limit.Allowed = true
+	limit.Remaining = int(now.Sub(allowAt) / interval)
+	limit.Reset = next.Sub(now)
+	return next, limit
+}
+
+// RateLimit represents the state of a bucket after a request tried to take a token from it.
+type RateLimit struct {
+	// reports whether the request got a token.
+	Allowed bool
+
+	// the capacity of the bucket and the tokens left in it.
+	Limit     int
+	Remaining int
+
+	// the time until the bucket is full again and until the next token is available, RetryAfter
+	// is 0 for allowed requests.
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimiter represents a store of token buckets.
+type RateLimiter interface {
+	// Allow takes a token from the bucket of policy under key, missing buckets start full.
+	Allow(ctx context.Context, key string, policy RateLimitPolicy) (*RateLimit, error)
+}
@@ -0,0 +1,82 @@
+// Package redis implements the pa services which share their state between instances through redis.
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// check to see if *RateLimiter object implements set interface.
+var _ pa.RateLimiter = (*RateLimiter)(nil)
+
+// KeyPrefix prefixes the keys of the buckets in redis.
+const KeyPrefix = "pa:rate-limit:"
+
+// maxTxRetries is the number of times a bucket update is retried when a concurrent request updated
+// the same bucket.
+const maxTxRetries = 10
+
+// RateLimiter represents a store of token buckets held in redis, the buckets expire once full.
+type RateLimiter struct {
+	client *goredis.Client
+
+	// Now returns the current time, configurable for tests.
+	Now func() time.Time
+}
+
+// NewRateLimiter returns a new instance of RateLimiter connected to the redis server at addr.
+func NewRateLimiter(addr string) *RateLimiter {
+	return &RateLimiter{
+		client: goredis.NewClient(&goredis.Options{
+			Addr: addr,
+		}),
+		Now: time.Now,
+	}
+}
+
+// Close closes the connection to the redis server.
+func (l *RateLimiter) Close() error {
+	return l.client.Close()
+}
+
+// Allow takes a token from the bucket of policy under key, missing buckets start full.
+func (l *RateLimiter) Allow(ctx context.Context, key string, policy pa.RateLimitPolicy) (limit *pa.RateLimit, err error) {
+	key = KeyPrefix + key
+
+	// read and update the bucket optimistically, the transaction fails if the bucket changed in between.
+	take := func(tx *goredis.Tx) error {
+		var tat time.Time
+		switch v, err := tx.Get(ctx, key).Int64(); {
+		case errors.Is(err, goredis.Nil): // missing buckets are full.
+		case err != nil:
+			return err
+		default:
+			tat = time.Unix(0, v)
+		}
+
+		now := l.Now()
+		next, state := policy.Take(now, tat)
+		limit = state
+		if !state.Allowed {
+			return nil
+		}
+
+		_, err := tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			return pipe.Set(ctx, key, strconv.FormatInt(next.UnixNano(), 10), next.Sub(now)).Err()
+		})
+		return err
+	}
+
+	for i := 0; i < maxTxRetries; i++ {
+		err = l.client.Watch(ctx, take, key)
+		if !errors.Is(err, goredis.TxFailedErr) {
+			return limit, err
+		}
+	}
+	return nil, err
+}
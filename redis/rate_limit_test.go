@@ -0,0 +1,58 @@
+package redis_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/redis"
+)
+
+// addrEnv holds the address of the redis server used by the tests, ie: "127.0.0.1:6379".
+// tests needing a server are skipped when unset.
+const addrEnv = "PA_REDIS_ADDR"
+
+func TestRateLimiter(t *testing.T) {
+	addr := os.Getenv(addrEnv)
+	if addr == "" {
+		t.Skip(addrEnv + " isnt set")
+	}
+
+	policy := pa.RateLimitPolicy{Path: "/", Limit: 2, Period: time.Second, Burst: 3}
+
+	t.Run("Ok Allow Call", func(t *testing.T) {
+		now := time.Now()
+		l := redis.NewRateLimiter(addr)
+		defer l.Close()
+		l.Now = func() time.Time { return now }
+
+		// unique key, the buckets outlive the test.
+		key := "test:" + strconv.FormatInt(now.UnixNano(), 10)
+
+		// the bucket starts full.
+		for want := 2; want >= 0; want-- {
+			if limit, err := l.Allow(context.Background(), key, policy); err != nil {
+				t.Fatal(err)
+			} else if !limit.Allowed || limit.Remaining != want {
+				t.Fatalf("limit=%+v", limit)
+			}
+		}
+
+		if limit, err := l.Allow(context.Background(), key, policy); err != nil {
+			t.Fatal(err)
+		} else if limit.Allowed || limit.RetryAfter != 500*time.Millisecond {
+			t.Fatalf("limit=%+v", limit)
+		}
+
+		// a token refills every 500ms.
+		now = now.Add(500 * time.Millisecond)
+		if limit, err := l.Allow(context.Background(), key, policy); err != nil {
+			t.Fatal(err)
+		} else if !limit.Allowed || limit.Remaining != 0 {
+			t.Fatalf("limit=%+v", limit)
+		}
+	})
+}
@@ -0,0 +1,363 @@
+// Package s3 implements pa.FileService on top of the S3 API, any S3 compatible object storage
+// (AWS S3, MinIO, R2, ...) can be used.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+var _ pa.FileService = (*FileService)(nil)
+
+// FileService represents a service used to manage files stored as objects in a S3 bucket.
+// Paths map to object keys under Prefix, ie: "/bar/file.txt" with the prefix "media" is stored
+// under the key "media/bar/file.txt".
+type FileService struct {
+	endpoint *url.URL
+	bucket   string
+
+	// credentials used to sign requests.
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+
+	// Prefix is prepended to every object key.
+	Prefix string
+
+	// PathStyle addresses the bucket in the path (http://host/bucket/key) instead of the host
+	// (http://bucket.host/key), required by most self hosted S3 compatible storages.
+	PathStyle bool
+
+	// MaxSize is the maximum size in bytes of a created file, 0 means no limit.
+	MaxSize int64
+
+	// AllowedMimeTypes restricts the sniffed mime type of created files, ie: "image/png" or "image/*".
+	// an empty list allows any type.
+	AllowedMimeTypes []string
+
+	// HTTPClient is the client used to send requests, defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Now returns the current time, used to sign requests. Configurable for tests.
+	Now func() time.Time
+}
+
+// NewFileService returns a new FileService storing objects in bucket at the S3 endpoint.
+func NewFileService(endpoint, bucket string) (*FileService, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", endpoint)
+	}
+
+	return &FileService{
+		endpoint:   u,
+		bucket:     bucket,
+		Region:     "us-east-1",
+		HTTPClient: http.DefaultClient,
+		Now:        time.Now,
+	}, nil
+}
+
+// CreateFile uploads content as the object at path, an existing object gets replaced.
+// returns EINVALID if the path is invalid, the content is too large or of a disallowed type.
+func (s *FileService) CreateFile(ctx context.Context, p string, content io.Reader) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	key, err := s.key(p)
+	if err != nil {
+		return err
+	}
+
+	// the payload gets hashed for the signature, buffer it.
+	r := content
+	if s.MaxSize > 0 {
+		r = io.LimitReader(content, s.MaxSize+1)
+	}
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	} else if s.MaxSize > 0 && int64(len(buf)) > s.MaxSize {
+		return pa.Errorf(pa.EINVALID, "file exceeds %d bytes.", s.MaxSize)
+	}
+
+	mimeType := http.DetectContentType(buf)
+	if !pa.MatchMimeType(mimeType, s.AllowedMimeTypes) {
+		return pa.Errorf(pa.EINVALID, "mime type not allowed: %s.", mimeType)
+	}
+
+	// prefer the type of the extension, sniffing cant tell apart most text formats.
+	if byExt := mime.TypeByExtension(path.Ext(key)); byExt != "" {
+		mimeType = byExt
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, nil, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mimeType)
+
+	resp, err := s.do(req, hashHex(buf))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// OpenFile streams the object at path.
+// returns ENOTFOUND if the object doesent exist.
+func (s *FileService) OpenFile(ctx context.Context, p string) (io.ReadCloser, *pa.FileInfo, error) {
+	key, err := s.key(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Body, s.newFileInfo(key, resp), nil
+}
+
+// Stat returns the file info of the object at path.
+// returns ENOTFOUND if the object doesent exist.
+func (s *FileService) Stat(ctx context.Context, p string) (*pa.FileInfo, error) {
+	key, err := s.key(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return s.newFileInfo(key, resp), nil
+}
+
+// List returns the objects under prefix ordered by path.
+func (s *FileService) List(ctx context.Context, prefix string) ([]*pa.FileInfo, error) {
+	keyPrefix := strings.TrimPrefix(path.Clean("/"+prefix), "/")
+	if keyPrefix != "" {
+		keyPrefix += "/"
+	}
+	keyPrefix = s.joinPrefix(keyPrefix)
+
+	files := []*pa.FileInfo{}
+	token := ""
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"prefix":    {keyPrefix},
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range result.Contents {
+			p := s.path(obj.Key)
+			files = append(files, &pa.FileInfo{
+				Path:        p,
+				Size:        obj.Size,
+				ContentType: mime.TypeByExtension(path.Ext(p)),
+				ModTime:     obj.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	return files, nil
+}
+
+// DeleteFile deletes the object at path.
+// returns ENOTFOUND if the object doesent exist.
+func (s *FileService) DeleteFile(ctx context.Context, p string) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	// S3 deletes are idempotent, check for existence to keep the ENOTFOUND contract.
+	if _, err := s.Stat(ctx, p); err != nil {
+		return err
+	}
+
+	key, err := s.key(p)
+	if err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// key maps path to an object key.
+// returns EINVALID if the path holds ".." elements or doesent name a file.
+func (s *FileService) key(p string) (string, error) {
+	for _, elem := range strings.Split(p, "/") {
+		if elem == ".." {
+			return "", pa.Errorf(pa.EINVALID, "invalid path: %q.", p)
+		}
+	}
+
+	cleaned := path.Clean("/" + p)
+	if cleaned == "/" {
+		return "", pa.Errorf(pa.EINVALID, "invalid path: %q.", p)
+	}
+
+	return s.joinPrefix(strings.TrimPrefix(cleaned, "/")), nil
+}
+
+// path maps an object key back to a path.
+func (s *FileService) path(key string) string {
+	return "/" + strings.TrimPrefix(key, s.joinPrefix(""))
+}
+
+// joinPrefix prepends the configured prefix to key.
+func (s *FileService) joinPrefix(key string) string {
+	prefix := strings.Trim(path.Clean("/"+s.Prefix), "/") // "./media" -> "media".
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
+
+// newRequest returns a request for the object key, an empty key addresses the bucket.
+func (s *FileService) newRequest(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Request, error) {
+	u := *s.endpoint
+	if s.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+		if key != "" {
+			u.Path += "/" + key
+		}
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	u.RawPath = escapePath(u.Path) // send the path exactly as it gets signed.
+	u.RawQuery = canonicalQuery(query)
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	return http.NewRequestWithContext(ctx, method, u.String(), r)
+}
+
+// do signs and sends req, responses with a non 2xx status get mapped to errors.
+func (s *FileService) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	sign(req, s.AccessKeyID, s.SecretAccessKey, s.Region, payloadHash, s.Now())
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(resp.Body).Decode(&e) // HEAD responses have no body.
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, pa.Errorf(pa.ENOTFOUND, "file doesent exist.")
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("s3: access denied: %s", e.Message)
+	default:
+		return nil, fmt.Errorf("s3: %s %s: %d %s: %s", req.Method, req.URL.Path, resp.StatusCode, e.Code, e.Message)
+	}
+}
+
+// newFileInfo returns the file info from the headers of an object response.
+func (s *FileService) newFileInfo(key string, resp *http.Response) *pa.FileInfo {
+	info := &pa.FileInfo{
+		Path:        s.path(key),
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if n, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = n
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+
+	return info
+}
+
+// listBucketResult is the response of ListObjectsV2.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
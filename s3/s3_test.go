@@ -0,0 +1,241 @@
+package s3_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/s3"
+)
+
+// FakeS3 is an in-memory stand-in for a S3 compatible storage using path style addressing.
+type FakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	content     []byte
+	contentType string
+	modTime     time.Time
+}
+
+func (f *FakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+f.bucket), "/")
+
+	switch {
+	case r.Method == http.MethodGet && key == "": // ListObjectsV2.
+		type content struct {
+			Key          string
+			Size         int64
+			LastModified time.Time
+		}
+		var result struct {
+			XMLName     xml.Name `xml:"ListBucketResult"`
+			Contents    []content
+			IsTruncated bool
+		}
+
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			result.Contents = append(result.Contents, content{k, int64(len(f.objects[k].content)), f.objects[k].modTime})
+		}
+		xml.NewEncoder(w).Encode(result)
+
+	case r.Method == http.MethodPut:
+		content, _ := io.ReadAll(r.Body)
+		f.objects[key] = fakeObject{content, r.Header.Get("Content-Type"), time.Now().UTC().Truncate(time.Second)}
+
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Last-Modified", obj.modTime.Format(http.TimeFormat))
+		w.Write(obj.content)
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MustOpenFakeS3 returns a FileService backed by a fake S3 server.
+func MustOpenFakeS3(t *testing.T) (*s3.FileService, *FakeS3) {
+	t.Helper()
+
+	fake := &FakeS3{bucket: "bucket", objects: make(map[string]fakeObject)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	fileService, err := s3.NewFileService(srv.URL, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileService.AccessKeyID = "AKID"
+	fileService.SecretAccessKey = "SECRET"
+	fileService.PathStyle = true
+	fileService.Prefix = "media"
+
+	return fileService, fake
+}
+
+func TestCreateFile(t *testing.T) {
+	fileService, fake := MustOpenFakeS3(t)
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	t.Run("Ok Create Call", func(t *testing.T) {
+		if err := fileService.CreateFile(adminUsrCtx, "/bar/file.txt", strings.NewReader("FOO BAR")); err != nil {
+			t.Fatal(err)
+		}
+
+		// assert creation under the prefix.
+		if obj, ok := fake.objects["media/bar/file.txt"]; !ok {
+			t.Fatal("object not created")
+		} else if string(obj.content) != "FOO BAR" {
+			t.Fatalf("content=%q", obj.content)
+		} else if !strings.HasPrefix(obj.contentType, "text/plain") {
+			t.Fatalf("content type=%v", obj.contentType)
+		}
+	})
+
+	t.Run("Bad Create Call", func(t *testing.T) {
+		// un auth.
+		if err := fileService.CreateFile(context.Background(), "/file.txt", strings.NewReader("FOO")); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatal("err != EUNAUTHORIZED")
+		}
+
+		// path traversal.
+		if err := fileService.CreateFile(adminUsrCtx, "/../file.txt", strings.NewReader("FOO")); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// too large.
+		fileService.MaxSize = 2
+		defer func() { fileService.MaxSize = 0 }()
+		if err := fileService.CreateFile(adminUsrCtx, "/file.txt", strings.NewReader("FOO")); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+}
+
+func TestOpenFile(t *testing.T) {
+	fileService, _ := MustOpenFakeS3(t)
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	MustCreateFile(t, fileService, adminUsrCtx, "/file.txt", "FOO BAR")
+
+	t.Run("Ok Open Call", func(t *testing.T) {
+		rc, info, err := fileService.OpenFile(context.Background(), "/file.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+
+		if content, err := io.ReadAll(rc); err != nil {
+			t.Fatal(err)
+		} else if string(content) != "FOO BAR" {
+			t.Fatalf("content=%q", content)
+		} else if info.Path != "/file.txt" || info.Size != 7 || info.ModTime.IsZero() {
+			t.Fatalf("info=%+v", info)
+		}
+	})
+
+	t.Run("Ok Stat Call", func(t *testing.T) {
+		if info, err := fileService.Stat(context.Background(), "/file.txt"); err != nil {
+			t.Fatal(err)
+		} else if info.Path != "/file.txt" {
+			t.Fatalf("path=%v", info.Path)
+		}
+	})
+
+	t.Run("Bad Open Call (Not Found)", func(t *testing.T) {
+		if _, _, err := fileService.OpenFile(context.Background(), "/missing.txt"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		} else if _, err := fileService.Stat(context.Background(), "/missing.txt"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	fileService, _ := MustOpenFakeS3(t)
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	MustCreateFile(t, fileService, adminUsrCtx, "/a.txt", "A")
+	MustCreateFile(t, fileService, adminUsrCtx, "/bar/b.txt", "B")
+	MustCreateFile(t, fileService, adminUsrCtx, "/bar/baz/c.txt", "C")
+
+	files, err := fileService.List(context.Background(), "/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files[0].Path != "/bar/b.txt" || files[1].Path != "/bar/baz/c.txt" {
+		t.Fatalf("got %v files", len(files))
+	}
+
+	if files, err := fileService.List(context.Background(), "/"); err != nil {
+		t.Fatal(err)
+	} else if len(files) != 3 {
+		t.Fatalf("got %v files", len(files))
+	}
+}
+
+func TestDeleteFile(t *testing.T) {
+	fileService, fake := MustOpenFakeS3(t)
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	MustCreateFile(t, fileService, adminUsrCtx, "/file.txt", "FOO")
+
+	t.Run("Ok Delete Call", func(t *testing.T) {
+		if err := fileService.DeleteFile(adminUsrCtx, "/file.txt"); err != nil {
+			t.Fatal(err)
+		} else if _, ok := fake.objects["media/file.txt"]; ok {
+			t.Fatal("object not deleted")
+		}
+	})
+
+	t.Run("Bad Delete Call (Not Found)", func(t *testing.T) {
+		if err := fileService.DeleteFile(adminUsrCtx, "/file.txt"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+	})
+}
+
+func MustCreateFile(t *testing.T, fileService pa.FileService, ctx context.Context, path string, content string) {
+	t.Helper()
+	if err := fileService.CreateFile(ctx, path, strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+}
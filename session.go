@@ -1,5 +1,7 @@
 package pa
 
+import "time"
+
 /*
 	You could argue that the session types should be under the http package but I consider them important
 	enough to get their own package + you could implement a session cacher which would also need access
@@ -13,6 +15,10 @@ const SessionCookieName = "session"
 type Session struct {
 	UserID  int  `json:"userID"`
 	IsAdmin bool `json:"isAdmin"`
+	// the time the user logged in, sessions created before the user got banned are revoked.
+	CreatedAt time.Time `json:"createdAt"`
+	// synchronizer token sent back by the cookie authentificated state changing requests, rotated on login.
+	CSRFToken string `json:"csrfToken"`
 	// Mainly used for auth 2.0 protocol dialogue to prevent CSRF attacks.
 	// can also be used to store redirect urls and any other state type variables.
 	State string `json:"state"`
@@ -0,0 +1,40 @@
+package pa
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Slugify returns a human-readable, url safe representation of s, ie: "My First Blog!" -> "my-first-blog".
+// returns an empty string if s doesent contain any letters or digits.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			// only write a dash between two words, never at the start.
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+
+		default: // anything else separates words.
+			dash = true
+		}
+	}
+
+	return b.String()
+}
+
+// IsValidSlug reports whether slug can be used as a slug.
+// numeric slugs are invalid as they would be mistaken for ids when resolving resources.
+func IsValidSlug(slug string) bool {
+	if slug == "" {
+		return false
+	}
+	_, err := strconv.Atoi(slug)
+	return err != nil
+}
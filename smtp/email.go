@@ -1,14 +1,29 @@
 package smtp
 
 import (
+	"context"
 	"net/smtp"
 
 	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/jordan-wright/email"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ pa.EmailService = (*EmailService)(nil)
 
+// tracer spans the sent emails.
+var tracer = otel.Tracer("github.com/Lambels/patrickarvatu.com/smtp")
+
+var emailCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "emails_sent_total",
+	Help: "total number of emails sent by result",
+}, []string{"result"})
+
 type EmailService struct {
 	auth smtp.Auth
 	addr string
@@ -23,11 +38,24 @@ func NewEmailService(addr, identity, username, password, host string) *EmailServ
 	}
 }
 
-func (e *EmailService) SendEmail(to []string, body, subject string) error {
+func (e *EmailService) SendEmail(ctx context.Context, to []string, body, subject string) error {
+	_, span := tracer.Start(ctx, "smtp.SendEmail",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("smtp.addr", e.addr), attribute.Int("smtp.recipients", len(to))),
+	)
+	defer span.End()
+
 	email := email.NewEmail()
 	email.Subject = subject
 	email.To = to
 	email.Text = []byte(body)
 
-	return email.Send(e.addr, e.auth)
+	if err := email.Send(e.addr, e.auth); err != nil {
+		emailCounter.WithLabelValues("failure").Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	emailCounter.WithLabelValues("success").Inc()
+	return nil
 }
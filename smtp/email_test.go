@@ -1 +1,57 @@
 package smtp_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Lambels/patrickarvatu.com/smtp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSendEmail(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	t.Run("Bad Send Call (Unreachable Server)", func(t *testing.T) {
+		// nothing listens on the address once the listener is closed.
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := l.Addr().String()
+		l.Close()
+
+		ctx, root := otel.Tracer("test").Start(context.Background(), "root")
+		s := smtp.NewEmailService(addr, "", "user", "pass", "127.0.0.1")
+		if err := s.SendEmail(ctx, []string{"jhon@doe.com"}, "body", "subject"); err == nil {
+			t.Fatal("expected send error")
+		}
+		root.End()
+
+		spans := recorder.Ended()
+		if len(spans) != 2 {
+			t.Fatalf("spans=%v", len(spans))
+		}
+		span := spans[0]
+		if span.Name() != "smtp.SendEmail" || span.SpanKind() != trace.SpanKindClient {
+			t.Fatalf("name=%v kind=%v", span.Name(), span.SpanKind())
+		} else if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Fatalf("parent id=%v", span.Parent().SpanID())
+		} else if span.Status().Code != codes.Error {
+			t.Fatalf("status=%v", span.Status())
+		}
+
+		// the recipients are counted, never recorded.
+		for _, attr := range span.Attributes() {
+			if strings.Contains(attr.Value.Emit(), "jhon@doe.com") {
+				t.Fatalf("attribute %v=%v", attr.Key, attr.Value.Emit())
+			}
+		}
+	})
+}
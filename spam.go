@@ -0,0 +1,112 @@
+package pa
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+// spam rule types.
+const (
+	// SpamRuleWord matches the comments containing the word, case insensitive.
+	SpamRuleWord = "word"
+
+	// SpamRuleRegex matches the comments matching the regular expression.
+	SpamRuleRegex = "regex"
+)
+
+// spam verdicts, the action taken on a comment based on its spam score.
+const (
+	SpamVerdictAccept = "accept"
+	SpamVerdictHold   = "hold"
+	SpamVerdictReject = "reject"
+)
+
+// SpamRule represents a banned word or regular expression, comments matching the rule get its score.
+type SpamRule struct {
+	// the pk of the rule.
+	ID int `json:"id"`
+
+	// the type of the rule, ie: SpamRuleRegex -> ./spam.go.
+	Type    string `json:"type"`
+	Pattern string `json:"pattern"`
+
+	// the spam score added to the matching comments.
+	Score int `json:"score"`
+
+	// timestamp.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate performs basic validation on the rule.
+// returns EINVALID if any error is found.
+func (r *SpamRule) Validate() error {
+	if r.Pattern == "" {
+		return Errorf(EINVALID, "pattern is a required field.")
+	}
+	if r.Score <= 0 {
+		return Errorf(EINVALID, "score must be positive.")
+	}
+
+	switch r.Type {
+	case SpamRuleWord:
+		return nil
+	case SpamRuleRegex:
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return Errorf(EINVALID, "invalid regex: %s.", err)
+		}
+		return nil
+	default:
+		return Errorf(EINVALID, "unknown spam rule type: %s.", r.Type)
+	}
+}
+
+// SpamService represents a service which manages the spam rules in the system.
+type SpamService interface {
+	// FindSpamRules returns all the spam rules, oldest first.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	FindSpamRules(ctx context.Context) ([]*SpamRule, error)
+
+	// CreateSpamRule creates a spam rule.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	CreateSpamRule(ctx context.Context, rule *SpamRule) error
+
+	// DeleteSpamRule permanently deletes a spam rule.
+	// returns ENOTFOUND if the rule doesent exist.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	DeleteSpamRule(ctx context.Context, id int) error
+}
+
+// SpamCheck represents a comment about to be created, checked by the spam checkers.
+type SpamCheck struct {
+	Comment *Comment
+
+	// the user creating the comment.
+	Author *User
+
+	// the value of the honeypot field of the form, left empty by humans.
+	Honeypot string
+}
+
+// SpamChecker represents a single check of the spam filter.
+type SpamChecker interface {
+	// CheckSpam returns the spam score of check and the reason of the score, 0 if the checker
+	// found nothing suspicious.
+	CheckSpam(ctx context.Context, check *SpamCheck) (score int, reason string, err error)
+}
+
+// SpamVerdict represents the outcome of the spam filter on a comment.
+type SpamVerdict struct {
+	// the action to take on the comment, ie: SpamVerdictHold -> ./spam.go.
+	Verdict string `json:"verdict"`
+
+	// the total score of the comment and the reasons of the checkers which scored it.
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// SpamFilter represents a service which decides on comments before they get created.
+type SpamFilter interface {
+	// FilterSpam runs check through the spam checkers and returns the verdict on the comment.
+	FilterSpam(ctx context.Context, check *SpamCheck) (*SpamVerdict, error)
+}
@@ -0,0 +1,150 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if the checkers implement set interface.
+var (
+	_ pa.SpamChecker = (*LinkChecker)(nil)
+	_ pa.SpamChecker = (*RuleChecker)(nil)
+	_ pa.SpamChecker = (*DuplicateChecker)(nil)
+	_ pa.SpamChecker = (*NewAccountChecker)(nil)
+	_ pa.SpamChecker = (*HoneypotChecker)(nil)
+)
+
+// linkRegex matches the links in a comment.
+var linkRegex = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// countLinks returns the number of links in content.
+func countLinks(content string) int {
+	return len(linkRegex.FindAllStringIndex(content, -1))
+}
+
+// LinkChecker scores the comments holding more then Max links, Score for each link over the limit.
+type LinkChecker struct {
+	Max   int
+	Score int
+}
+
+// CheckSpam scores the links of the comment over the limit.
+func (c *LinkChecker) CheckSpam(ctx context.Context, check *pa.SpamCheck) (int, string, error) {
+	n := countLinks(check.Comment.Content)
+	if n <= c.Max {
+		return 0, "", nil
+	}
+	return (n - c.Max) * c.Score, fmt.Sprintf("%d links over the limit of %d.", n-c.Max, c.Max), nil
+}
+
+// RuleChecker scores the comments matching the spam rules of Service.
+type RuleChecker struct {
+	Service pa.SpamService
+}
+
+// CheckSpam sums the scores of the rules the comment matches.
+func (c *RuleChecker) CheckSpam(ctx context.Context, check *pa.SpamCheck) (int, string, error) {
+	// the rules are admin only.
+	adminCtx := pa.NewContextWithUser(ctx, &pa.User{IsAdmin: true})
+	rules, err := c.Service.FindSpamRules(adminCtx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var score int
+	var matched []string
+	for _, rule := range rules {
+		pattern := rule.Pattern
+		if rule.Type == pa.SpamRuleWord {
+			// \b doesent hold around words starting or ending with symbols, ie: c++.
+			pattern = `(?i)(?:^|\W)` + regexp.QuoteMeta(pattern) + `(?:\W|$)`
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil { // validated on creation.
+			return 0, "", err
+		}
+		if re.MatchString(check.Comment.Content) {
+			score += rule.Score
+			matched = append(matched, rule.Pattern)
+		}
+	}
+
+	if score == 0 {
+		return 0, "", nil
+	}
+	return score, fmt.Sprintf("matches the banned rules: %s.", strings.Join(matched, ", ")), nil
+}
+
+// DuplicateChecker scores the comments repeating a comment of their author posted within Window.
+type DuplicateChecker struct {
+	Service pa.CommentService
+	Window  time.Duration
+	Score   int
+}
+
+// CheckSpam scores the comment if its author already posted the same content.
+func (c *DuplicateChecker) CheckSpam(ctx context.Context, check *pa.SpamCheck) (int, string, error) {
+	after := time.Now().Add(-c.Window)
+
+	// held comments count as well.
+	adminCtx := pa.NewContextWithUser(ctx, &pa.User{IsAdmin: true})
+	comments, _, err := c.Service.FindComments(adminCtx, pa.CommentFilter{
+		UserID:    &check.Author.ID,
+		QuerySpec: pa.QuerySpec{CreatedAfter: &after},
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	content := normalizeContent(check.Comment.Content)
+	for _, comment := range comments {
+		if normalizeContent(comment.Content) == content {
+			return c.Score, "duplicate of a recent comment.", nil
+		}
+	}
+	return 0, "", nil
+}
+
+// normalizeContent lowercases content and collapses its white space, small edits dont hide duplicates.
+func normalizeContent(content string) string {
+	return strings.Join(strings.Fields(strings.ToLower(content)), " ")
+}
+
+// NewAccountChecker scores the comments of accounts younger then MinAge, Score for the account and
+// LinkScore more if the comment holds links.
+type NewAccountChecker struct {
+	MinAge    time.Duration
+	Score     int
+	LinkScore int
+}
+
+// CheckSpam scores the comment if its author just signed up.
+func (c *NewAccountChecker) CheckSpam(ctx context.Context, check *pa.SpamCheck) (int, string, error) {
+	if time.Since(check.Author.CreatedAt) >= c.MinAge {
+		return 0, "", nil
+	}
+
+	if countLinks(check.Comment.Content) > 0 {
+		return c.Score + c.LinkScore, "new account posting links.", nil
+	}
+	return c.Score, "new account.", nil
+}
+
+// HoneypotChecker scores the comments which filled the honeypot field, only bots see the field.
+type HoneypotChecker struct {
+	Score int
+}
+
+// CheckSpam scores the comment if the honeypot field is set.
+func (c *HoneypotChecker) CheckSpam(ctx context.Context, check *pa.SpamCheck) (int, string, error) {
+	if check.Honeypot == "" {
+		return 0, "", nil
+	}
+	return c.Score, "honeypot field filled.", nil
+}
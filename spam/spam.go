@@ -0,0 +1,78 @@
+// Package spam implements the spam filter run on the comments before they get created, the filter
+// is a chain of checkers each scoring the comment.
+package spam
+
+import (
+	"context"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *Chain object implements set interface.
+var _ pa.SpamFilter = (*Chain)(nil)
+
+// default score thresholds of the chain.
+const (
+	DefaultHoldScore   = 5
+	DefaultRejectScore = 10
+)
+
+// default limits of the checkers.
+const (
+	DefaultMaxLinks        = 2
+	DefaultDuplicateWindow = 24 * time.Hour
+	DefaultNewAccountAge   = 24 * time.Hour
+)
+
+// Chain represents a spam filter summing the scores of its checkers.
+type Chain struct {
+	Checkers []pa.SpamChecker
+
+	// comments scoring at least HoldScore are held for approval and comments scoring at least
+	// RejectScore are rejected.
+	HoldScore   int
+	RejectScore int
+}
+
+// NewChain returns a new chain of checkers with the default thresholds.
+func NewChain(checkers ...pa.SpamChecker) *Chain {
+	return &Chain{
+		Checkers:    checkers,
+		HoldScore:   DefaultHoldScore,
+		RejectScore: DefaultRejectScore,
+	}
+}
+
+// FilterSpam runs check through each checker and returns the verdict on the comment, the chain
+// stops early once the comment is bound to be rejected.
+func (c *Chain) FilterSpam(ctx context.Context, check *pa.SpamCheck) (*pa.SpamVerdict, error) {
+	verdict := &pa.SpamVerdict{
+		Verdict: pa.SpamVerdictAccept,
+		Reasons: []string{},
+	}
+
+	for _, checker := range c.Checkers {
+		score, reason, err := checker.CheckSpam(ctx, check)
+		if err != nil {
+			return nil, err
+		} else if score == 0 {
+			continue
+		}
+
+		verdict.Score += score
+		verdict.Reasons = append(verdict.Reasons, reason)
+		if verdict.Score >= c.RejectScore {
+			break
+		}
+	}
+
+	switch {
+	case verdict.Score >= c.RejectScore:
+		verdict.Verdict = pa.SpamVerdictReject
+	case verdict.Score >= c.HoldScore:
+		verdict.Verdict = pa.SpamVerdictHold
+	}
+
+	return verdict, nil
+}
@@ -0,0 +1,199 @@
+package spam_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/spam"
+)
+
+// checkerFunc is a pa.SpamChecker calling itself.
+type checkerFunc func(ctx context.Context, check *pa.SpamCheck) (int, string, error)
+
+func (f checkerFunc) CheckSpam(ctx context.Context, check *pa.SpamCheck) (int, string, error) {
+	return f(ctx, check)
+}
+
+// scoring returns a checker scoring every comment with score.
+func scoring(score int, calls *int) pa.SpamChecker {
+	return checkerFunc(func(ctx context.Context, check *pa.SpamCheck) (int, string, error) {
+		*calls++
+		return score, "reason", nil
+	})
+}
+
+func TestChain(t *testing.T) {
+	check := &pa.SpamCheck{Comment: &pa.Comment{Content: "hello"}, Author: &pa.User{}}
+
+	t.Run("Ok Filter Call", func(t *testing.T) {
+		for _, tt := range []struct {
+			scores  []int
+			verdict string
+		}{
+			{[]int{0, 0}, pa.SpamVerdictAccept},
+			{[]int{2, 2}, pa.SpamVerdictAccept},
+			{[]int{3, 2}, pa.SpamVerdictHold},
+			{[]int{5, 5}, pa.SpamVerdictReject},
+		} {
+			var calls int
+			var checkers []pa.SpamChecker
+			for _, score := range tt.scores {
+				checkers = append(checkers, scoring(score, &calls))
+			}
+
+			verdict, err := spam.NewChain(checkers...).FilterSpam(context.Background(), check)
+			if err != nil {
+				t.Fatal(err)
+			} else if verdict.Verdict != tt.verdict {
+				t.Fatalf("scores=%v verdict=%+v", tt.scores, verdict)
+			}
+		}
+	})
+
+	t.Run("Ok Filter Call (Stop Early)", func(t *testing.T) {
+		var calls int
+		chain := spam.NewChain(scoring(spam.DefaultRejectScore, &calls), scoring(1, &calls))
+
+		if verdict, err := chain.FilterSpam(context.Background(), check); err != nil {
+			t.Fatal(err)
+		} else if verdict.Verdict != pa.SpamVerdictReject || len(verdict.Reasons) != 1 || calls != 1 {
+			t.Fatalf("verdict=%+v calls=%v", verdict, calls)
+		}
+	})
+}
+
+func TestLinkChecker(t *testing.T) {
+	c := &spam.LinkChecker{Max: 1, Score: 3}
+
+	for content, want := range map[string]int{
+		"no links":                                    0,
+		"see https://example.com":                     0,
+		"see https://a.com and www.b.com":             3,
+		"http://a.com http://b.com HTTPS://c.com/x?y": 6,
+	} {
+		check := &pa.SpamCheck{Comment: &pa.Comment{Content: content}}
+		if score, _, err := c.CheckSpam(context.Background(), check); err != nil {
+			t.Fatal(err)
+		} else if score != want {
+			t.Fatalf("content=%q score=%v", content, score)
+		}
+	}
+}
+
+// spamService is a pa.SpamService holding a fixed set of rules.
+type spamService struct {
+	pa.SpamService
+	rules []*pa.SpamRule
+}
+
+func (s *spamService) FindSpamRules(ctx context.Context) ([]*pa.SpamRule, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+	return s.rules, nil
+}
+
+func TestRuleChecker(t *testing.T) {
+	c := &spam.RuleChecker{Service: &spamService{rules: []*pa.SpamRule{
+		{Type: pa.SpamRuleWord, Pattern: "casino", Score: 4},
+		{Type: pa.SpamRuleWord, Pattern: "c++", Score: 1},
+		{Type: pa.SpamRuleRegex, Pattern: `\d{3}-\d{4}`, Score: 2},
+	}}}
+
+	for content, want := range map[string]int{
+		"nice post":                     0,
+		"visit our CASINO":              4,
+		"casinos are fine":              0,
+		"i like c++ and call 555-1234":  3,
+		"casino casino, call 555-1234.": 6,
+	} {
+		check := &pa.SpamCheck{Comment: &pa.Comment{Content: content}}
+		if score, _, err := c.CheckSpam(context.Background(), check); err != nil {
+			t.Fatal(err)
+		} else if score != want {
+			t.Fatalf("content=%q score=%v", content, score)
+		}
+	}
+}
+
+// commentService is a pa.CommentService holding a fixed set of comments.
+type commentService struct {
+	pa.CommentService
+	comments []*pa.Comment
+}
+
+func (s *commentService) FindComments(ctx context.Context, filter pa.CommentFilter) ([]*pa.Comment, int, error) {
+	var comments []*pa.Comment
+	for _, comment := range s.comments {
+		if filter.UserID != nil && comment.UserID != *filter.UserID {
+			continue
+		} else if filter.CreatedAfter != nil && !comment.CreatedAt.After(*filter.CreatedAfter) {
+			continue
+		}
+		comments = append(comments, comment)
+	}
+	return comments, len(comments), nil
+}
+
+func TestDuplicateChecker(t *testing.T) {
+	now := time.Now()
+	c := &spam.DuplicateChecker{Window: time.Hour, Score: 5, Service: &commentService{comments: []*pa.Comment{
+		{UserID: 1, Content: "Great   post!", CreatedAt: now.Add(-time.Minute)},
+		{UserID: 1, Content: "old comment", CreatedAt: now.Add(-2 * time.Hour)},
+		{UserID: 2, Content: "other user", CreatedAt: now},
+	}}}
+
+	for content, want := range map[string]int{
+		"great post!": 5,
+		"old comment": 0,
+		"other user":  0,
+		"new comment": 0,
+	} {
+		check := &pa.SpamCheck{Comment: &pa.Comment{Content: content}, Author: &pa.User{ID: 1}}
+		if score, _, err := c.CheckSpam(context.Background(), check); err != nil {
+			t.Fatal(err)
+		} else if score != want {
+			t.Fatalf("content=%q score=%v", content, score)
+		}
+	}
+}
+
+func TestNewAccountChecker(t *testing.T) {
+	c := &spam.NewAccountChecker{MinAge: time.Hour, Score: 2, LinkScore: 3}
+
+	for _, tt := range []struct {
+		age     time.Duration
+		content string
+		want    int
+	}{
+		{2 * time.Hour, "see https://a.com", 0},
+		{time.Minute, "hello", 2},
+		{time.Minute, "see https://a.com", 5},
+	} {
+		check := &pa.SpamCheck{
+			Comment: &pa.Comment{Content: tt.content},
+			Author:  &pa.User{CreatedAt: time.Now().Add(-tt.age)},
+		}
+		if score, _, err := c.CheckSpam(context.Background(), check); err != nil {
+			t.Fatal(err)
+		} else if score != tt.want {
+			t.Fatalf("age=%v content=%q score=%v", tt.age, tt.content, score)
+		}
+	}
+}
+
+func TestHoneypotChecker(t *testing.T) {
+	c := &spam.HoneypotChecker{Score: 10}
+
+	if score, _, err := c.CheckSpam(context.Background(), &pa.SpamCheck{}); err != nil {
+		t.Fatal(err)
+	} else if score != 0 {
+		t.Fatalf("score=%v", score)
+	} else if score, _, err := c.CheckSpam(context.Background(), &pa.SpamCheck{Honeypot: "http://spam.com"}); err != nil {
+		t.Fatal(err)
+	} else if score != 10 {
+		t.Fatalf("score=%v", score)
+	}
+}
@@ -29,7 +29,7 @@ func NewAuthService(db *DB) *AuthService {
 // FindAuthByID returns a auth based on the id.
 // returns ENOTFOUND if the auth doesent exist.
 func (s *AuthService) FindAuthByID(ctx context.Context, id int) (*pa.Auth, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +48,7 @@ func (s *AuthService) FindAuthByID(ctx context.Context, id int) (*pa.Auth, error
 
 // FindAuths returns a range of auth based on filter.
 func (s *AuthService) FindAuths(ctx context.Context, filter pa.AuthFilter) ([]*pa.Auth, int, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -0,0 +1,316 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *BackupService object implements set interface.
+var _ pa.BackupService = (*BackupService)(nil)
+
+// BackupService represents a service used to snapshot the database into a file service.
+type BackupService struct {
+	db *DB
+
+	// FileService stores the snapshots at its root, it should not be served.
+	FileService pa.FileService
+
+	// Retention prunes old snapshots after each backup.
+	Retention pa.BackupRetention
+}
+
+// NewBackupService returns a new instance of BackupService snapshotting db into fileService.
+func NewBackupService(db *DB, fileService pa.FileService) *BackupService {
+	return &BackupService{
+		db:          db,
+		FileService: fileService,
+	}
+}
+
+func (s *BackupService) FindBackups(ctx context.Context) ([]*pa.Backup, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	return findBackups(ctx, s.FileService)
+}
+
+// CreateBackup snapshots the database with VACUUM INTO, which reads a consistent snapshot without
+// blocking the writers, then stores the snapshot and prunes the backups outside of the retention.
+func (s *BackupService) CreateBackup(ctx context.Context) (*pa.Backup, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	dir, err := os.MkdirTemp("", "pa-backup-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir) // clean up the local snapshot.
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+	if err := s.vacuumInto(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("vacuum into: %w", err)
+	}
+
+	f, err := os.Open(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &pa.Backup{
+		Name:      s.db.Now().UTC().Format(pa.BackupNameFormat),
+		Size:      stat.Size(),
+		CreatedAt: s.db.Now().UTC().Truncate(time.Second),
+	}
+
+	if err := s.FileService.CreateFile(ctx, "/"+backup.Name, f); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordBackupAudit(ctx, backup); err != nil {
+		return nil, err
+	}
+
+	if err := s.prune(ctx); err != nil {
+		pa.LoggerFromContext(ctx).Error("prune backups failed.", "err", err)
+	}
+
+	return backup, nil
+}
+
+// recordBackupAudit records the creation of backup in the audit log.
+func (s *BackupService) recordBackupAudit(ctx context.Context, backup *pa.Backup) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetBackup, 0, nil, backup); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// vacuumInto writes a snapshot of the database to the file name. The snapshot is taken on its own
+// connection, the readers are query only and the writer would block the writes meanwhile.
+func (s *BackupService) vacuumInto(ctx context.Context, name string) error {
+	conn := s.db.db
+	if s.db.DSN != ":memory:" {
+		var err error
+		if conn, err = sql.Open("sqlite3", s.db.dsn("_txlock=deferred")); err != nil {
+			return err
+		}
+		defer conn.Close()
+	}
+
+	_, err := conn.ExecContext(ctx, `VACUUM INTO ?`, name)
+	return err
+}
+
+// prune deletes the backups outside of the retention, the latest backup is always kept.
+func (s *BackupService) prune(ctx context.Context) error {
+	backups, err := findBackups(ctx, s.FileService)
+	if err != nil {
+		return err
+	}
+
+	now := s.db.Now().UTC()
+	for i, backup := range backups {
+		if i == 0 {
+			continue
+		}
+
+		tooMany := s.Retention.KeepLast > 0 && i >= s.Retention.KeepLast
+		tooOld := s.Retention.MaxAge > 0 && now.Sub(backup.CreatedAt) > s.Retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err := s.FileService.DeleteFile(ctx, "/"+backup.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RestoreBackup replaces the database file at dsn with the backup name from fileService.
+// the snapshot gets integrity checked before replacing the database, the replaced database is kept
+// under "<dsn>.pre-restore". The database must not be in use while restoring.
+// returns ENOTFOUND if the backup doesent exist.
+// returns EINVALID if the name isnt a backup name or the snapshot is corrupt.
+func RestoreBackup(ctx context.Context, fileService pa.FileService, name, dsn string) error {
+	if _, err := time.Parse(pa.BackupNameFormat, path.Base(name)); err != nil {
+		return pa.Errorf(pa.EINVALID, "invalid backup name: %q.", name)
+	} else if dsn == "" || dsn == ":memory:" {
+		return fmt.Errorf("cannot restore into dsn: %q", dsn)
+	}
+
+	rc, _, err := fileService.OpenFile(ctx, "/"+path.Base(name))
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// download next to the database so the final rename stays on the same file system.
+	tmp := dsn + ".restore"
+	if err := os.MkdirAll(filepath.Dir(dsn), 0700); err != nil {
+		return err
+	}
+	if err := writeFileSync(tmp, rc); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	defer os.Remove(tmp) // no-op after the rename.
+
+	if err := checkIntegrity(ctx, tmp); err != nil {
+		return err
+	} else if err := recordRestoreAudit(ctx, tmp, path.Base(name)); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dsn); err == nil {
+		if err := os.Rename(dsn, dsn+".pre-restore"); err != nil {
+			return err
+		}
+	}
+
+	// the wal of the replaced database doesent belong to the snapshot.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dsn + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.Rename(tmp, dsn)
+}
+
+// recordRestoreAudit records the restore of the backup name in the audit log of the snapshot at dsn, the
+// restore runs without the server so it has no actor. Snapshots taken before the audit log existed
+// have nowhere to record it.
+func recordRestoreAudit(ctx context.Context, dsn, name string) error {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'audit_entries'`).Scan(&n); err != nil {
+		return err
+	} else if n == 0 {
+		return nil
+	}
+
+	diff, err := pa.NewAuditDiff(nil, map[string]interface{}{"name": name})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO audit_entries (
+			actor_id,
+			action,
+			target_type,
+			target_id,
+			diff,
+			ip,
+			user_agent,
+			created_at
+		)
+		VALUES(0, ?, ?, 0, ?, '', '', ?)
+	`,
+		pa.AuditActionRestore,
+		pa.AuditTargetBackup,
+		string(diff),
+		(*NullTime)(&now),
+	)
+	return err
+}
+
+// findBackups lists the backups in fileService, latest first.
+func findBackups(ctx context.Context, fileService pa.FileService) ([]*pa.Backup, error) {
+	files, err := fileService.List(ctx, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []*pa.Backup{}
+	for _, file := range files {
+		name := path.Base(file.Path)
+		if "/"+name != file.Path {
+			continue // backups are stored at the root.
+		}
+
+		createdAt, err := time.Parse(pa.BackupNameFormat, name)
+		if err != nil {
+			continue // not a backup.
+		}
+
+		backups = append(backups, &pa.Backup{
+			Name:      name,
+			Size:      file.Size,
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// checkIntegrity runs the sqlite integrity check on the database file at name.
+func checkIntegrity(ctx context.Context, name string) error {
+	db, err := sql.Open("sqlite3", name)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRowContext(ctx, `PRAGMA integrity_check;`).Scan(&result); err != nil {
+		return pa.Errorf(pa.EINVALID, "corrupt backup: %v.", err)
+	} else if result != "ok" {
+		return pa.Errorf(pa.EINVALID, "corrupt backup: %s.", result)
+	}
+
+	return nil
+}
+
+// writeFileSync writes r to the file name and syncs it to disk.
+func writeFileSync(name string, r io.Reader) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	} else if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,161 @@
+package sqlite_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/fs"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestCreateBackup(t *testing.T) {
+	db := MustOpenTempDB(t)
+	defer MustCloseDB(t, db)
+
+	fileService := fs.NewFileService(t.TempDir())
+	backupService := sqlite.NewBackupService(db, fileService)
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	t.Run("Ok Create Call", func(t *testing.T) {
+		backup, err := backupService.CreateBackup(adminUsrCtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if backup.Size == 0 || !strings.HasSuffix(backup.Name, ".db") {
+			t.Fatalf("backup=%+v", backup)
+		}
+
+		if backups, err := backupService.FindBackups(adminUsrCtx); err != nil {
+			t.Fatal(err)
+		} else if len(backups) != 1 || backups[0].Name != backup.Name || backups[0].Size != backup.Size {
+			t.Fatalf("backups=%v", backups)
+		}
+
+		MustFindBackupAudit(t, db, pa.AuditActionCreate, backup.Name)
+	})
+
+	t.Run("Ok Create Call (Retention)", func(t *testing.T) {
+		backupService.Retention = pa.BackupRetention{KeepLast: 2}
+		defer func() { db.Now = time.Now }()
+
+		for i := 1; i <= 3; i++ {
+			now := time.Now().Add(time.Duration(i) * time.Hour)
+			db.Now = func() time.Time { return now }
+
+			if _, err := backupService.CreateBackup(adminUsrCtx); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		backups, err := backupService.FindBackups(adminUsrCtx)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(backups) != 2 || !backups[0].CreatedAt.After(backups[1].CreatedAt) {
+			t.Fatalf("backups=%v", backups)
+		}
+
+		// age based retention keeps the latest backup.
+		backupService.Retention = pa.BackupRetention{MaxAge: time.Minute}
+		now := time.Now().Add(24 * time.Hour)
+		db.Now = func() time.Time { return now }
+
+		if _, err := backupService.CreateBackup(adminUsrCtx); err != nil {
+			t.Fatal(err)
+		} else if backups, err := backupService.FindBackups(adminUsrCtx); err != nil {
+			t.Fatal(err)
+		} else if len(backups) != 1 {
+			t.Fatalf("backups=%v", backups)
+		}
+	})
+
+	t.Run("Bad Create Call (Unauthorized)", func(t *testing.T) {
+		if _, err := backupService.CreateBackup(context.Background()); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatal("err != EUNAUTHORIZED")
+		} else if _, err := backupService.FindBackups(context.Background()); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatal("err != EUNAUTHORIZED")
+		}
+	})
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "db")
+
+	db := MustOpenDB(t, dsn)
+	fileService := fs.NewFileService(filepath.Join(dir, "backups"))
+	backupService := sqlite.NewBackupService(db, fileService)
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+
+	MustCreateBlog(t, db, adminUsrCtx, &pa.Blog{Title: "Before", Description: "foo"})
+	backup, err := backupService.CreateBackup(adminUsrCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	MustCreateBlog(t, db, adminUsrCtx, &pa.Blog{Title: "After", Description: "foo"})
+	MustCloseDB(t, db)
+
+	t.Run("Ok Restore Call", func(t *testing.T) {
+		if err := sqlite.RestoreBackup(context.Background(), fileService, backup.Name, dsn); err != nil {
+			t.Fatal(err)
+		}
+
+		db := MustOpenDB(t, dsn)
+		defer MustCloseDB(t, db)
+
+		if _, n, err := sqlite.NewBlogService(db).FindBlogs(context.Background(), pa.BlogFilter{}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 {
+			t.Fatalf("n=%v", n)
+		}
+
+		// the replaced database is kept.
+		if _, err := os.Stat(dsn + ".pre-restore"); err != nil {
+			t.Fatal(err)
+		}
+
+		// the restored database records its restore.
+		MustFindBackupAudit(t, db, pa.AuditActionRestore, backup.Name)
+	})
+
+	t.Run("Bad Restore Call", func(t *testing.T) {
+		// invalid name.
+		if err := sqlite.RestoreBackup(context.Background(), fileService, "../db", dsn); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// not found.
+		if err := sqlite.RestoreBackup(context.Background(), fileService, "20000101T000000Z.db", dsn); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+
+		// corrupt.
+		if err := fileService.CreateFile(adminUsrCtx, "/20000101T000000Z.db", strings.NewReader("FOO BAR")); err != nil {
+			t.Fatal(err)
+		}
+		if err := sqlite.RestoreBackup(context.Background(), fileService, "20000101T000000Z.db", dsn); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatalf("err=%v", err)
+		}
+	})
+}
+
+// MustFindBackupAudit asserts that the latest backup audit entry of db records action on the backup name.
+func MustFindBackupAudit(tb testing.TB, db *sqlite.DB, action, name string) {
+	tb.Helper()
+
+	adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{IsAdmin: true})
+	targetType := pa.AuditTargetBackup
+	entries, _, err := sqlite.NewAuditService(db).FindAuditEntries(adminUsrCtx, pa.AuditFilter{TargetType: &targetType})
+	if err != nil {
+		tb.Fatal(err)
+	} else if len(entries) == 0 || entries[0].Action != action || !strings.Contains(string(entries[0].Diff), name) {
+		tb.Fatalf("entries=%v", entries)
+	}
+}
@@ -400,7 +400,272 @@ func TestFindBlogs(t *testing.T) {
 	})
 }
 
-func MustCreateBlog(t *testing.T, db *sqlite.DB, ctx context.Context, blog *pa.Blog) {
+func TestFindBlogBySlug(t *testing.T) {
+	t.Run("Ok Find Call (unique slugs)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		backgroundCtx := context.Background()
+
+		blogService := sqlite.NewBlogService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as CreateBlog doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		blog := &pa.Blog{
+			Title: "Epic Blog",
+		}
+		blog2 := &pa.Blog{
+			Title: "Epic Blog!",
+		}
+		blog3 := &pa.Blog{
+			Title: "2022",
+		}
+
+		// create blogs.
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+		MustCreateBlog(t, db, adminUsrCtx, blog2)
+		MustCreateBlog(t, db, adminUsrCtx, blog3)
+
+		// assert slugs.
+		if blog.Slug != "epic-blog" {
+			t.Fatalf("slug=%v != epic-blog", blog.Slug)
+		} else if blog2.Slug != "epic-blog-2" {
+			t.Fatalf("slug=%v != epic-blog-2", blog2.Slug)
+		} else if blog3.Slug != "2022-2" { // numeric slugs arent allowed.
+			t.Fatalf("slug=%v != 2022-2", blog3.Slug)
+		}
+
+		// find blog by slug.
+		if gotBlog, err := blogService.FindBlogBySlug(backgroundCtx, blog2.Slug); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(gotBlog, blog2) {
+			t.Fatal("DeepEqual: gotBlog != blog2")
+		}
+	})
+
+	t.Run("Ok Find Call (renamed blog)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		backgroundCtx := context.Background()
+
+		blogService := sqlite.NewBlogService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as UpdateBlog doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		blog := &pa.Blog{
+			Title: "Epic Blog",
+		}
+
+		// create blog.
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		// rename blog.
+		if updatedBlog, err := blogService.UpdateBlog(adminUsrCtx, blog.ID, pa.BlogUpdate{Title: NewStringPointer("Bad Blog")}); err != nil {
+			t.Fatal(err)
+		} else if updatedBlog.Slug != "bad-blog" {
+			t.Fatalf("slug=%v != bad-blog", updatedBlog.Slug)
+		}
+
+		// old slug still resolves.
+		if gotBlog, err := blogService.FindBlogBySlug(backgroundCtx, "epic-blog"); err != nil {
+			t.Fatal(err)
+		} else if gotBlog.ID != blog.ID {
+			t.Fatalf("id=%v != %v", gotBlog.ID, blog.ID)
+		} else if gotBlog.Slug != "bad-blog" {
+			t.Fatalf("slug=%v != bad-blog", gotBlog.Slug)
+		}
+
+		// old slug cant be taken by another blog.
+		blog2 := &pa.Blog{
+			Title: "Epic Blog",
+		}
+		MustCreateBlog(t, db, adminUsrCtx, blog2)
+
+		if blog2.Slug != "epic-blog-2" {
+			t.Fatalf("slug=%v != epic-blog-2", blog2.Slug)
+		}
+
+		// rename blog back to its old title, reclaiming the old slug.
+		if updatedBlog, err := blogService.UpdateBlog(adminUsrCtx, blog.ID, pa.BlogUpdate{Title: NewStringPointer("Epic-Blog")}); err != nil {
+			t.Fatal(err)
+		} else if updatedBlog.Slug != "epic-blog" {
+			t.Fatalf("slug=%v != epic-blog", updatedBlog.Slug)
+		}
+
+		if gotBlog, err := blogService.FindBlogBySlug(backgroundCtx, "bad-blog"); err != nil {
+			t.Fatal(err)
+		} else if gotBlog.Slug != "epic-blog" {
+			t.Fatalf("slug=%v != epic-blog", gotBlog.Slug)
+		}
+	})
+
+	t.Run("Bad Find Call (Not Found)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		blogService := sqlite.NewBlogService(db)
+
+		// find blog (Not Found).
+		if _, err := blogService.FindBlogBySlug(context.Background(), "epic-blog"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+	})
+}
+
+func TestReorderSubBlogs(t *testing.T) {
+	t.Run("Ok Reorder Call", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		backgroundCtx := context.Background()
+
+		blogService := sqlite.NewBlogService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as ReorderSubBlogs doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		blog := &pa.Blog{
+			Title: "Epic Blog",
+		}
+
+		// create blog.
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		// create sub blogs.
+		var ids []int
+		for _, title := range []string{"one", "two", "three"} {
+			subBlog := &pa.SubBlog{
+				BlogID:  blog.ID,
+				Title:   title,
+				Content: "some content",
+			}
+			MustCreateSubBlog(t, db, adminUsrCtx, subBlog)
+			ids = append(ids, subBlog.ID)
+		}
+
+		// reorder sub blogs: three, one, two.
+		gotBlog, err := blogService.ReorderSubBlogs(adminUsrCtx, blog.ID, []int{ids[2], ids[0], ids[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// assert order and navigation.
+		for i, id := range []int{ids[2], ids[0], ids[1]} {
+			if gotBlog.SubBlogs[i].ID != id {
+				t.Fatalf("sub blog %d: id=%v != %v", i, gotBlog.SubBlogs[i].ID, id)
+			} else if gotBlog.SubBlogs[i].Position != i+1 {
+				t.Fatalf("sub blog %d: position=%v != %v", i, gotBlog.SubBlogs[i].Position, i+1)
+			}
+		}
+		if gotBlog.SubBlogs[0].Prev != nil {
+			t.Fatal("first sub blog has prev")
+		} else if gotBlog.SubBlogs[1].Prev.ID != ids[2] || gotBlog.SubBlogs[1].Next.ID != ids[1] {
+			t.Fatal("second sub blog navigation mismatch")
+		} else if gotBlog.SubBlogs[2].Next != nil {
+			t.Fatal("last sub blog has next")
+		}
+	})
+
+	t.Run("Bad Reorder Call (Invalid)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		backgroundCtx := context.Background()
+
+		blogService := sqlite.NewBlogService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as ReorderSubBlogs doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		blog := &pa.Blog{
+			Title: "Epic Blog",
+		}
+
+		// create blog.
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		subBlog := &pa.SubBlog{
+			BlogID:  blog.ID,
+			Title:   "one",
+			Content: "some content",
+		}
+		subBlog2 := &pa.SubBlog{
+			BlogID:  blog.ID,
+			Title:   "two",
+			Content: "some content",
+		}
+
+		// create sub blogs.
+		MustCreateSubBlog(t, db, adminUsrCtx, subBlog)
+		MustCreateSubBlog(t, db, adminUsrCtx, subBlog2)
+
+		// missing sub blog.
+		if _, err := blogService.ReorderSubBlogs(adminUsrCtx, blog.ID, []int{subBlog.ID}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// duplicated sub blog.
+		if _, err := blogService.ReorderSubBlogs(adminUsrCtx, blog.ID, []int{subBlog.ID, subBlog.ID}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+
+	t.Run("Bad Reorder Call (Un Auth)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		backgroundCtx := context.Background()
+
+		blogService := sqlite.NewBlogService(db)
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		})
+		usrCtx := pa.NewContextWithUser(backgroundCtx, &pa.User{
+			Name:  "Lambels",
+			Email: "Lamb@Lambels.com",
+		})
+
+		blog := &pa.Blog{
+			Title: "Epic Blog",
+		}
+
+		// create blog.
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		// reorder sub blogs (Un Auth).
+		if _, err := blogService.ReorderSubBlogs(usrCtx, blog.ID, []int{}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatal("err != EUNAUTHORIZED")
+		}
+	})
+}
+
+func MustCreateBlog(t testing.TB, db *sqlite.DB, ctx context.Context, blog *pa.Blog) {
 	t.Helper()
 	if err := sqlite.NewBlogService(db).CreateBlog(ctx, blog); err != nil {
 		t.Fatal(err)
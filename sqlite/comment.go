@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"strings"
 
 	pa "github.com/Lambels/patrickarvatu.com"
@@ -25,7 +26,7 @@ func NewCommentService(db *DB) *CommentService {
 // FindCommentByID returns a comment based on id.
 // returns ENOTFOUND if the comment doesent exist.
 func (s *CommentService) FindCommentByID(ctx context.Context, id int) (*pa.Comment, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, err
 	}
@@ -35,7 +36,7 @@ func (s *CommentService) FindCommentByID(ctx context.Context, id int) (*pa.Comme
 	if err != nil {
 		return nil, err
 
-	} else if err := attachUserToComment(ctx, tx, comment); err != nil {
+	} else if err := attachUsersToComments(ctx, tx, []*pa.Comment{comment}); err != nil {
 		return nil, err
 	}
 
@@ -44,7 +45,7 @@ func (s *CommentService) FindCommentByID(ctx context.Context, id int) (*pa.Comme
 
 // FindComments returns a range of comment based on filter.
 func (s *CommentService) FindComments(ctx context.Context, filter pa.CommentFilter) ([]*pa.Comment, int, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -55,8 +56,9 @@ func (s *CommentService) FindComments(ctx context.Context, filter pa.CommentFilt
 		return comments, n, err
 	}
 
-	for _, comment := range comments {
-		if err := attachUserToComment(ctx, tx, comment); err != nil {
+	// attach the users of all the comments at once, only if included.
+	if filter.IncludeUser {
+		if err := attachUsersToComments(ctx, tx, comments); err != nil {
 			return comments, n, err
 		}
 	}
@@ -75,7 +77,7 @@ func (s *CommentService) CreateComment(ctx context.Context, comment *pa.Comment)
 	if err := createComment(ctx, tx, comment); err != nil {
 		return err
 
-	} else if err := attachUserToComment(ctx, tx, comment); err != nil {
+	} else if err := attachUsersToComments(ctx, tx, []*pa.Comment{comment}); err != nil {
 		return err
 	}
 
@@ -96,15 +98,15 @@ func (s *CommentService) UpdateComment(ctx context.Context, id int, update pa.Co
 	if err != nil {
 		return nil, err
 
-	} else if err := attachUserToComment(ctx, tx, comment); err != nil {
+	} else if err := attachUsersToComments(ctx, tx, []*pa.Comment{comment}); err != nil {
 		return nil, err
 	}
 
 	return comment, tx.Commit()
 }
 
-// DeleteComment permanently deletes the comment specified by id.
-// returns EUNAUTHORIZED if the user isnt trying to delete his own comment.
+// DeleteComment moves the comment specified by id to the trash.
+// returns EUNAUTHORIZED if the user isnt trying to delete his own comment and isnt the admin user.
 // returns ENOTFOUND if the comment doesent exist.
 func (s *CommentService) DeleteComment(ctx context.Context, id int) error {
 	tx, err := s.db.BeginTX(ctx, nil)
@@ -135,6 +137,12 @@ func findCommentByID(ctx context.Context, tx *Tx, id int) (*pa.Comment, error) {
 	return comments[0], nil
 }
 
+// commentSortFields maps the sortable fields of pa.CommentFilter to their column.
+var commentSortFields = map[string]string{
+	"id":        "id",
+	"createdAt": "created_at",
+}
+
 func findComments(ctx context.Context, tx *Tx, filter pa.CommentFilter) (_ []*pa.Comment, n int, err error) {
 	// build where and args statement method.
 	// not vulnerable to sql injection attack.
@@ -153,18 +161,63 @@ func findComments(ctx context.Context, tx *Tx, filter pa.CommentFilter) (_ []*pa
 		args = append(args, *v)
 	}
 
+	// held comments are only visible to the admin user and to their author.
+	if v := filter.Status; v != nil {
+		if *v != pa.CommentStatusApproved && !pa.IsAdminContext(ctx) {
+			return nil, 0, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+		}
+		where = append(where, "status = ?")
+		args = append(args, *v)
+	} else if !pa.IsAdminContext(ctx) {
+		where = append(where, "(status = ? OR user_id = ?)")
+		args = append(args, pa.CommentStatusApproved, pa.UserIDFromContext(ctx))
+	}
+
+	where, args, order, err := formatQuerySpec(filter.QuerySpec, commentSortFields, "id ASC", where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	offset := filter.Offset
+	if v := filter.Cursor; v != nil {
+		if filter.SortBy != "" {
+			return nil, 0, pa.Errorf(pa.EINVALID, "sorting cant be combined with a cursor.")
+		}
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
+
+	comments, n, err := queryComments(ctx, tx, where, args, order, FormatLimitOffset(filter.Limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+			comments[i], comments[j] = comments[j], comments[i]
+		}
+	}
+
+	return comments, n, nil
+}
+
+// queryComments returns the comments matching all the where conditions in order.
+func queryComments(ctx context.Context, tx *Tx, where []string, args []interface{}, order, limitOffset string) (_ []*pa.Comment, n int, err error) {
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
 			id,
 			sub_blog_id,
 			user_id,
 			content,
+			status,
 			created_at,
 			COUNT(*) OVER()
 		FROM comments
-		WHERE `+strings.Join(where, " AND ")+`
-		ORDER BY id ASC
-		`+FormatLimitOffset(filter.Limit, filter.Offset)+`
+		WHERE `+liveComments+` AND `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+limitOffset+`
 	`,
 		args...,
 	)
@@ -172,6 +225,7 @@ func findComments(ctx context.Context, tx *Tx, filter pa.CommentFilter) (_ []*pa
 	if err != nil {
 		return nil, n, err
 	}
+	defer rows.Close()
 
 	// deserialize rows.
 	comments := []*pa.Comment{}
@@ -183,6 +237,7 @@ func findComments(ctx context.Context, tx *Tx, filter pa.CommentFilter) (_ []*pa
 			&comment.SubBlogID,
 			&comment.UserID,
 			&comment.Content,
+			&comment.Status,
 			(*NullTime)(&comment.CreatedAt),
 			&n,
 		); err != nil {
@@ -201,23 +256,35 @@ func findComments(ctx context.Context, tx *Tx, filter pa.CommentFilter) (_ []*pa
 func createComment(ctx context.Context, tx *Tx, comment *pa.Comment) error {
 	comment.UserID = pa.UserIDFromContext(ctx)
 	comment.CreatedAt = tx.now
+	if comment.Status == "" {
+		comment.Status = pa.CommentStatusApproved
+	}
 
 	if err := comment.Validate(); err != nil {
 		return err
 	}
 
+	// banned users cant comment, whatever the scope of the ban.
+	if ban, err := findActiveBan(ctx, tx, comment.UserID); err == nil {
+		return pa.Errorf(pa.EFORBIDDEN, "user is banned: %s.", ban.Reason)
+	} else if pa.ErrorCode(err) != pa.ENOTFOUND {
+		return err
+	}
+
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO comments (
 			sub_blog_id,
 			user_id,
 			content,
+			status,
 			created_at
 		)
-		VALUES(?, ?, ?, ?)
+		VALUES(?, ?, ?, ?, ?)
 	`,
 		comment.SubBlogID,
 		comment.UserID,
 		comment.Content,
+		comment.Status,
 		(*NullTime)(&comment.CreatedAt),
 	)
 
@@ -244,10 +311,14 @@ func updateComment(ctx context.Context, tx *Tx, id int, update pa.CommentUpdate)
 	if err != nil {
 		return nil, err
 	}
+	before := *comment
 
 	if v := update.Content; v != nil {
 		comment.Content = *v
 	}
+	if v := update.Status; v != nil {
+		comment.Status = *v
+	}
 
 	if err := comment.Validate(); err != nil {
 		return comment, err
@@ -255,15 +326,21 @@ func updateComment(ctx context.Context, tx *Tx, id int, update pa.CommentUpdate)
 
 	if _, err := tx.ExecContext(ctx, `
 		UPDATE comments
-		SET content = ?
+		SET content = ?,
+		    status = ?
 		WHERE id = ?
 	`,
 		comment.Content,
+		comment.Status,
 		id,
 	); err != nil {
 		return nil, err
 	}
 
+	if err := recordAudit(ctx, tx, pa.AuditActionUpdate, pa.AuditTargetComment, id, &before, comment); err != nil {
+		return nil, err
+	}
+
 	return comment, nil
 }
 
@@ -273,23 +350,41 @@ func deleteComment(ctx context.Context, tx *Tx, id int) error {
 		return err
 	}
 
-	if comment.UserID != pa.UserIDFromContext(ctx) {
+	if comment.UserID != pa.UserIDFromContext(ctx) && !(pa.IsAdminContext(ctx) && comment.Status == pa.CommentStatusHeld) {
 		return pa.Errorf(pa.EUNAUTHORIZED, "user cant delete comment")
 	}
 
-	if _, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE id = ?`, id); err != nil {
+	if _, err := tx.ExecContext(ctx, `UPDATE comments SET deleted_at = ? WHERE id = ?`, (*NullTime)(&tx.now), id); err != nil {
 		return err
 	}
 
-	return nil
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetComment, id, comment, nil)
 }
 
-func attachUserToComment(ctx context.Context, tx *Tx, comment *pa.Comment) error {
-	user, err := findUserByID(ctx, tx, comment.UserID)
+// attachUsersToComments attaches the user owning each comment, the users are loaded at once.
+func attachUsersToComments(ctx context.Context, tx *Tx, comments []*pa.Comment) error {
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.UserID
+	}
+
+	cond, args := formatIn("id", ids)
+	users, _, err := queryUsers(ctx, tx, []string{cond}, args, "")
 	if err != nil {
 		return err
 	}
 
-	comment.User = user
+	byID := make(map[int]*pa.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	for _, comment := range comments {
+		user, ok := byID[comment.UserID]
+		if !ok {
+			return pa.Errorf(pa.ENOTFOUND, "user not found.")
+		}
+		comment.User = user
+	}
 	return nil
 }
@@ -0,0 +1,189 @@
+package sqlite_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestConcurrentReadWrite(t *testing.T) {
+	db := MustOpenTempDB(t)
+	defer MustCloseDB(t, db)
+
+	srv, client := MustOpenLoadServer(t, db)
+
+	// writers and readers racing each other must never see SQLITE_BUSY.
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				path := "/blogs"
+				if i%2 == 0 {
+					path = "/comments"
+				}
+				if err := doLoadRequest(client, srv.URL+path, j%2 == 0 && path == "/comments"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if _, n, err := sqlite.NewCommentService(db).FindComments(context.Background(), pa.CommentFilter{}); err != nil {
+		t.Fatal(err)
+	} else if n != 16*5 {
+		t.Fatalf("n=%v", n)
+	}
+}
+
+// BenchmarkHTTPLoad measures the throughput of read heavy, mixed and write heavy http traffic
+// with a small and a large reader pool.
+func BenchmarkHTTPLoad(b *testing.B) {
+	for _, readConns := range []int{1, 8} {
+		for _, bench := range []struct {
+			name       string
+			writeEvery int64 // every nth request is a write, 0 for none.
+		}{
+			{"Reads", 0},
+			{"Mixed", 10},
+			{"Writes", 1},
+		} {
+			b.Run(fmt.Sprintf("%s/readers=%d", bench.name, readConns), func(b *testing.B) {
+				db := sqlite.NewDB(MustTempDSN(b))
+				db.MaxReadConns = readConns
+				if err := db.Open(); err != nil {
+					b.Fatal(err)
+				}
+				defer MustCloseDB(b, db)
+
+				srv, client := MustOpenLoadServer(b, db)
+
+				var n int64
+				b.SetParallelism(8) // simulate more concurrent clients than cores.
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						i := atomic.AddInt64(&n, 1)
+
+						write := bench.writeEvery > 0 && i%bench.writeEvery == 0
+						path := "/blogs"
+						if write || i%2 == 0 {
+							path = "/comments"
+						}
+
+						if err := doLoadRequest(client, srv.URL+path, write); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// MustOpenLoadServer seeds db and serves blog and comment endpoints on top of it.
+func MustOpenLoadServer(tb testing.TB, db *sqlite.DB) (*httptest.Server, *http.Client) {
+	tb.Helper()
+
+	adminUsrCtx := MustCreateUser(tb, db, context.Background(), &pa.User{
+		Name:    "Lambels",
+		Email:   "lamb@lambels.com",
+		IsAdmin: true,
+	})
+
+	blog := &pa.Blog{Title: "Cool Title", Description: "Idk man"}
+	MustCreateBlog(tb, db, adminUsrCtx, blog)
+
+	subBlog := &pa.SubBlog{BlogID: blog.ID, Title: "Cool Sub blog", Content: "idk"}
+	MustCreateSubBlog(tb, db, adminUsrCtx, subBlog)
+
+	blogService := sqlite.NewBlogService(db)
+	commentService := sqlite.NewCommentService(db)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blogs", func(w http.ResponseWriter, r *http.Request) {
+		blogs, _, err := blogService.FindBlogs(r.Context(), pa.BlogFilter{Limit: 20})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(blogs)
+	})
+	mux.HandleFunc("/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			comment := &pa.Comment{SubBlogID: subBlog.ID, Content: "Cool content"}
+			if err := commentService.CreateComment(adminUsrCtx, comment); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		comments, _, err := commentService.FindComments(r.Context(), pa.CommentFilter{SubBlogID: &subBlog.ID, Limit: 20})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(comments)
+	})
+
+	srv := httptest.NewServer(mux)
+	tb.Cleanup(srv.Close)
+
+	// keep the connections alive, the default 2 idle connections per host run out of ports under load.
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 256}}
+	tb.Cleanup(client.CloseIdleConnections)
+
+	return srv, client
+}
+
+// MustTempDSN returns the path of a database in a temp dir removed on cleanup.
+func MustTempDSN(tb testing.TB) string {
+	tb.Helper()
+	return tb.TempDir() + "/db"
+}
+
+func doLoadRequest(client *http.Client, url string, write bool) error {
+	method := http.MethodGet
+	if write {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequest(method, url, strings.NewReader(""))
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %d %s", method, url, resp.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/Lambels/patrickarvatu.com/conformance"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) *conformance.Services {
+		db := MustOpenTempDB(t)
+		t.Cleanup(func() { MustCloseDB(t, db) })
+
+		return &conformance.Services{
+			AuthService:         sqlite.NewAuthService(db),
+			UserService:         sqlite.NewUserService(db),
+			BlogService:         sqlite.NewBlogService(db),
+			SubBlogService:      sqlite.NewSubBlogService(db),
+			CommentService:      sqlite.NewCommentService(db),
+			SubscriptionService: sqlite.NewSubscriptionService(db),
+			ProjectService:      sqlite.NewProjectService(db),
+			MediaService:        sqlite.NewMediaService(db),
+			TrashService:        sqlite.NewTrashService(db),
+			AuditService:        sqlite.NewAuditService(db),
+			SpamService:         sqlite.NewSpamService(db),
+			BanService:          sqlite.NewBanService(db),
+		}
+	})
+}
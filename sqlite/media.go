@@ -0,0 +1,628 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *MediaService object implements set interface.
+var _ pa.MediaService = (*MediaService)(nil)
+
+// MediaService represents a service used to manage the media library.
+type MediaService struct {
+	db *DB
+}
+
+// NewMediaService returns a new instance of MediaService attached to db.
+func NewMediaService(db *DB) *MediaService {
+	return &MediaService{
+		db: db,
+	}
+}
+
+func (s *MediaService) FindMediaByID(ctx context.Context, id int) (*pa.Media, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findMediaByID(ctx, tx, id)
+}
+
+func (s *MediaService) FindMedia(ctx context.Context, filter pa.MediaFilter) ([]*pa.Media, int, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	return findMedia(ctx, tx, filter)
+}
+
+func (s *MediaService) CreateMedia(ctx context.Context, media *pa.Media) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createMedia(ctx, tx, media); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *MediaService) UpdateMedia(ctx context.Context, id int, update pa.MediaUpdate) (*pa.Media, error) {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	media, err := updateMedia(ctx, tx, id, update)
+	if err != nil {
+		return media, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+func (s *MediaService) DeleteMedia(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteMedia(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *MediaService) CreateMediaRef(ctx context.Context, id int, ref pa.MediaRef) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createMediaRef(ctx, tx, id, ref); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *MediaService) DeleteMediaRef(ctx context.Context, id int, ref pa.MediaRef) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteMediaRef(ctx, tx, id, ref); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findMediaByID is a helper function to interface with findMedia and returns ENOTFOUND
+// if media doesent exist.
+func findMediaByID(ctx context.Context, tx *Tx, id int) (*pa.Media, error) {
+	filter := pa.MediaFilter{
+		ID: &id,
+	}
+
+	media, _, err := findMedia(ctx, tx, filter)
+	if err != nil {
+		return nil, err
+	} else if len(media) == 0 {
+		return nil, pa.Errorf(pa.ENOTFOUND, "media not found.")
+	}
+
+	return media[0], nil
+}
+
+func findMedia(ctx context.Context, tx *Tx, filter pa.MediaFilter) (_ []*pa.Media, n int, err error) {
+	// build where and args statement method.
+	// not vulnerable to sql injection attack.
+	where, args := []string{"1 = 1"}, []interface{}{}
+
+	if v := filter.ID; v != nil {
+		where = append(where, "id = ?")
+		args = append(args, *v)
+	}
+	if v := filter.Hash; v != nil {
+		where = append(where, "hash = ?")
+		args = append(args, *v)
+	}
+	if v := filter.BlogID; v != nil {
+		where = append(where, "id IN (SELECT media_id FROM blog_media WHERE blog_id = ?)")
+		args = append(args, *v)
+	}
+	if v := filter.SubBlogID; v != nil {
+		where = append(where, "id IN (SELECT media_id FROM sub_blog_media WHERE sub_blog_id = ?)")
+		args = append(args, *v)
+	}
+	if v := filter.ProjectID; v != nil {
+		where = append(where, "id IN (SELECT media_id FROM project_media WHERE project_id = ?)")
+		args = append(args, *v)
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	order, offset := "id ASC", filter.Offset
+	if v := filter.Cursor; v != nil {
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
+
+	media, n, err := queryMedia(ctx, tx, where, args, order, FormatLimitOffset(filter.Limit, offset))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(media)-1; i < j; i, j = i+1, j-1 {
+			media[i], media[j] = media[j], media[i]
+		}
+	}
+
+	return media, n, nil
+}
+
+// queryMedia returns the media matching all the where conditions in order with their variants.
+func queryMedia(ctx context.Context, tx *Tx, where []string, args []interface{}, order, limitOffset string) (_ []*pa.Media, n int, err error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			hash,
+			name,
+			mime_type,
+			size,
+			width,
+			height,
+			alt_text,
+			(SELECT COUNT(*) FROM blog_media WHERE media_id = media.id) +
+			(SELECT COUNT(*) FROM sub_blog_media WHERE media_id = media.id) +
+			(SELECT COUNT(*) FROM project_media WHERE media_id = media.id),
+			created_at,
+			COUNT(*) OVER()
+		FROM media
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+order+`
+		`+limitOffset+`
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, n, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	media := []*pa.Media{}
+	for rows.Next() {
+		var m pa.Media
+
+		if err := rows.Scan(
+			&m.ID,
+			&m.Hash,
+			&m.Name,
+			&m.MimeType,
+			&m.Size,
+			&m.Width,
+			&m.Height,
+			&m.AltText,
+			&m.RefCount,
+			(*NullTime)(&m.CreatedAt),
+			&n,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		media = append(media, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := attachVariantsToMedia(ctx, tx, media); err != nil {
+		return nil, 0, err
+	}
+
+	return media, n, nil
+}
+
+func createMedia(ctx context.Context, tx *Tx, media *pa.Media) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	// set timestamp to media obj.
+	media.CreatedAt = tx.now
+	media.RefCount = 0
+
+	if err := media.Validate(); err != nil {
+		return err
+	}
+
+	// media is content addressed, the same content can only exist once.
+	if _, n, err := findMedia(ctx, tx, pa.MediaFilter{Hash: &media.Hash, Limit: 1}); err != nil {
+		return err
+	} else if n > 0 {
+		return pa.Errorf(pa.ECONFLICT, "media already exists.")
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO media (
+			hash,
+			name,
+			mime_type,
+			size,
+			width,
+			height,
+			alt_text,
+			created_at
+		)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		media.Hash,
+		media.Name,
+		media.MimeType,
+		media.Size,
+		media.Width,
+		media.Height,
+		media.AltText,
+		(*NullTime)(&media.CreatedAt),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// set id from database to media obj.
+	media.ID = int(id)
+
+	for _, v := range media.Variants {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO media_variants (
+				media_id,
+				label,
+				name,
+				mime_type,
+				size,
+				width,
+				height
+			)
+			VALUES(?, ?, ?, ?, ?, ?, ?)
+		`,
+			media.ID,
+			v.Label,
+			v.Name,
+			v.MimeType,
+			v.Size,
+			v.Width,
+			v.Height,
+		); err != nil {
+			return err
+		}
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetMedia, media.ID, nil, media)
+}
+
+func updateMedia(ctx context.Context, tx *Tx, id int, update pa.MediaUpdate) (*pa.Media, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	media, err := findMediaByID(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *media
+
+	if v := update.AltText; v != nil {
+		media.AltText = *v
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE media
+		SET alt_text = ?
+		WHERE id = ?
+	`,
+		media.AltText,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, pa.AuditActionUpdate, pa.AuditTargetMedia, id, &before, media); err != nil {
+		return nil, err
+	}
+
+	return media, nil
+}
+
+func deleteMedia(ctx context.Context, tx *Tx, id int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	media, err := findMediaByID(ctx, tx, id)
+	if err != nil {
+		return err
+	} else if media.RefCount > 0 {
+		return pa.Errorf(pa.ECONFLICT, "media is referenced by %d objects.", media.RefCount)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM media WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetMedia, id, media, nil)
+}
+
+// media references -------------------------------------------------------------------
+
+func createMediaRef(ctx context.Context, tx *Tx, id int, ref pa.MediaRef) (err error) {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	if err := ref.Validate(); err != nil {
+		return err
+	}
+
+	// check that both ends of the reference exist.
+	if _, err := findMediaByID(ctx, tx, id); err != nil {
+		return err
+	}
+
+	switch ref.Type {
+	case pa.MediaRefBlog:
+		if _, err = findBlogByID(ctx, tx, ref.ID); err == nil {
+			_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO blog_media (blog_id, media_id) VALUES(?, ?)`, ref.ID, id)
+		}
+
+	case pa.MediaRefSubBlog:
+		if _, err = findSubBlogByID(ctx, tx, ref.ID); err == nil {
+			_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO sub_blog_media (sub_blog_id, media_id) VALUES(?, ?)`, ref.ID, id)
+		}
+
+	case pa.MediaRefProject:
+		if _, err = findProjectByID(ctx, tx, ref.ID); err == nil {
+			_, err = tx.ExecContext(ctx, `INSERT OR REPLACE INTO project_media (project_id, media_id) VALUES(?, ?)`, ref.ID, id)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionRef, pa.AuditTargetMedia, id, nil, ref)
+}
+
+func deleteMediaRef(ctx context.Context, tx *Tx, id int, ref pa.MediaRef) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	if err := ref.Validate(); err != nil {
+		return err
+	}
+
+	var query string
+	switch ref.Type {
+	case pa.MediaRefBlog:
+		query = `DELETE FROM blog_media WHERE blog_id = ? AND media_id = ?`
+
+	case pa.MediaRefSubBlog:
+		query = `DELETE FROM sub_blog_media WHERE sub_blog_id = ? AND media_id = ?`
+
+	case pa.MediaRefProject:
+		query = `DELETE FROM project_media WHERE project_id = ? AND media_id = ?`
+	}
+
+	result, err := tx.ExecContext(ctx, query, ref.ID, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return pa.Errorf(pa.ENOTFOUND, "media reference not found.")
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionUnref, pa.AuditTargetMedia, id, ref, nil)
+}
+
+// attachVariantsToMedia attaches the variants of each media ordered by width.
+func attachVariantsToMedia(ctx context.Context, tx *Tx, media []*pa.Media) error {
+	ids := make([]int, len(media))
+	for i, m := range media {
+		ids[i] = m.ID
+	}
+
+	cond, args := formatIn("media_id", ids)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			media_id,
+			label,
+			name,
+			mime_type,
+			size,
+			width,
+			height
+		FROM media_variants
+		WHERE `+cond+`
+		ORDER BY media_id ASC, width ASC, name ASC
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	variants := make(map[int][]*pa.MediaVariant)
+	for rows.Next() {
+		var v pa.MediaVariant
+		var mediaID int
+
+		if err := rows.Scan(
+			&mediaID,
+			&v.Label,
+			&v.Name,
+			&v.MimeType,
+			&v.Size,
+			&v.Width,
+			&v.Height,
+		); err != nil {
+			return err
+		}
+
+		variants[mediaID] = append(variants[mediaID], &v)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range media {
+		m.Variants = append(m.Variants[:0], variants[m.ID]...)
+	}
+	return nil
+}
+
+// findMediaRefs returns the media referenced through the link table by each of the ids, keyed by id.
+// table and column are never user input.
+func findMediaRefs(ctx context.Context, tx *Tx, table, column string, ids []int) (map[int][]*pa.Media, error) {
+	cond, args := formatIn(column, ids)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			`+column+`,
+			media_id
+		FROM `+table+`
+		WHERE `+cond+`
+		ORDER BY `+column+` ASC, media_id ASC
+	`,
+		args...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	var refIDs, mediaIDs []int
+	for rows.Next() {
+		var refID, mediaID int
+
+		if err := rows.Scan(&refID, &mediaID); err != nil {
+			return nil, err
+		}
+
+		refIDs, mediaIDs = append(refIDs, refID), append(mediaIDs, mediaID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// load the referenced media once all the rows are read.
+	cond, args = formatIn("id", mediaIDs)
+	media, _, err := queryMedia(ctx, tx, []string{cond}, args, "id ASC", "")
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*pa.Media, len(media))
+	for _, m := range media {
+		byID[m.ID] = m
+	}
+
+	refs := make(map[int][]*pa.Media)
+	for i, refID := range refIDs {
+		refs[refID] = append(refs[refID], byID[mediaIDs[i]])
+	}
+	return refs, nil
+}
+
+// attachImageToBlogs attaches the media referenced by each blog, if any.
+func attachImageToBlogs(ctx context.Context, tx *Tx, blogs []*pa.Blog) error {
+	ids := make([]int, len(blogs))
+	for i, blog := range blogs {
+		ids[i] = blog.ID
+	}
+
+	refs, err := findMediaRefs(ctx, tx, "blog_media", "blog_id", ids)
+	if err != nil {
+		return err
+	}
+
+	for _, blog := range blogs {
+		if media := refs[blog.ID]; len(media) > 0 {
+			blog.Image = media[0]
+		}
+	}
+	return nil
+}
+
+// attachMediaToSubBlogs attaches the media referenced by each sub blog.
+func attachMediaToSubBlogs(ctx context.Context, tx *Tx, subBlogs []*pa.SubBlog) error {
+	ids := make([]int, len(subBlogs))
+	for i, subBlog := range subBlogs {
+		ids[i] = subBlog.ID
+	}
+
+	refs, err := findMediaRefs(ctx, tx, "sub_blog_media", "sub_blog_id", ids)
+	if err != nil {
+		return err
+	}
+
+	for _, subBlog := range subBlogs {
+		subBlog.Media = append(subBlog.Media[:0], refs[subBlog.ID]...)
+	}
+	return nil
+}
+
+// attachImageToProjects attaches the media referenced by each project, if any.
+func attachImageToProjects(ctx context.Context, tx *Tx, projects []*pa.Project) error {
+	ids := make([]int, len(projects))
+	for i, project := range projects {
+		ids[i] = project.ID
+	}
+
+	refs, err := findMediaRefs(ctx, tx, "project_media", "project_id", ids)
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		if media := refs[project.ID]; len(media) > 0 {
+			project.Image = media[0]
+		}
+	}
+	return nil
+}
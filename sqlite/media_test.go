@@ -0,0 +1,277 @@
+package sqlite_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestCreateMedia(t *testing.T) {
+	t.Run("Ok Create Call", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		mediaService := sqlite.NewMediaService(db)
+
+		backgroundCtx := context.Background()
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as CreateMedia doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		media := &pa.Media{
+			Hash:     "abc",
+			Name:     "abc.png",
+			MimeType: "image/png",
+			Size:     100,
+			Width:    10,
+			Height:   20,
+			AltText:  "some image",
+			Variants: []*pa.MediaVariant{
+				{Label: "thumbnail", Name: "abc-320w.png", MimeType: "image/png", Size: 10, Width: 320, Height: 640},
+				{Label: "thumbnail", Name: "abc-320w.webp", MimeType: "image/webp", Size: 8, Width: 320, Height: 640},
+			},
+		}
+
+		// create media.
+		if err := mediaService.CreateMedia(adminUsrCtx, media); err != nil {
+			t.Fatal(err)
+		} else if media.ID == 0 {
+			t.Fatal("got id = 0")
+		}
+
+		// assert creation.
+		if gotMedia, err := mediaService.FindMediaByID(backgroundCtx, media.ID); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(media, gotMedia) {
+			t.Fatal("DeepEqual: gotMedia != media")
+		}
+
+		// assert lookup by hash.
+		if gotMedia, n, err := mediaService.FindMedia(backgroundCtx, pa.MediaFilter{Hash: NewStringPointer("abc")}); err != nil {
+			t.Fatal(err)
+		} else if n != 1 || gotMedia[0].ID != media.ID {
+			t.Fatal("media not found by hash")
+		}
+	})
+
+	t.Run("Bad Create Call", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		mediaService := sqlite.NewMediaService(db)
+
+		backgroundCtx := context.Background()
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as CreateMedia doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		// create media (Un Auth).
+		if err := mediaService.CreateMedia(backgroundCtx, &pa.Media{Hash: "abc", Name: "abc.png", MimeType: "image/png", Size: 1}); pa.ErrorCode(err) != pa.EUNAUTHORIZED {
+			t.Fatal("err != EUNAUTHORIZED")
+		}
+
+		// create media (Invalid).
+		if err := mediaService.CreateMedia(adminUsrCtx, &pa.Media{Hash: "abc"}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// create media (Conflict).
+		MustCreateMedia(t, db, adminUsrCtx, &pa.Media{Hash: "abc", Name: "abc.png", MimeType: "image/png", Size: 1})
+		if err := mediaService.CreateMedia(adminUsrCtx, &pa.Media{Hash: "abc", Name: "abc.png", MimeType: "image/png", Size: 1}); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatal("err != ECONFLICT")
+		}
+	})
+}
+
+func TestUpdateMedia(t *testing.T) {
+	t.Run("Ok Update Call", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		mediaService := sqlite.NewMediaService(db)
+
+		backgroundCtx := context.Background()
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as UpdateMedia doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		media := &pa.Media{Hash: "abc", Name: "abc.png", MimeType: "image/png", Size: 1}
+		MustCreateMedia(t, db, adminUsrCtx, media)
+
+		// update media.
+		if gotMedia, err := mediaService.UpdateMedia(adminUsrCtx, media.ID, pa.MediaUpdate{AltText: NewStringPointer("new alt")}); err != nil {
+			t.Fatal(err)
+		} else if gotMedia.AltText != "new alt" {
+			t.Fatal("alt text not updated")
+		}
+	})
+
+	t.Run("Bad Update Call (Not Found)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		mediaService := sqlite.NewMediaService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		}
+
+		adminUsrCtx := pa.NewContextWithUser(context.Background(), user)
+
+		if _, err := mediaService.UpdateMedia(adminUsrCtx, 1, pa.MediaUpdate{}); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+	})
+}
+
+func TestMediaRefs(t *testing.T) {
+	t.Run("Ok Ref Call", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		mediaService := sqlite.NewMediaService(db)
+		blogService := sqlite.NewBlogService(db)
+		subBlogService := sqlite.NewSubBlogService(db)
+
+		backgroundCtx := context.Background()
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as the media refs dont check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		blog := &pa.Blog{Title: "Epic Blog"}
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		subBlog := &pa.SubBlog{BlogID: blog.ID, Title: "Epic Sub Blog", Content: "some content"}
+		MustCreateSubBlog(t, db, adminUsrCtx, subBlog)
+
+		m1 := &pa.Media{Hash: "abc", Name: "abc.png", MimeType: "image/png", Size: 1}
+		MustCreateMedia(t, db, adminUsrCtx, m1)
+		m2 := &pa.Media{Hash: "def", Name: "def.png", MimeType: "image/png", Size: 1}
+		MustCreateMedia(t, db, adminUsrCtx, m2)
+
+		// reference m1 from blog and sub blog.
+		if err := mediaService.CreateMediaRef(adminUsrCtx, m1.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: blog.ID}); err != nil {
+			t.Fatal(err)
+		} else if err := mediaService.CreateMediaRef(adminUsrCtx, m1.ID, pa.MediaRef{Type: pa.MediaRefSubBlog, ID: subBlog.ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		// assert ref count and attached media.
+		if gotMedia, err := mediaService.FindMediaByID(backgroundCtx, m1.ID); err != nil {
+			t.Fatal(err)
+		} else if gotMedia.RefCount != 2 {
+			t.Fatalf("ref count=%v != 2", gotMedia.RefCount)
+		}
+		if gotBlog, err := blogService.FindBlogByID(backgroundCtx, blog.ID); err != nil {
+			t.Fatal(err)
+		} else if gotBlog.Image == nil || gotBlog.Image.ID != m1.ID {
+			t.Fatal("blog image != m1")
+		}
+		if gotSubBlog, err := subBlogService.FindSubBlogByID(backgroundCtx, subBlog.ID); err != nil {
+			t.Fatal(err)
+		} else if len(gotSubBlog.Media) != 1 || gotSubBlog.Media[0].ID != m1.ID {
+			t.Fatal("sub blog media != [m1]")
+		}
+
+		// referenced media cant be deleted.
+		if err := mediaService.DeleteMedia(adminUsrCtx, m1.ID); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatal("err != ECONFLICT")
+		}
+
+		// replace blog image with m2.
+		if err := mediaService.CreateMediaRef(adminUsrCtx, m2.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: blog.ID}); err != nil {
+			t.Fatal(err)
+		}
+		if gotBlog, err := blogService.FindBlogByID(backgroundCtx, blog.ID); err != nil {
+			t.Fatal(err)
+		} else if gotBlog.Image == nil || gotBlog.Image.ID != m2.ID {
+			t.Fatal("blog image != m2")
+		}
+
+		// remove the remaining reference and delete m1.
+		if err := mediaService.DeleteMediaRef(adminUsrCtx, m1.ID, pa.MediaRef{Type: pa.MediaRefSubBlog, ID: subBlog.ID}); err != nil {
+			t.Fatal(err)
+		} else if err := mediaService.DeleteMedia(adminUsrCtx, m1.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// the deleted blog keeps m2 until purged.
+		if err := blogService.DeleteBlog(adminUsrCtx, blog.ID); err != nil {
+			t.Fatal(err)
+		} else if err := mediaService.DeleteMedia(adminUsrCtx, m2.ID); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatal("err != ECONFLICT")
+		}
+
+		if _, err := sqlite.NewTrashService(db).Purge(adminUsrCtx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if err := mediaService.DeleteMedia(adminUsrCtx, m2.ID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Bad Ref Call", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		mediaService := sqlite.NewMediaService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		}
+
+		adminUsrCtx := pa.NewContextWithUser(context.Background(), user)
+
+		media := &pa.Media{Hash: "abc", Name: "abc.png", MimeType: "image/png", Size: 1}
+		MustCreateMedia(t, db, adminUsrCtx, media)
+
+		// reference from unknown type (Invalid).
+		if err := mediaService.CreateMediaRef(adminUsrCtx, media.ID, pa.MediaRef{Type: "user", ID: 1}); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+
+		// reference from unexisting blog (Not Found).
+		if err := mediaService.CreateMediaRef(adminUsrCtx, media.ID, pa.MediaRef{Type: pa.MediaRefBlog, ID: 1}); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+
+		// delete unexisting reference (Not Found).
+		if err := mediaService.DeleteMediaRef(adminUsrCtx, media.ID, pa.MediaRef{Type: pa.MediaRefProject, ID: 1}); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+	})
+}
+
+func MustCreateMedia(t *testing.T, db *sqlite.DB, ctx context.Context, media *pa.Media) {
+	t.Helper()
+	if err := sqlite.NewMediaService(db).CreateMedia(ctx, media); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,323 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+//go:embed migration/*.sql
+var migrationFS embed.FS
+
+var _ pa.MigrationService = (*DB)(nil)
+
+// migrationFile is an embedded migration, made of an up file and an optional down file reverting it,
+// ie: "00000004.sql" is reverted by "00000004.down.sql".
+type migrationFile struct {
+	name     string // recorded in the migrations table, ie: "migration/00000004.sql".
+	up       string
+	down     string // empty if the migration isnt reversible.
+	checksum string
+}
+
+// appliedMigration is a row of the migrations table.
+type appliedMigration struct {
+	checksum  string // empty for migrations applied before checksums were recorded.
+	appliedAt time.Time
+}
+
+// Migrations returns the embedded migrations followed by the unknown applied migrations, in name order.
+func (db *DB) Migrations(ctx context.Context) ([]*pa.Migration, error) {
+	if err := db.createMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	migrations, _, err := findMigrations(ctx, tx)
+	return migrations, err
+}
+
+// MigrateUp applies the next n pending migrations in one transaction, n <= 0 applies all of them.
+// migrations applied before checksums were recorded get the checksum of their embedded file.
+// returns ECONFLICT if the schema is newer than the binary or an applied migration was modified.
+func (db *DB) MigrateUp(ctx context.Context, n int, dryRun bool) ([]*pa.Migration, error) {
+	if err := db.createMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTX(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	migrations, files, err := findMigrations(ctx, tx)
+	if err != nil {
+		return nil, err
+	} else if err := checkMigrations(migrations); err != nil {
+		return nil, err
+	}
+
+	pending := []*pa.Migration{}
+	for i, migration := range migrations {
+		if migration.Applied {
+			if _, err := tx.ExecContext(ctx, `UPDATE migrations SET checksum = ? WHERE name = ? AND checksum = ''`, migration.Checksum, files[i].name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if n > 0 && len(pending) == n {
+			break
+		}
+
+		if !dryRun {
+			if _, err := tx.ExecContext(ctx, files[i].up); err != nil {
+				return nil, pa.Errorf(pa.EINTERNAL, "migration %q: %v", migration.Name, err)
+			}
+
+			if _, err := tx.ExecContext(ctx, `INSERT INTO migrations (name, checksum, applied_at) VALUES (?, ?, ?)`,
+				files[i].name,
+				migration.Checksum,
+				(*NullTime)(&tx.now),
+			); err != nil {
+				return nil, err
+			}
+
+			migration.Applied, migration.AppliedAt = true, tx.now
+		}
+		pending = append(pending, migration)
+	}
+
+	if dryRun {
+		return pending, nil
+	}
+
+	// the slug placeholders cant be generated in sql, they are replaced on every run so that
+	// databases which applied the slug migration before the backfill existed get it too.
+	for i, file := range files {
+		if file.name == slugMigration && migrations[i].Applied {
+			if err := backfillSlugs(ctx, tx); err != nil {
+				return nil, pa.Errorf(pa.EINTERNAL, "backfill slugs: %v", err)
+			}
+		}
+	}
+	return pending, tx.Commit()
+}
+
+// MigrateDown reverts the last n applied migrations in one transaction, latest first.
+// returns ECONFLICT if the schema is newer than the binary or an applied migration was modified.
+// returns EINVALID if n < 1 or one of the migrations isnt reversible.
+func (db *DB) MigrateDown(ctx context.Context, n int, dryRun bool) ([]*pa.Migration, error) {
+	if n < 1 {
+		return nil, pa.Errorf(pa.EINVALID, "number of migrations to revert must be positive.")
+	}
+
+	if err := db.createMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.BeginTX(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	migrations, files, err := findMigrations(ctx, tx)
+	if err != nil {
+		return nil, err
+	} else if err := checkMigrations(migrations); err != nil {
+		return nil, err
+	}
+
+	// collect the last n applied migrations, latest first.
+	var indexes []int
+	for i := len(files) - 1; i >= 0 && len(indexes) < n; i-- {
+		if !migrations[i].Applied {
+			continue
+		} else if files[i].down == "" {
+			return nil, pa.Errorf(pa.EINVALID, "migration %q isnt reversible.", migrations[i].Name)
+		}
+		indexes = append(indexes, i)
+	}
+
+	reverted := []*pa.Migration{}
+	for _, i := range indexes {
+		if !dryRun {
+			if _, err := tx.ExecContext(ctx, files[i].down); err != nil {
+				return nil, pa.Errorf(pa.EINTERNAL, "migration %q: %v", migrations[i].Name, err)
+			}
+
+			if _, err := tx.ExecContext(ctx, `DELETE FROM migrations WHERE name = ?`, files[i].name); err != nil {
+				return nil, err
+			}
+
+			migrations[i].Applied, migrations[i].AppliedAt = false, time.Time{}
+		}
+		reverted = append(reverted, migrations[i])
+	}
+
+	if dryRun {
+		return reverted, nil
+	}
+	return reverted, tx.Commit()
+}
+
+// createMigrationsTable creates the migrations table, adding the columns missing from tables created
+// by older versions.
+func (db *DB) createMigrationsTable(ctx context.Context) error {
+	if _, err := db.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS migrations (name TEXT PRIMARY KEY);`); err != nil {
+		return err
+	}
+
+	for _, column := range []string{
+		`checksum TEXT NOT NULL DEFAULT ''`,
+		`applied_at TEXT`,
+	} {
+		var n int
+		if err := db.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('migrations') WHERE name = ?`, strings.Fields(column)[0]).Scan(&n); err != nil {
+			return err
+		} else if n != 0 {
+			continue
+		}
+
+		if _, err := db.db.ExecContext(ctx, `ALTER TABLE migrations ADD COLUMN `+column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findMigrations returns the embedded migrations followed by the unknown applied migrations.
+// the embedded files are returned in the same order, they are shorter then the migrations
+// by the number of unknown migrations.
+func findMigrations(ctx context.Context, tx *Tx) ([]*pa.Migration, []*migrationFile, error) {
+	files, err := readMigrationFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT name, checksum, applied_at FROM migrations`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]*appliedMigration)
+	for rows.Next() {
+		var name string
+		var row appliedMigration
+		if err := rows.Scan(&name, &row.checksum, (*NullTime)(&row.appliedAt)); err != nil {
+			return nil, nil, err
+		}
+		applied[name] = &row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return newMigrations(files, applied), files, nil
+}
+
+// newMigrations merges the embedded migration files with the rows of the migrations table.
+func newMigrations(files []*migrationFile, applied map[string]*appliedMigration) []*pa.Migration {
+	migrations := make([]*pa.Migration, 0, len(files))
+	for _, file := range files {
+		migration := &pa.Migration{
+			Name:       path.Base(file.name),
+			Checksum:   file.checksum,
+			Reversible: file.down != "",
+		}
+
+		if row, ok := applied[file.name]; ok {
+			migration.Applied = true
+			migration.AppliedAt = row.appliedAt
+			migration.Modified = row.checksum != "" && row.checksum != file.checksum
+			delete(applied, file.name)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	// the left over rows were applied by a newer binary.
+	var unknown []string
+	for name := range applied {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+
+	for _, name := range unknown {
+		migrations = append(migrations, &pa.Migration{
+			Name:      path.Base(name),
+			Checksum:  applied[name].checksum,
+			Applied:   true,
+			AppliedAt: applied[name].appliedAt,
+			Unknown:   true,
+		})
+	}
+
+	return migrations
+}
+
+// checkMigrations refuses to migrate a schema newer than the binary or with modified migrations.
+func checkMigrations(migrations []*pa.Migration) error {
+	for _, migration := range migrations {
+		if migration.Unknown {
+			return pa.Errorf(pa.ECONFLICT, "database schema is newer than the binary, unknown migration %q.", migration.Name)
+		} else if migration.Modified {
+			return pa.Errorf(pa.ECONFLICT, "migration %q was modified after being applied.", migration.Name)
+		}
+	}
+
+	return nil
+}
+
+// readMigrationFiles reads the embedded migrations in name order.
+func readMigrationFiles() ([]*migrationFile, error) {
+	names, err := fs.Glob(migrationFS, "migration/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var files []*migrationFile
+	for _, name := range names {
+		if strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+
+		up, err := fs.ReadFile(migrationFS, name)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(up)
+
+		down, err := fs.ReadFile(migrationFS, strings.TrimSuffix(name, ".sql")+".down.sql")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
+		files = append(files, &migrationFile{
+			name:     name,
+			up:       string(up),
+			down:     string(down),
+			checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	return files, nil
+}
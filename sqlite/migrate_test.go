@@ -0,0 +1,260 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+	"github.com/Lambels/patrickarvatu.com/sqlite"
+)
+
+func TestMigrations(t *testing.T) {
+	db := MustOpenTempDB(t)
+	defer MustCloseDB(t, db)
+
+	migrations, err := db.Migrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, migration := range migrations {
+		if !migration.Applied || migration.AppliedAt.IsZero() || migration.Modified || migration.Unknown {
+			t.Fatalf("migration=%+v", migration)
+		} else if !migration.Reversible || len(migration.Checksum) != 64 {
+			t.Fatalf("migration=%+v", migration)
+		}
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	db := MustOpenTempDB(t)
+	defer MustCloseDB(t, db)
+
+	t.Run("Ok Dry Run Call", func(t *testing.T) {
+		reverted, err := db.MigrateDown(context.Background(), 1, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(reverted) != 1 || reverted[0].Name != "00000013.sql" {
+			t.Fatalf("reverted=%v", reverted)
+		}
+
+		// assert nothing got reverted.
+		MustCountPending(t, db, 0)
+	})
+
+	t.Run("Ok Down Up Call", func(t *testing.T) {
+		reverted, err := db.MigrateDown(context.Background(), 8, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(reverted) != 8 || reverted[0].Name != "00000013.sql" || reverted[7].Name != "00000006.sql" {
+			t.Fatalf("reverted=%v", reverted)
+		}
+		MustCountPending(t, db, 8)
+
+		// assert the tables got dropped.
+		tx := db.MustBeginTX(context.Background(), nil)
+		defer tx.Rollback()
+		if _, err := tx.Exec(`SELECT COUNT(*) FROM media`); err == nil {
+			t.Fatal("media table not dropped")
+		}
+		tx.Rollback()
+
+		// re apply one.
+		if applied, err := db.MigrateUp(context.Background(), 1, false); err != nil {
+			t.Fatal(err)
+		} else if len(applied) != 1 || applied[0].Name != "00000006.sql" || !applied[0].Applied {
+			t.Fatalf("applied=%v", applied)
+		}
+		MustCountPending(t, db, 7)
+	})
+
+	t.Run("Ok Down All Call", func(t *testing.T) {
+		migrations, err := db.Migrations(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every down file reverts its up file.
+		if _, err := db.MigrateDown(context.Background(), len(migrations), false); err != nil {
+			t.Fatal(err)
+		}
+		MustCountPending(t, db, len(migrations))
+
+		if _, err := db.MigrateUp(context.Background(), 0, false); err != nil {
+			t.Fatal(err)
+		}
+		MustCountPending(t, db, 0)
+	})
+
+	t.Run("Bad Down Call (Invalid N)", func(t *testing.T) {
+		if _, err := db.MigrateDown(context.Background(), 0, false); pa.ErrorCode(err) != pa.EINVALID {
+			t.Fatal("err != EINVALID")
+		}
+	})
+}
+
+func TestMigrateUp(t *testing.T) {
+	t.Run("Bad Up Call (Modified)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		tx := db.MustBeginTX(context.Background(), nil)
+		if _, err := tx.Exec(`UPDATE migrations SET checksum = 'edited' WHERE name = 'migration/00000004.sql'`); err != nil {
+			t.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.MigrateUp(context.Background(), 0, false); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatal("err != ECONFLICT")
+		}
+
+		if migrations, err := db.Migrations(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if !migrations[1].Modified {
+			t.Fatalf("migration=%+v", migrations[1])
+		}
+	})
+
+	t.Run("Ok Up Call (Legacy Rows)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		// rows recorded before checksums existed get backfilled.
+		tx := db.MustBeginTX(context.Background(), nil)
+		if _, err := tx.Exec(`UPDATE migrations SET checksum = '', applied_at = NULL`); err != nil {
+			t.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.MigrateUp(context.Background(), 0, false); err != nil {
+			t.Fatal(err)
+		}
+
+		tx = db.MustBeginTX(context.Background(), nil)
+		defer tx.Rollback()
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM migrations WHERE checksum = ''`).Scan(&n); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("%v migrations without checksum", n)
+		}
+	})
+
+	t.Run("Ok Up Call (Slug Placeholders)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		adminUsrCtx := pa.NewContextWithUser(context.Background(), &pa.User{
+			Name:    "Jhon Doe",
+			IsAdmin: true,
+		})
+
+		blog := &pa.Blog{
+			Title:       "Epic Blog",
+			Description: "Honestly the best blog ever.",
+		}
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		subBlog := &pa.SubBlog{
+			BlogID:  blog.ID,
+			Title:   "First Chapter",
+			Content: "Once upon a time.",
+		}
+		MustCreateSubBlog(t, db, adminUsrCtx, subBlog)
+
+		// rows created before slugs existed got placeholders from the migration.
+		tx := db.MustBeginTX(context.Background(), nil)
+		if _, err := tx.Exec(`UPDATE blogs SET slug = 'blog-' || id`); err != nil {
+			t.Fatal(err)
+		} else if _, err := tx.Exec(`UPDATE sub_blogs SET slug = 'sub-blog-' || id`); err != nil {
+			t.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.MigrateUp(context.Background(), 0, false); err != nil {
+			t.Fatal(err)
+		}
+
+		blogService := sqlite.NewBlogService(db)
+		if blog, err := blogService.FindBlogByID(adminUsrCtx, blog.ID); err != nil {
+			t.Fatal(err)
+		} else if blog.Slug != "epic-blog" {
+			t.Fatalf("slug=%v", blog.Slug)
+		}
+
+		// the placeholder keeps resolving.
+		if found, err := blogService.FindBlogBySlug(adminUsrCtx, "blog-"+strconv.Itoa(blog.ID)); err != nil {
+			t.Fatal(err)
+		} else if found.ID != blog.ID {
+			t.Fatalf("id=%v want=%v", found.ID, blog.ID)
+		}
+
+		subBlogService := sqlite.NewSubBlogService(db)
+		if subBlog, err := subBlogService.FindSubBlogByID(adminUsrCtx, subBlog.ID); err != nil {
+			t.Fatal(err)
+		} else if subBlog.Slug != "first-chapter" {
+			t.Fatalf("slug=%v", subBlog.Slug)
+		}
+	})
+
+	t.Run("Bad Open Call (Newer Schema)", func(t *testing.T) {
+		dsn := filepath.Join(t.TempDir(), "db")
+
+		db := MustOpenDB(t, dsn)
+		tx := db.MustBeginTX(context.Background(), nil)
+		if _, err := tx.Exec(`INSERT INTO migrations (name, checksum) VALUES ('migration/99999999.sql', 'x')`); err != nil {
+			t.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		MustCloseDB(t, db)
+
+		db = sqlite.NewDB(dsn)
+		if err := db.Open(); pa.ErrorCode(err) != pa.ECONFLICT {
+			t.Fatalf("err=%v", err)
+		}
+		db.Close()
+
+		// the status is still readable without migrating.
+		db = sqlite.NewDB(dsn)
+		db.AutoMigrate = false
+		if err := db.Open(); err != nil {
+			t.Fatal(err)
+		}
+		defer MustCloseDB(t, db)
+
+		if migrations, err := db.Migrations(context.Background()); err != nil {
+			t.Fatal(err)
+		} else if last := migrations[len(migrations)-1]; !last.Unknown || last.Name != "99999999.sql" {
+			t.Fatalf("migration=%+v", last)
+		}
+	})
+}
+
+func MustCountPending(t *testing.T, db *sqlite.DB, want int) {
+	t.Helper()
+
+	migrations, err := db.Migrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	for _, migration := range migrations {
+		if !migration.Applied {
+			n++
+		}
+	}
+	if n != want {
+		t.Fatalf("pending=%v want=%v", n, want)
+	}
+}
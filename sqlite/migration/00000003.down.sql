@@ -0,0 +1,10 @@
+DROP TABLE projects_topics;
+DROP TABLE topics_description;
+DROP TABLE projects;
+DROP TABLE sub_blog_subscriptions;
+DROP TABLE blog_subscriptions;
+DROP TABLE comments;
+DROP TABLE sub_blogs;
+DROP TABLE blogs;
+DROP TABLE auths;
+DROP TABLE users;
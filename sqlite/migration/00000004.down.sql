@@ -0,0 +1,8 @@
+DROP TABLE sub_blog_slugs;
+DROP TABLE blog_slugs;
+
+DROP INDEX sub_blogs_slug_idx;
+ALTER TABLE sub_blogs DROP COLUMN slug;
+
+DROP INDEX blogs_slug_idx;
+ALTER TABLE blogs DROP COLUMN slug;
@@ -0,0 +1,22 @@
+-- slugs are generated from the title, rows created before slugs existed get a placeholder slug
+-- which gets replaced once the title changes.
+ALTER TABLE blogs ADD COLUMN slug TEXT NOT NULL DEFAULT '';
+UPDATE blogs SET slug = 'blog-' || id;
+CREATE UNIQUE INDEX blogs_slug_idx ON blogs (slug);
+
+ALTER TABLE sub_blogs ADD COLUMN slug TEXT NOT NULL DEFAULT '';
+UPDATE sub_blogs SET slug = 'sub-blog-' || id;
+CREATE UNIQUE INDEX sub_blogs_slug_idx ON sub_blogs (slug);
+
+-- slug history, old slugs keep resolving to the renamed row.
+CREATE TABLE blog_slugs (
+    slug        TEXT PRIMARY KEY,
+    blog_id     INTEGER NOT NULL REFERENCES blogs (id) ON DELETE CASCADE,
+    created_at  TEXT NOT NULL
+);
+
+CREATE TABLE sub_blog_slugs (
+    slug        TEXT PRIMARY KEY,
+    sub_blog_id INTEGER NOT NULL REFERENCES sub_blogs (id) ON DELETE CASCADE,
+    created_at  TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP INDEX sub_blogs_blog_id_position_idx;
+ALTER TABLE sub_blogs DROP COLUMN position;
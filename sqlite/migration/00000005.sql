@@ -0,0 +1,7 @@
+-- explicit position of the sub blogs inside their blog, existing sub blogs are ordered by creation.
+ALTER TABLE sub_blogs ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+UPDATE sub_blogs SET position = (
+    SELECT COUNT(*) FROM sub_blogs AS other
+    WHERE other.blog_id = sub_blogs.blog_id AND other.id <= sub_blogs.id
+);
+CREATE INDEX sub_blogs_blog_id_position_idx ON sub_blogs (blog_id, position);
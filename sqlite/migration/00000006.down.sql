@@ -0,0 +1,4 @@
+DROP TABLE project_media;
+DROP TABLE sub_blog_media;
+DROP TABLE blog_media;
+DROP TABLE media;
@@ -0,0 +1,31 @@
+-- media library, files are content addressed by the sha256 of their content.
+CREATE TABLE media (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    hash        TEXT NOT NULL UNIQUE,
+    name        TEXT NOT NULL,
+    mime_type   TEXT NOT NULL,
+    size        INTEGER NOT NULL,
+    width       INTEGER NOT NULL,
+    height      INTEGER NOT NULL,
+    alt_text    TEXT NOT NULL,
+    created_at  TEXT NOT NULL
+);
+
+-- references to media, media cant be deleted while referenced.
+-- blogs and projects reference at most one media (their image).
+CREATE TABLE blog_media (
+    blog_id     INTEGER NOT NULL UNIQUE REFERENCES blogs (id) ON DELETE CASCADE,
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE RESTRICT
+);
+
+CREATE TABLE sub_blog_media (
+    sub_blog_id INTEGER NOT NULL REFERENCES sub_blogs (id) ON DELETE CASCADE,
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE RESTRICT,
+
+    UNIQUE(sub_blog_id, media_id)
+);
+
+CREATE TABLE project_media (
+    project_id  INTEGER NOT NULL UNIQUE REFERENCES projects (id) ON DELETE CASCADE,
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE RESTRICT
+);
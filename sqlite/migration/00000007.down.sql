@@ -0,0 +1 @@
+DROP TABLE media_variants;
@@ -0,0 +1,12 @@
+-- resized renditions of media, removed with their media.
+CREATE TABLE media_variants (
+    media_id    INTEGER NOT NULL REFERENCES media (id) ON DELETE CASCADE,
+    label       TEXT NOT NULL,
+    name        TEXT NOT NULL UNIQUE,
+    mime_type   TEXT NOT NULL,
+    size        INTEGER NOT NULL,
+    width       INTEGER NOT NULL,
+    height      INTEGER NOT NULL
+);
+
+CREATE INDEX media_variants_media_id_idx ON media_variants (media_id);
@@ -0,0 +1,4 @@
+-- the deleted user tombstone owns the comments of anonymized users, its id never collides with
+-- the generated ids.
+INSERT INTO users (id, name, api_key, created_at, updated_at)
+VALUES (-1, 'deleted user', lower(hex(randomblob(32))), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
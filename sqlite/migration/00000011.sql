@@ -0,0 +1,13 @@
+-- moderation status of the comments, held comments wait for the admin to approve them.
+ALTER TABLE comments ADD COLUMN status TEXT NOT NULL DEFAULT 'approved';
+
+-- banned words and regular expressions of the spam filter.
+CREATE TABLE spam_rules (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    type        TEXT NOT NULL,
+    pattern     TEXT NOT NULL,
+    score       INTEGER NOT NULL,
+    created_at  TEXT NOT NULL,
+
+    UNIQUE (type, pattern)
+);
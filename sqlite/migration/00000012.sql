@@ -0,0 +1,14 @@
+-- sessions created before it are revoked, set by full bans.
+ALTER TABLE users ADD COLUMN sessions_revoked_at TEXT;
+
+-- bans placed by the admin on the users, lifted bans get deleted.
+CREATE TABLE bans (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason      TEXT NOT NULL,
+    scope       TEXT NOT NULL,
+    expires_at  TEXT,
+    created_at  TEXT NOT NULL
+);
+
+CREATE INDEX bans_user_id_idx ON bans (user_id);
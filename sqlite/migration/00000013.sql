@@ -0,0 +1,9 @@
+-- the projects and the subscriptions keep their creation time, the cursors page through them by it.
+-- the rows created before get the time of the migration.
+ALTER TABLE projects ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE blog_subscriptions ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE sub_blog_subscriptions ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+
+UPDATE projects SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now');
+UPDATE blog_subscriptions SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now');
+UPDATE sub_blog_subscriptions SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now');
@@ -25,7 +25,7 @@ func NewProjectService(db *DB) *ProjectService {
 }
 
 func (s *ProjectService) FindProjectByID(ctx context.Context, id int) (*pa.Project, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, err
 	}
@@ -35,8 +35,7 @@ func (s *ProjectService) FindProjectByID(ctx context.Context, id int) (*pa.Proje
 	if err != nil {
 		return nil, err
 
-	} else if err = attachTopicsToProject(ctx, tx, proj); err != nil { // attach topics.
-
+	} else if err = attachToProjects(ctx, tx, []*pa.Project{proj}); err != nil { // attach topics and image.
 		return nil, err
 	}
 
@@ -44,7 +43,7 @@ func (s *ProjectService) FindProjectByID(ctx context.Context, id int) (*pa.Proje
 }
 
 func (s *ProjectService) FindProjectByName(ctx context.Context, name string) (*pa.Project, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, err
 	}
@@ -54,8 +53,7 @@ func (s *ProjectService) FindProjectByName(ctx context.Context, name string) (*p
 	if err != nil {
 		return nil, err
 
-	} else if err = attachTopicsToProject(ctx, tx, proj); err != nil { // attach topics.
-
+	} else if err = attachToProjects(ctx, tx, []*pa.Project{proj}); err != nil { // attach topics and image.
 		return nil, err
 	}
 
@@ -63,7 +61,7 @@ func (s *ProjectService) FindProjectByName(ctx context.Context, name string) (*p
 }
 
 func (s *ProjectService) FindProjects(ctx context.Context, filter pa.ProjectFilter) ([]*pa.Project, int, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -74,11 +72,9 @@ func (s *ProjectService) FindProjects(ctx context.Context, filter pa.ProjectFilt
 		return projects, n, err
 	}
 
-	// loop over each project.
-	for _, project := range projects {
-		if err := attachTopicsToProject(ctx, tx, project); err != nil { // attach topics.
-			return projects, n, err
-		}
+	// attach topics and image of all the projects at once.
+	if err := attachToProjects(ctx, tx, projects); err != nil {
+		return projects, n, err
 	}
 
 	return projects, n, err
@@ -159,6 +155,13 @@ func findProjectByName(ctx context.Context, tx *Tx, name string) (*pa.Project, e
 	return projects[0], nil
 }
 
+// projectSortFields maps the sortable fields of pa.ProjectFilter to their column.
+var projectSortFields = map[string]string{
+	"id":        "id",
+	"name":      "name",
+	"createdAt": "created_at",
+}
+
 func findProjects(ctx context.Context, tx *Tx, filter pa.ProjectFilter) (_ []*pa.Project, n int, err error) {
 	// build where and args statement method.
 	// not vulnerable to sql injection attack.
@@ -172,6 +175,25 @@ func findProjects(ctx context.Context, tx *Tx, filter pa.ProjectFilter) (_ []*pa
 		where = append(where, "name = ?")
 		args = append(args, *v)
 	}
+	if v := filter.NameContains; v != nil {
+		cond, arg := formatContains("name", *v)
+		where, args = append(where, cond), append(args, arg)
+	}
+
+	where, args, order, err := formatQuerySpec(filter.QuerySpec, projectSortFields, "id ASC", where, args)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	offset := filter.Offset
+	if v := filter.Cursor; v != nil {
+		if filter.SortBy != "" {
+			return nil, 0, pa.Errorf(pa.EINVALID, "sorting cant be combined with a cursor.")
+		}
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
 
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
@@ -179,11 +201,12 @@ func findProjects(ctx context.Context, tx *Tx, filter pa.ProjectFilter) (_ []*pa
 			name,
 			description,
 			html_url,
+			created_at,
 			COUNT(*) OVER()
 		FROM projects
 		WHERE `+strings.Join(where, " AND ")+`
-		ORDER BY id ASC
-		`+FormatLimitOffset(filter.Limit, filter.Offset)+`
+		ORDER BY `+order+`
+		`+FormatLimitOffset(filter.Limit, offset)+`
 	`,
 		args...,
 	)
@@ -203,6 +226,7 @@ func findProjects(ctx context.Context, tx *Tx, filter pa.ProjectFilter) (_ []*pa
 			&proj.Name,
 			&proj.Description,
 			&proj.HtmlURL,
+			(*NullTime)(&proj.CreatedAt),
 			&n,
 		); err != nil {
 			return nil, 0, err
@@ -214,6 +238,13 @@ func findProjects(ctx context.Context, tx *Tx, filter pa.ProjectFilter) (_ []*pa
 		return nil, 0, err
 	}
 
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(projects)-1; i < j; i, j = i+1, j-1 {
+			projects[i], projects[j] = projects[j], projects[i]
+		}
+	}
+
 	return projects, n, nil
 }
 
@@ -226,18 +257,22 @@ func createProject(ctx context.Context, tx *Tx, project *pa.Project) error {
 		return err
 	}
 
+	project.CreatedAt = tx.now
+
 	// create project.
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO projects (
 			name,
 			description,
-			html_url
+			html_url,
+			created_at
 		)
-		VALUES(?, ?, ?)
+		VALUES(?, ?, ?, ?)
 	`,
 		project.Name,
 		project.Description,
 		project.HtmlURL,
+		(*NullTime)(&project.CreatedAt),
 	)
 
 	if err != nil {
@@ -280,7 +315,7 @@ func createProject(ctx context.Context, tx *Tx, project *pa.Project) error {
 		}
 	}
 
-	return nil
+	return recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetProject, project.ID, nil, project)
 }
 
 func updateProject(ctx context.Context, tx *Tx, id int, project *pa.Project) error {
@@ -296,7 +331,7 @@ func updateProject(ctx context.Context, tx *Tx, id int, project *pa.Project) err
 	if err != nil {
 		return err
 
-	} else if err := attachTopicsToProject(ctx, tx, currentProject); err != nil {
+	} else if err := attachTopicsToProjects(ctx, tx, []*pa.Project{currentProject}); err != nil {
 		return err
 	}
 
@@ -322,6 +357,7 @@ func updateProject(ctx context.Context, tx *Tx, id int, project *pa.Project) err
 	); err != nil {
 		return err
 	}
+	project.CreatedAt = currentProject.CreatedAt
 
 	// handle topics.
 	for _, content := range project.Topics {
@@ -351,7 +387,10 @@ func updateProject(ctx context.Context, tx *Tx, id int, project *pa.Project) err
 		}
 	}
 
-	return nil
+	// the update doesent touch the image.
+	after := *project
+	after.ID, after.Image = id, currentProject.Image
+	return recordAudit(ctx, tx, pa.AuditActionUpdate, pa.AuditTargetProject, id, currentProject, &after)
 }
 
 func deleteProject(ctx context.Context, tx *Tx, name string) error {
@@ -359,7 +398,8 @@ func deleteProject(ctx context.Context, tx *Tx, name string) error {
 		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
 	}
 
-	if _, err := findProjectByName(ctx, tx, name); err != nil {
+	project, err := findProjectByName(ctx, tx, name)
+	if err != nil {
 		return err
 	}
 
@@ -367,7 +407,7 @@ func deleteProject(ctx context.Context, tx *Tx, name string) error {
 		return err
 	}
 
-	return nil
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetProject, project.ID, project, nil)
 }
 
 // topics: many 2 many interface functions ----------------------------------------------
@@ -428,14 +468,20 @@ func findTopicByContent(ctx context.Context, tx *Tx, content string) (*pa.Topic,
 	return &topic, nil
 }
 
-func findTopicsByProjectID(ctx context.Context, tx *Tx, id int) ([]*pa.Topic, error) {
+// findTopicsByProjectIDs returns the topics linked to each of the project ids, keyed by project id.
+func findTopicsByProjectIDs(ctx context.Context, tx *Tx, ids []int) (map[int][]*pa.Topic, error) {
+	cond, args := formatIn("projects_topics.project_id", ids)
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
-			topic_description_id
+			projects_topics.project_id,
+			topics_description.id,
+			topics_description.content
 		FROM projects_topics
-		WHERE project_id = ?
+		JOIN topics_description ON topics_description.id = projects_topics.topic_description_id
+		WHERE `+cond+`
+		ORDER BY projects_topics.project_id ASC, projects_topics.rowid ASC
 	`,
-		id,
+		args...,
 	)
 
 	if err != nil {
@@ -444,43 +490,21 @@ func findTopicsByProjectID(ctx context.Context, tx *Tx, id int) ([]*pa.Topic, er
 	defer rows.Close()
 
 	// deserialize rows.
-	var topicIDs []int
+	topics := make(map[int][]*pa.Topic)
 	for rows.Next() {
-		var topicID int
+		var topic pa.Topic
+		var projectID int
 
-		if err := rows.Scan(&topicID); err != nil {
+		if err := rows.Scan(&projectID, &topic.ID, &topic.Content); err != nil {
 			return nil, err
 		}
 
-		topicIDs = append(topicIDs, topicID)
+		topics[projectID] = append(topics[projectID], &topic)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	// loop over each topicID.
-	topics := []*pa.Topic{}
-	for _, id := range topicIDs {
-		var content string
-
-		// query the content for each id.
-		if err := tx.QueryRowContext(ctx, `
-			SELECT
-				content
-			FROM topics_description
-			WHERE id = ?
-		`,
-			id,
-		).Scan(&content); err != nil {
-			return nil, err
-		}
-
-		topics = append(topics, &pa.Topic{
-			ID:      id,
-			Content: content,
-		})
-	}
-
 	return topics, nil
 }
 
@@ -511,14 +535,30 @@ func deleteTopicLinkByProjectID(ctx context.Context, tx *Tx, projID int) error {
 	return err
 }
 
-func attachTopicsToProject(ctx context.Context, tx *Tx, project *pa.Project) error {
-	topics, err := findTopicsByProjectID(ctx, tx, project.ID)
+// attachToProjects attaches the topics and the image to projects, each loaded for all the projects at once.
+func attachToProjects(ctx context.Context, tx *Tx, projects []*pa.Project) error {
+	if err := attachTopicsToProjects(ctx, tx, projects); err != nil {
+		return err
+	}
+
+	return attachImageToProjects(ctx, tx, projects)
+}
+
+func attachTopicsToProjects(ctx context.Context, tx *Tx, projects []*pa.Project) error {
+	ids := make([]int, len(projects))
+	for i, project := range projects {
+		ids[i] = project.ID
+	}
+
+	topics, err := findTopicsByProjectIDs(ctx, tx, ids)
 	if err != nil {
 		return err
 	}
 
-	for _, topic := range topics { // attach the content for each.
-		project.Topics = append(project.Topics, topic.Content)
+	for _, project := range projects {
+		for _, topic := range topics[project.ID] { // attach the content for each.
+			project.Topics = append(project.Topics, topic.Content)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// slugTable describes a table owning slugs and the table holding its slug history.
+type slugTable struct {
+	table        string // table with the current slugs, ie: blogs.
+	historyTable string // table with the old slugs, ie: blog_slugs.
+	foreignKey   string // column of historyTable pointing back to table, ie: blog_id.
+	fallback     string // slug used when the title doesent produce one.
+}
+
+var (
+	blogSlugTable = slugTable{
+		table:        "blogs",
+		historyTable: "blog_slugs",
+		foreignKey:   "blog_id",
+		fallback:     "blog",
+	}
+
+	subBlogSlugTable = slugTable{
+		table:        "sub_blogs",
+		historyTable: "sub_blog_slugs",
+		foreignKey:   "sub_blog_id",
+		fallback:     "sub-blog",
+	}
+)
+
+// uniqueSlug generates a slug from title which isnt used by any other row in st.table nor in
+// its slug history. id represents the row requesting the slug (0 on creation) so that a row can
+// reclaim its own old slugs.
+func uniqueSlug(ctx context.Context, tx *Tx, st slugTable, title string, id int) (string, error) {
+	base := pa.Slugify(title)
+	if base == "" {
+		base = st.fallback
+	}
+
+	for i := 1; ; i++ {
+		slug := base
+		if i > 1 {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		if !pa.IsValidSlug(slug) { // numeric slugs would be mistaken for ids.
+			continue
+		}
+
+		var n int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT
+				(SELECT COUNT(*) FROM `+st.table+` WHERE slug = ? AND id != ?) +
+				(SELECT COUNT(*) FROM `+st.historyTable+` WHERE slug = ? AND `+st.foreignKey+` != ?)
+		`,
+			slug, id,
+			slug, id,
+		).Scan(&n); err != nil {
+			return "", err
+		} else if n == 0 {
+			return slug, nil
+		}
+	}
+}
+
+// renameSlug moves the current slug of the row with id: id into the slug history and removes
+// newSlug from the history if the row owned it before.
+func renameSlug(ctx context.Context, tx *Tx, st slugTable, id int, oldSlug, newSlug string) error {
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM `+st.historyTable+` WHERE slug = ? AND `+st.foreignKey+` = ?
+	`,
+		newSlug,
+		id,
+	); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO `+st.historyTable+` (
+			slug,
+			`+st.foreignKey+`,
+			created_at
+		)
+		VALUES(?, ?, ?)
+	`,
+		oldSlug,
+		id,
+		(*NullTime)(&tx.now),
+	)
+	return err
+}
+
+// findIDBySlugHistory returns the id of the row which owned slug in the past.
+// returns ENOTFOUND if slug isnt in the history.
+func findIDBySlugHistory(ctx context.Context, tx *Tx, st slugTable, slug string) (int, error) {
+	var id int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT `+st.foreignKey+` FROM `+st.historyTable+` WHERE slug = ?
+	`,
+		slug,
+	).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, pa.Errorf(pa.ENOTFOUND, "slug not found.")
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// slugMigration is the migration adding the slug columns, it gives the existing rows placeholder
+// slugs, ie: "blog-1", which are replaced by backfillSlugs.
+const slugMigration = "migration/00000004.sql"
+
+// backfillSlugs replaces the placeholder slugs of the rows created before slugs existed with slugs
+// generated from their titles. the placeholders move into the slug history so that links using
+// them keep resolving. rows which already have a generated slug are left untouched.
+func backfillSlugs(ctx context.Context, tx *Tx) error {
+	for _, st := range []slugTable{blogSlugTable, subBlogSlugTable} {
+		if err := backfillTableSlugs(ctx, tx, st); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func backfillTableSlugs(ctx context.Context, tx *Tx, st slugTable) error {
+	type row struct {
+		id    int
+		title string
+		slug  string
+	}
+
+	// the placeholders are made of the fallback and the id, ie: "sub-blog-3".
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, title, slug FROM `+st.table+` WHERE slug = ? || id
+	`,
+		st.fallback+"-",
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var placeholders []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.title, &r.slug); err != nil {
+			return err
+		}
+		placeholders = append(placeholders, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, r := range placeholders {
+		slug, err := uniqueSlug(ctx, tx, st, r.title, r.id)
+		if err != nil {
+			return err
+		} else if slug == r.slug { // the title produces the placeholder itself, ie: "Blog 1".
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE `+st.table+` SET slug = ? WHERE id = ?`, slug, r.id); err != nil {
+			return err
+		} else if err := renameSlug(ctx, tx, st, r.id, r.slug, slug); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
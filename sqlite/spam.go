@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	pa "github.com/Lambels/patrickarvatu.com"
+)
+
+// check to see if *SpamService object implements set interface.
+var _ pa.SpamService = (*SpamService)(nil)
+
+// SpamService represents a service used to manage the spam rules.
+type SpamService struct {
+	db *DB
+}
+
+// NewSpamService returns a new instance of SpamService attached to db.
+func NewSpamService(db *DB) *SpamService {
+	return &SpamService{
+		db: db,
+	}
+}
+
+// FindSpamRules returns all the spam rules, oldest first.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *SpamService) FindSpamRules(ctx context.Context) ([]*pa.SpamRule, error) {
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findSpamRules(ctx, tx)
+}
+
+// CreateSpamRule creates a spam rule.
+// returns EINVALID if the rule is invalid or already exists.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *SpamService) CreateSpamRule(ctx context.Context, rule *pa.SpamRule) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createSpamRule(ctx, tx, rule); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteSpamRule permanently deletes the spam rule with id.
+// returns ENOTFOUND if the rule doesent exist.
+// returns EUNAUTHORIZED if used by anyone other then the admin user.
+func (s *SpamService) DeleteSpamRule(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := deleteSpamRule(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func findSpamRules(ctx context.Context, tx *Tx) ([]*pa.SpamRule, error) {
+	if !pa.IsAdminContext(ctx) {
+		return nil, pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			type,
+			pattern,
+			score,
+			created_at
+		FROM spam_rules
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// deserialize rows.
+	rules := []*pa.SpamRule{}
+	for rows.Next() {
+		var rule pa.SpamRule
+
+		if err := rows.Scan(
+			&rule.ID,
+			&rule.Type,
+			&rule.Pattern,
+			&rule.Score,
+			(*NullTime)(&rule.CreatedAt),
+		); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, &rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func createSpamRule(ctx context.Context, tx *Tx, rule *pa.SpamRule) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	rule.CreatedAt = tx.now
+
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM spam_rules WHERE type = ? AND pattern = ?`, rule.Type, rule.Pattern).Scan(&n); err != nil {
+		return err
+	} else if n != 0 {
+		return pa.Errorf(pa.EINVALID, "spam rule already exists.")
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO spam_rules (
+			type,
+			pattern,
+			score,
+			created_at
+		)
+		VALUES(?, ?, ?, ?)
+	`,
+		rule.Type,
+		rule.Pattern,
+		rule.Score,
+		(*NullTime)(&rule.CreatedAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// set id from database to rule obj.
+	rule.ID = int(id)
+
+	return recordAudit(ctx, tx, pa.AuditActionCreate, pa.AuditTargetSpamRule, rule.ID, nil, rule)
+}
+
+func deleteSpamRule(ctx context.Context, tx *Tx, id int) error {
+	if !pa.IsAdminContext(ctx) {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user isnt admin.")
+	}
+
+	rules, err := findSpamRules(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	var rule *pa.SpamRule
+	for _, r := range rules {
+		if r.ID == id {
+			rule = r
+		}
+	}
+	if rule == nil {
+		return pa.Errorf(pa.ENOTFOUND, "spam rule not found.")
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM spam_rules WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, pa.AuditActionDelete, pa.AuditTargetSpamRule, id, rule, nil)
+}
@@ -4,69 +4,94 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
-	"embed"
 	"fmt"
-	"io/fs"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"sort"
+	"runtime"
+	"strings"
 	"time"
 
+	pa "github.com/Lambels/patrickarvatu.com"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer spans the transactions and their queries.
+var tracer = otel.Tracer("github.com/Lambels/patrickarvatu.com/sqlite")
+
 var (
 	userCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "db_users",
-		Help: "total number of db users",
+		Name:        "db_users",
+		Help:        "total number of db users",
+		ConstLabels: prometheus.Labels{"driver": "sqlite"},
 	})
 
 	blogCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "db_blogs",
-		Help: "total number of blogs",
+		Name:        "db_blogs",
+		Help:        "total number of blogs",
+		ConstLabels: prometheus.Labels{"driver": "sqlite"},
 	})
 
 	subBlogCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "db_sub_blogs",
-		Help: "total number of sub blogs",
+		Name:        "db_sub_blogs",
+		Help:        "total number of sub blogs",
+		ConstLabels: prometheus.Labels{"driver": "sqlite"},
 	})
 
 	commentCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "db_comments",
-		Help: "total number of comments",
+		Name:        "db_comments",
+		Help:        "total number of comments",
+		ConstLabels: prometheus.Labels{"driver": "sqlite"},
 	})
 
 	subscriptionCountGauge = promauto.NewGauge(prometheus.GaugeOpts{
-		Name: "db_subscriptions",
-		Help: "combined total of total number of blog subscriptions and sub blog subscriptions",
+		Name:        "db_subscriptions",
+		Help:        "combined total of total number of blog subscriptions and sub blog subscriptions",
+		ConstLabels: prometheus.Labels{"driver": "sqlite"},
 	})
 )
 
-//go:embed migration/*.sql
-var migrationFS embed.FS
-
 type DB struct {
-	db     *sql.DB
+	db     *sql.DB // the writer, a single connection.
+	rdb    *sql.DB // the readers, used by read only transactions.
 	ctx    context.Context
 	cancel func()
 
 	DSN string
 
+	// MaxReadConns is the size of the reader pool, defaults to 2 * GOMAXPROCS.
+	MaxReadConns int
+
+	// BusyTimeout is how long a connection waits for a lock before failing with SQLITE_BUSY, defaults to 5s.
+	BusyTimeout time.Duration
+
+	// AutoMigrate applies the pending migrations and starts monitoring on Open, defaults to true.
+	// when disabled the schema is left to a MigrationService, ie: the migrations command.
+	AutoMigrate bool
+
 	Now func() time.Time
 }
 
 type Tx struct {
 	*sql.Tx
-	now time.Time
+	now  time.Time
+	span trace.Span // spans the transaction, the parent of the query spans.
 }
 
 func NewDB(dsn string) *DB {
 	db := &DB{
-		DSN: dsn,
-		Now: time.Now,
+		DSN:          dsn,
+		MaxReadConns: 2 * runtime.GOMAXPROCS(0),
+		BusyTimeout:  5 * time.Second,
+		AutoMigrate:  true,
+		Now:          time.Now,
 	}
 
 	db.ctx, db.cancel = context.WithCancel(context.Background())
@@ -84,19 +109,38 @@ func (db *DB) Open() (err error) {
 		}
 	}
 
-	if db.db, err = sql.Open("sqlite3", db.DSN); err != nil {
+	// a single writer queues the writes in the pool instead of failing with SQLITE_BUSY,
+	// BEGIN IMMEDIATE takes the write lock up front so a transaction never fails to upgrade its read lock.
+	if db.db, err = sql.Open("sqlite3", db.dsn("_journal_mode=WAL&_txlock=immediate")); err != nil {
 		return err
 	}
+	db.db.SetMaxOpenConns(1)
+
+	if err := db.db.PingContext(db.ctx); err != nil {
+		return fmt.Errorf("open writer: %w", err)
+	}
+
+	// every connection to ":memory:" opens its own database, share the writer.
+	if db.DSN == ":memory:" {
+		db.rdb = db.db
+	} else {
+		// with wal the readers see the last commit without blocking the writer.
+		if db.rdb, err = sql.Open("sqlite3", db.dsn("_query_only=1")); err != nil {
+			return err
+		}
+		db.rdb.SetMaxOpenConns(db.MaxReadConns)
+		db.rdb.SetMaxIdleConns(db.MaxReadConns)
 
-	if _, err := db.db.Exec(`PRAGMA journal_mode = wal;`); err != nil {
-		return fmt.Errorf("enable wal: %w", err)
+		if err := db.rdb.PingContext(db.ctx); err != nil {
+			return fmt.Errorf("open readers: %w", err)
+		}
 	}
 
-	if _, err := db.db.Exec(`PRAGMA foreign_keys = ON;`); err != nil {
-		return fmt.Errorf("foreign keys pragma: %w", err)
+	if !db.AutoMigrate {
+		return nil
 	}
 
-	if err := db.migrate(); err != nil {
+	if _, err := db.MigrateUp(db.ctx, 0, false); err != nil {
 		return fmt.Errorf("migrate: %w", err)
 	}
 
@@ -105,68 +149,49 @@ func (db *DB) Open() (err error) {
 	return nil
 }
 
-func (db *DB) migrate() error {
-	if _, err := db.db.Exec(`CREATE TABLE IF NOT EXISTS migrations (name TEXT PRIMARY KEY);`); err != nil {
-		return fmt.Errorf("cannot create migrations table: %w", err)
-	}
-
-	names, err := fs.Glob(migrationFS, "migration/*.sql")
-	if err != nil {
-		return err
-	}
-	sort.Strings(names)
+func (db *DB) Close() error {
+	db.cancel() // cancel ctx
 
-	for _, name := range names {
-		if err := db.migrateFile(name); err != nil {
-			return fmt.Errorf("mgrateFile: err=%w name=%q", err, name)
+	if db.rdb != nil && db.rdb != db.db {
+		if err := db.rdb.Close(); err != nil {
+			return err
 		}
 	}
-
-	return nil
+	return db.db.Close()
 }
 
-func (db *DB) migrateFile(name string) error {
-	tx, err := db.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	var n int
-	if err := tx.QueryRow(`SELECT COUNT(*) FROM migrations WHERE name = ?`, name).Scan(&n); err != nil {
-		return err
-	} else if n != 0 {
-		return nil
-	}
-
-	if buf, err := fs.ReadFile(migrationFS, name); err != nil {
-		return err
-	} else if _, err := tx.Exec(string(buf)); err != nil {
-		return err
+// dsn returns the DSN with the connection params, shared by the writer and the readers, and params.
+func (db *DB) dsn(params string) string {
+	sep := "?"
+	if strings.Contains(db.DSN, "?") {
+		sep = "&"
 	}
 
-	if _, err := tx.Exec(`INSERT INTO migrations (name) VALUES (?)`, name); err != nil {
-		return err
-	}
-
-	return tx.Commit()
+	return fmt.Sprintf("%s%s_foreign_keys=1&_busy_timeout=%d&%s", db.DSN, sep, db.BusyTimeout.Milliseconds(), params)
 }
 
-func (db *DB) Close() error {
-	db.cancel() // cancel ctx
+// BeginTX begins a transaction, read only transactions run on the reader pool and never wait on the writer.
+func (db *DB) BeginTX(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	conn := db.db
+	if opts != nil && opts.ReadOnly {
+		conn = db.rdb
+	}
 
-	return db.db.Close()
-}
+	ctx, span := tracer.Start(ctx, "sqlite.Tx",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemSqlite, attribute.Bool("db.read_only", opts != nil && opts.ReadOnly)),
+	)
 
-func (db *DB) BeginTX(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
-	tx, err := db.db.BeginTx(ctx, opts)
+	tx, err := conn.BeginTx(ctx, opts)
 	if err != nil {
+		endSpan(span, err)
 		return nil, err
 	}
 
 	return &Tx{
-		Tx:  tx,
-		now: db.Now().UTC().Truncate(time.Second),
+		Tx:   tx,
+		now:  db.Now().UTC().Truncate(time.Second),
+		span: span,
 	}, nil
 }
 
@@ -180,6 +205,75 @@ func (db *DB) MustBeginTX(ctx context.Context, opts *sql.TxOptions) *Tx {
 	return tx
 }
 
+// Commit commits the transaction and ends its span.
+func (tx *Tx) Commit() error {
+	err := tx.Tx.Commit()
+	endSpan(tx.span, err)
+	return err
+}
+
+// Rollback aborts the transaction and ends its span, the rollback deferred after a commit only
+// returns sql.ErrTxDone.
+func (tx *Tx) Rollback() error {
+	err := tx.Tx.Rollback()
+	if err != sql.ErrTxDone {
+		endSpan(tx.span, err)
+	}
+	return err
+}
+
+// ExecContext executes query in a span under the span of the transaction.
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := tx.startQuery(ctx, query)
+	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+// QueryContext runs query in a span under the span of the transaction, the span ends before the rows are read.
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := tx.startQuery(ctx, query)
+	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs query in a span under the span of the transaction.
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := tx.startQuery(ctx, query)
+	row := tx.Tx.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// startQuery starts the span of query, named after its operation, ie: "SELECT". the statement is recorded
+// with its placeholders, the bound values never reach the span.
+func (tx *Tx) startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	// drop the comment lines and the indentation, the migrations start with comments.
+	var lines []string
+	for _, line := range strings.Split(query, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			lines = append(lines, line)
+		}
+	}
+	query = strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+	op, _, _ := strings.Cut(query, " ")
+
+	return tracer.Start(trace.ContextWithSpan(ctx, tx.span), strings.ToUpper(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemSqlite, semconv.DBStatement(query)),
+	)
+}
+
+// endSpan ends span, marking it as failed by err. sql.ErrNoRows isnt a failure.
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 func (db *DB) monitor() {
 	ticker := time.NewTicker(time.Second * 10)
 	defer ticker.Stop()
@@ -193,13 +287,13 @@ func (db *DB) monitor() {
 		}
 
 		if err := db.update(db.ctx); err != nil {
-			log.Printf("stats err: %s", err)
+			slog.Error("update stats failed.", "err", err)
 		}
 	}
 }
 
 func (db *DB) update(ctx context.Context) error {
-	tx, err := db.BeginTX(ctx, nil)
+	tx, err := db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return err
 	}
@@ -211,17 +305,17 @@ func (db *DB) update(ctx context.Context) error {
 	}
 	userCountGauge.Set(float64(n))
 
-	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM blogs;`).Scan(&n); err != nil {
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM blogs WHERE `+liveBlogs).Scan(&n); err != nil {
 		return fmt.Errorf("blog count: %w", err)
 	}
 	blogCountGauge.Set(float64(n))
 
-	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sub_blogs;`).Scan(&n); err != nil {
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM sub_blogs WHERE `+liveSubBlogs).Scan(&n); err != nil {
 		return fmt.Errorf("sub_blog count: %w", err)
 	}
 	subBlogCountGauge.Set(float64(n))
 
-	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments;`).Scan(&n); err != nil {
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE `+liveComments).Scan(&n); err != nil {
 		return fmt.Errorf("comment count: %w", err)
 	}
 	commentCountGauge.Set(float64(n))
@@ -269,3 +363,93 @@ func FormatLimitOffset(limit, offset int) string {
 	}
 	return ""
 }
+
+// formatIn returns the "column IN (?, ...)" condition matching any of the ids and its args,
+// used by the batched loaders to fetch the related objects of many parents in one query.
+func formatIn(column string, ids []int) (string, []interface{}) {
+	seen := make(map[int]struct{}, len(ids))
+	marks, args := make([]string, 0, len(ids)), make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		marks, args = append(marks, "?"), append(args, id)
+	}
+
+	if len(args) == 0 {
+		return "1 = 0", nil // matches nothing.
+	}
+	return column + " IN (" + strings.Join(marks, ", ") + ")", args
+}
+
+// conditions matching the blogs, sub blogs and comments which arent in the trash, sub blogs and
+// comments are hidden along their deleted parents.
+const (
+	liveBlogs    = "deleted_at IS NULL"
+	liveSubBlogs = "deleted_at IS NULL AND blog_id IN (SELECT id FROM blogs WHERE " + liveBlogs + ")"
+	liveComments = "deleted_at IS NULL AND sub_blog_id IN (SELECT id FROM sub_blogs WHERE " + liveSubBlogs + ")"
+)
+
+// formatCursor appends the keyset condition of cursor to where and args and returns the order reading
+// the range from the cursor on. A range read before the cursor comes in reverse.
+func formatCursor(cursor *pa.Cursor, where []string, args []interface{}) ([]string, []interface{}, string) {
+	if cursor.Before {
+		return append(where, "(created_at, id) < (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at DESC, id DESC"
+	}
+	return append(where, "(created_at, id) > (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at ASC, id ASC"
+}
+
+// formatLatestCursor is formatCursor for the ranges listing the latest objects first, the range read
+// from the cursor on holds the older objects.
+func formatLatestCursor(cursor *pa.Cursor, where []string, args []interface{}) ([]string, []interface{}, string) {
+	if cursor.Before {
+		return append(where, "(created_at, id) > (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at ASC, id ASC"
+	}
+	return append(where, "(created_at, id) < (?, ?)"), append(args, (*NullTime)(&cursor.CreatedAt), cursor.ID), "created_at DESC, id DESC"
+}
+
+// formatQuerySpec validates spec, appends its creation time range to where and args and returns the order
+// it sorts by, def if it doesnt sort by any field. fields whitelists the sortable fields of the filter mapped
+// to their column, the field names passed by the caller never reach the query.
+// returns EINVALID if spec is invalid or sorts by a field outside of fields.
+func formatQuerySpec(spec pa.QuerySpec, fields map[string]string, def string, where []string, args []interface{}) ([]string, []interface{}, string, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, nil, "", err
+	}
+
+	if v := spec.CreatedAfter; v != nil {
+		where, args = append(where, "created_at > ?"), append(args, (*NullTime)(v))
+	}
+	if v := spec.CreatedBefore; v != nil {
+		where, args = append(where, "created_at < ?"), append(args, (*NullTime)(v))
+	}
+
+	if spec.SortBy == "" {
+		return where, args, def, nil
+	}
+	column, ok := fields[spec.SortBy]
+	if !ok {
+		return nil, nil, "", pa.Errorf(pa.EINVALID, "cant sort by %q.", spec.SortBy)
+	}
+
+	// the id breaks the ties so the pages stay stable.
+	direction := "ASC"
+	if spec.Order == pa.OrderDesc {
+		direction = "DESC"
+	}
+	if column == "id" {
+		return where, args, "id " + direction, nil
+	}
+	return where, args, column + " " + direction + ", id " + direction, nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// formatContains returns the case insensitive condition matching the rows where column contains v,
+// the wildcards in v are matched literally.
+func formatContains(column, v string) (string, interface{}) {
+	return column + ` LIKE ? ESCAPE '\'`, "%" + likeEscaper.Replace(v) + "%"
+}
@@ -1,12 +1,21 @@
 package sqlite_test
 
 import (
+	"context"
+	"database/sql"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	pa "github.com/Lambels/patrickarvatu.com"
 	"github.com/Lambels/patrickarvatu.com/sqlite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestOpenDB(t *testing.T) {
@@ -14,6 +23,112 @@ func TestOpenDB(t *testing.T) {
 	MustCloseDB(t, db)
 }
 
+func TestTrace(t *testing.T) {
+	ended := MustRecordSpans(t)
+	db := MustOpenTempDB(t)
+	defer MustCloseDB(t, db)
+
+	t.Run("Ok Tx Call (Child Spans)", func(t *testing.T) {
+		n := len(ended())
+
+		ctx, root := otel.Tracer("test").Start(context.Background(), "root")
+		tx := db.MustBeginTX(ctx, &sql.TxOptions{ReadOnly: true})
+		var count int
+		if err := tx.QueryRowContext(ctx, `
+			-- count the users.
+			SELECT COUNT(*)
+			FROM users
+		`).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		root.End()
+
+		spans := ended()[n:]
+		if len(spans) != 3 {
+			t.Fatalf("spans=%v", len(spans))
+		}
+		query, txSpan := spans[0], spans[1]
+
+		// the tx span is a child of the caller span, the query span a child of the tx span.
+		if txSpan.Name() != "sqlite.Tx" || txSpan.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Fatalf("tx=%v parent=%v", txSpan.Name(), txSpan.Parent().SpanID())
+		} else if query.Name() != "SELECT" || query.Parent().SpanID() != txSpan.SpanContext().SpanID() {
+			t.Fatalf("query=%v parent=%v", query.Name(), query.Parent().SpanID())
+		} else if query.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Fatalf("trace id=%v", query.SpanContext().TraceID())
+		}
+
+		// the statement is recorded without the comments and the indentation.
+		for _, attr := range query.Attributes() {
+			if attr.Key == "db.statement" && attr.Value.AsString() != "SELECT COUNT(*) FROM users" {
+				t.Fatalf("statement=%q", attr.Value.AsString())
+			}
+		}
+	})
+
+	t.Run("Ok Tx Call (No Bound Values)", func(t *testing.T) {
+		n := len(ended())
+
+		// the bound values never reach the spans, not even through the errors.
+		const secret = "secret-b0und-value"
+		user := &pa.User{Name: secret, Email: secret + "@doe.com"}
+		MustCreateUser(t, db, context.Background(), user)
+		if err := sqlite.NewUserService(db).CreateUser(context.Background(), &pa.User{Name: secret, Email: secret + "@doe.com"}); err == nil {
+			t.Fatal("expected duplicate email error")
+		}
+
+		var failed bool
+		for _, span := range ended()[n:] {
+			MustNotContainSpan(t, span, secret)
+			failed = failed || span.Status().Code == codes.Error
+		}
+		if !failed {
+			t.Fatal("expected failed span")
+		}
+	})
+}
+
+var (
+	spanRecorder     = tracetest.NewSpanRecorder()
+	spanRecorderOnce sync.Once
+)
+
+// MustRecordSpans records the spans of the package, the tracers delegate to the first global tracer
+// provider only so the provider is set once for all the tests.
+func MustRecordSpans(tb testing.TB) func() []sdktrace.ReadOnlySpan {
+	tb.Helper()
+
+	spanRecorderOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	return spanRecorder.Ended
+}
+
+// MustNotContainSpan asserts that str isnt in the name, status, attributes or events of span.
+func MustNotContainSpan(tb testing.TB, span sdktrace.ReadOnlySpan, str string) {
+	tb.Helper()
+
+	values := []string{span.Name(), span.Status().Description}
+	for _, attr := range span.Attributes() {
+		values = append(values, attr.Value.Emit())
+	}
+	for _, event := range span.Events() {
+		values = append(values, event.Name)
+		for _, attr := range event.Attributes {
+			values = append(values, attr.Value.Emit())
+		}
+	}
+
+	for _, v := range values {
+		if strings.Contains(v, str) {
+			tb.Fatalf("span %v holds %q", span.Name(), v)
+		}
+	}
+}
+
 func MustOpenTempDB(t testing.TB) *sqlite.DB {
 	t.Helper()
 
@@ -21,7 +136,7 @@ func MustOpenTempDB(t testing.TB) *sqlite.DB {
 	if err != nil {
 		t.Fatalf("tempDir: %s", err.Error())
 	}
-	defer os.RemoveAll(dir) // clean up temp files
+	t.Cleanup(func() { os.RemoveAll(dir) }) // clean up temp files, the readers open lazily.
 
 	temp := filepath.Join(dir, "db")
 
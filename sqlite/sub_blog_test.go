@@ -422,6 +422,17 @@ func TestFindSubBlogs(t *testing.T) {
 			BlogID: NewIntPointer(blog.ID),
 		}
 
+		// find sub blogs, comments arent loaded unless included.
+		if gotSubBlogs, _, err := subBlogService.FindSubBlogs(backgroundCtx, subBlogFilter); err != nil {
+			t.Fatal(err)
+		} else if len(gotSubBlogs) != 2 || gotSubBlogs[0].Comments != nil || gotSubBlogs[1].Comments != nil {
+			t.Fatalf("sub blogs=%v", gotSubBlogs)
+		} else if gotSubBlogs[0].Next.ID != gotSubBlogs[1].ID || gotSubBlogs[1].Prev.ID != gotSubBlogs[0].ID {
+			t.Fatal("navigation not attached")
+		}
+
+		subBlogFilter.IncludeComments = true
+
 		// find sub blogs.
 		if gotSubBlogs, n, err := subBlogService.FindSubBlogs(backgroundCtx, subBlogFilter); err != nil {
 			t.Fatal(err)
@@ -451,7 +462,138 @@ func TestFindSubBlogs(t *testing.T) {
 	})
 }
 
-func MustCreateSubBlog(t *testing.T, db *sqlite.DB, ctx context.Context, subBlog *pa.SubBlog) {
+func TestFindSubBlogBySlug(t *testing.T) {
+	t.Run("Ok Find Call (renamed sub blog)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		backgroundCtx := context.Background()
+
+		subBlogService := sqlite.NewSubBlogService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as CreateSubBlog doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		blog := &pa.Blog{
+			Title:       "Epic Blog",
+			Description: "Honestly the best blog ever.",
+		}
+
+		// create blog.
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		subBlog := &pa.SubBlog{
+			BlogID:  blog.ID,
+			Title:   "Part One: Setup",
+			Content: "some content",
+		}
+
+		// create sub blog.
+		MustCreateSubBlog(t, db, adminUsrCtx, subBlog)
+
+		if subBlog.Slug != "part-one-setup" {
+			t.Fatalf("slug=%v != part-one-setup", subBlog.Slug)
+		}
+
+		// rename sub blog.
+		if _, err := subBlogService.UpdateSubBlog(adminUsrCtx, subBlog.ID, pa.SubBlogUpdate{Title: NewStringPointer("Part 1: Setup")}); err != nil {
+			t.Fatal(err)
+		}
+
+		// find sub blog by old and new slug.
+		for _, slug := range []string{"part-one-setup", "part-1-setup"} {
+			if gotSubBlog, err := subBlogService.FindSubBlogBySlug(backgroundCtx, slug); err != nil {
+				t.Fatal(err)
+			} else if gotSubBlog.ID != subBlog.ID {
+				t.Fatalf("id=%v != %v", gotSubBlog.ID, subBlog.ID)
+			} else if gotSubBlog.Slug != "part-1-setup" {
+				t.Fatalf("slug=%v != part-1-setup", gotSubBlog.Slug)
+			}
+		}
+	})
+
+	t.Run("Bad Find Call (Not Found)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		subBlogService := sqlite.NewSubBlogService(db)
+
+		// find sub blog (Not Found).
+		if _, err := subBlogService.FindSubBlogBySlug(context.Background(), "part-one"); pa.ErrorCode(err) != pa.ENOTFOUND {
+			t.Fatal("err != ENOTFOUND")
+		}
+	})
+}
+
+func TestSubBlogPositions(t *testing.T) {
+	t.Run("Ok Positions (create and delete)", func(t *testing.T) {
+		db := MustOpenTempDB(t)
+		defer MustCloseDB(t, db)
+
+		backgroundCtx := context.Background()
+
+		subBlogService := sqlite.NewSubBlogService(db)
+
+		user := &pa.User{
+			Name:    "Jhon Doe",
+			Email:   "jhon@doe.com",
+			IsAdmin: true,
+		} // no need to create user as CreateSubBlog doesent check any keys.
+
+		adminUsrCtx := pa.NewContextWithUser(backgroundCtx, user)
+
+		blog := &pa.Blog{
+			Title: "Epic Blog",
+		}
+
+		// create blog.
+		MustCreateBlog(t, db, adminUsrCtx, blog)
+
+		first := &pa.SubBlog{BlogID: blog.ID, Title: "first", Content: "some content"}
+		last := &pa.SubBlog{BlogID: blog.ID, Title: "last", Content: "some content"}
+		middle := &pa.SubBlog{BlogID: blog.ID, Title: "middle", Content: "some content", Position: 2}
+
+		// create sub blogs, middle gets inserted between first and last.
+		MustCreateSubBlog(t, db, adminUsrCtx, first)
+		MustCreateSubBlog(t, db, adminUsrCtx, last)
+		MustCreateSubBlog(t, db, adminUsrCtx, middle)
+
+		// assert navigation of the middle sub blog.
+		gotSubBlog, err := subBlogService.FindSubBlogByID(backgroundCtx, middle.ID)
+		if err != nil {
+			t.Fatal(err)
+		} else if gotSubBlog.Position != 2 {
+			t.Fatalf("position=%v != 2", gotSubBlog.Position)
+		} else if gotSubBlog.Prev == nil || gotSubBlog.Prev.ID != first.ID {
+			t.Fatal("prev != first")
+		} else if gotSubBlog.Next == nil || gotSubBlog.Next.ID != last.ID || gotSubBlog.Next.Position != 3 {
+			t.Fatal("next != last")
+		}
+
+		// delete middle sub blog.
+		if err := subBlogService.DeleteSubBlog(adminUsrCtx, middle.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// assert the gap got closed.
+		if gotSubBlog, err := subBlogService.FindSubBlogByID(backgroundCtx, last.ID); err != nil {
+			t.Fatal(err)
+		} else if gotSubBlog.Position != 2 {
+			t.Fatalf("position=%v != 2", gotSubBlog.Position)
+		} else if gotSubBlog.Prev == nil || gotSubBlog.Prev.ID != first.ID {
+			t.Fatal("prev != first")
+		} else if gotSubBlog.Next != nil {
+			t.Fatal("last sub blog has next")
+		}
+	})
+}
+
+func MustCreateSubBlog(t testing.TB, db *sqlite.DB, ctx context.Context, subBlog *pa.SubBlog) {
 	t.Helper()
 	if err := sqlite.NewSubBlogService(db).CreateSubBlog(ctx, subBlog); err != nil {
 		t.Fatal(err)
@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -28,7 +29,7 @@ func NewSubscriptionService(db *DB) *SubscriptionService {
 // FindSubscriptionByID returns a subscription based on the id and topic.
 // returns ENOTFOUND if the subscription doesent exist.
 func (s *SubscriptionService) FindSubscriptionByID(ctx context.Context, id int, topic string) (*pa.Subscription, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +41,7 @@ func (s *SubscriptionService) FindSubscriptionByID(ctx context.Context, id int,
 // FindSubscriptions returns a range of subscriptions based on filter. Only returns subscriptions
 // which the user owns.
 func (s *SubscriptionService) FindSubscriptions(ctx context.Context, filter pa.SubscriptionFilter) ([]*pa.Subscription, int, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -101,8 +102,8 @@ func findSubscriptions(ctx context.Context, tx *Tx, filter pa.SubscriptionFilter
 // findBlogSubscriptions finds blog subscriptions pointed to by the filter.
 func findBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.SubscriptionFilter) (_ []*pa.Subscription, n int, err error) {
 	// build where and args statement method.
-	// not vulnerable to sql injection attack.
-	where, args := []string{"1 = 1"}, []interface{}{}
+	// not vulnerable to sql injection attack, subscriptions to deleted blogs are hidden along them.
+	where, args := []string{"blog_id IN (SELECT id FROM blogs WHERE " + liveBlogs + ")"}, []interface{}{}
 
 	if v := filter.ID; v != nil {
 		where = append(where, "id = ?")
@@ -117,16 +118,24 @@ func findBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.SubscriptionFi
 		args = append(args, v.(pa.SubBlogPayload).BlogID)
 	}
 
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	order, offset := "id ASC", filter.Offset
+	if v := filter.Cursor; v != nil {
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
+
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
 			id,
 			user_id,
 			blog_id,
+			created_at,
 			COUNT(*) OVER()
 		FROM blog_subscriptions
 		WHERE `+strings.Join(where, " AND ")+`
-		ORDER BY id ASC
-		`+FormatLimitOffset(filter.Limit, filter.Offset)+`
+		ORDER BY `+order+`
+		`+FormatLimitOffset(filter.Limit, offset)+`
 	`,
 		args...,
 	)
@@ -134,6 +143,7 @@ func findBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.SubscriptionFi
 	if err != nil {
 		return nil, n, err
 	}
+	defer rows.Close()
 
 	// deserialize rows.
 	subscriptions := []*pa.Subscription{}
@@ -148,16 +158,12 @@ func findBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.SubscriptionFi
 			&subscription.ID,
 			&subscription.UserID,
 			&payload.BlogID,
+			(*NullTime)(&subscription.CreatedAt),
 			&n,
 		); err != nil {
 			return nil, 0, err
 		}
 
-		// attach blog to payload through blog id.
-		if payload.Blog, err = findBlogByID(ctx, tx, payload.BlogID); err != nil {
-			return nil, 0, err
-		}
-
 		// attach payload to subscription.
 		subscription.Payload = payload
 
@@ -167,14 +173,26 @@ func findBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.SubscriptionFi
 		return nil, 0, err
 	}
 
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(subscriptions)-1; i < j; i, j = i+1, j-1 {
+			subscriptions[i], subscriptions[j] = subscriptions[j], subscriptions[i]
+		}
+	}
+
+	// attach the blogs of all the subscriptions at once.
+	if err := attachBlogsToSubscriptions(ctx, tx, subscriptions); err != nil {
+		return nil, 0, err
+	}
+
 	return subscriptions, n, nil
 }
 
 // findSubBlogSubscriptions finds sub blog subscriptions pointed to by the filter.
 func findSubBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.SubscriptionFilter) (_ []*pa.Subscription, n int, err error) {
 	// build where and args statement method.
-	// not vulnerable to sql injection attack.
-	where, args := []string{"1 = 1"}, []interface{}{}
+	// not vulnerable to sql injection attack, subscriptions to deleted sub blogs are hidden along them.
+	where, args := []string{"sub_blog_id IN (SELECT id FROM sub_blogs WHERE " + liveSubBlogs + ")"}, []interface{}{}
 
 	if v := filter.ID; v != nil {
 		where = append(where, "id = ?")
@@ -189,16 +207,24 @@ func findSubBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.Subscriptio
 		args = append(args, v.(pa.CommentPayload).SubBlogID)
 	}
 
+	// keyset pagination reads the range from the cursor on and ignores the offset.
+	order, offset := "id ASC", filter.Offset
+	if v := filter.Cursor; v != nil {
+		where, args, order = formatCursor(v, where, args)
+		offset = 0
+	}
+
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
 			id,
 			user_id,
 			sub_blog_id,
+			created_at,
 			COUNT(*) OVER()
 		FROM sub_blog_subscriptions
 		WHERE `+strings.Join(where, " AND ")+`
-		ORDER BY id ASC
-		`+FormatLimitOffset(filter.Limit, filter.Offset)+`
+		ORDER BY `+order+`
+		`+FormatLimitOffset(filter.Limit, offset)+`
 	`,
 		args...,
 	)
@@ -206,6 +232,7 @@ func findSubBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.Subscriptio
 	if err != nil {
 		return nil, n, err
 	}
+	defer rows.Close()
 
 	// deserialize rows.
 	subscriptions := []*pa.Subscription{}
@@ -220,16 +247,12 @@ func findSubBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.Subscriptio
 			&subscription.ID,
 			&subscription.UserID,
 			&payload.SubBlogID,
+			(*NullTime)(&subscription.CreatedAt),
 			&n,
 		); err != nil {
 			return nil, 0, err
 		}
 
-		// attach blog to payload through blog id.
-		if payload.SubBlog, err = findSubBlogByID(ctx, tx, payload.SubBlogID); err != nil {
-			return nil, 0, err
-		}
-
 		// attach payload to subscription.
 		subscription.Payload = payload
 
@@ -239,8 +262,75 @@ func findSubBlogSubscriptions(ctx context.Context, tx *Tx, filter pa.Subscriptio
 		return nil, 0, err
 	}
 
+	// the range read before the cursor comes in reverse.
+	if v := filter.Cursor; v != nil && v.Before {
+		for i, j := 0, len(subscriptions)-1; i < j; i, j = i+1, j-1 {
+			subscriptions[i], subscriptions[j] = subscriptions[j], subscriptions[i]
+		}
+	}
+
+	// attach the sub blogs of all the subscriptions at once.
+	if err := attachSubBlogsToSubscriptions(ctx, tx, subscriptions); err != nil {
+		return nil, 0, err
+	}
+
 	return subscriptions, n, nil
+}
+
+// attachBlogsToSubscriptions attaches the blog to the payload of each blog subscription.
+func attachBlogsToSubscriptions(ctx context.Context, tx *Tx, subscriptions []*pa.Subscription) error {
+	ids := make([]int, len(subscriptions))
+	for i, subscription := range subscriptions {
+		ids[i] = subscription.Payload.(pa.SubBlogPayload).BlogID
+	}
 
+	cond, args := formatIn("id", ids)
+	blogs, _, err := queryBlogs(ctx, tx, []string{cond}, args, "id ASC", "")
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]*pa.Blog, len(blogs))
+	for _, blog := range blogs {
+		byID[blog.ID] = blog
+	}
+
+	for _, subscription := range subscriptions {
+		payload := subscription.Payload.(pa.SubBlogPayload)
+		if payload.Blog = byID[payload.BlogID]; payload.Blog == nil {
+			return pa.Errorf(pa.ENOTFOUND, "blog not found.")
+		}
+		subscription.Payload = payload
+	}
+	return nil
+}
+
+// attachSubBlogsToSubscriptions attaches the sub blog to the payload of each sub blog subscription.
+func attachSubBlogsToSubscriptions(ctx context.Context, tx *Tx, subscriptions []*pa.Subscription) error {
+	ids := make([]int, len(subscriptions))
+	for i, subscription := range subscriptions {
+		ids[i] = subscription.Payload.(pa.CommentPayload).SubBlogID
+	}
+
+	cond, args := formatIn("id", ids)
+	subBlogs, _, err := querySubBlogs(ctx, tx, []string{cond}, args, "blog_id ASC, position ASC", "")
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]*pa.SubBlog, len(subBlogs))
+	for _, subBlog := range subBlogs {
+		byID[subBlog.ID] = subBlog
+	}
+
+	for _, subscription := range subscriptions {
+		payload := subscription.Payload.(pa.CommentPayload)
+		if payload.SubBlog = byID[payload.SubBlogID]; payload.SubBlog == nil {
+			return pa.Errorf(pa.ENOTFOUND, "sub blog not found.")
+		}
+		subscription.Payload = payload
+	}
+	return nil
 }
 
 // createXXXSubscription -------------------------------------------------------------
@@ -279,16 +369,19 @@ func createSubscription(ctx context.Context, tx *Tx, subscription *pa.Subscripti
 // createBlogSubscription creates a blog subscription.
 func createBlogSubscription(ctx context.Context, tx *Tx, sub *pa.Subscription) error {
 	sub.UserID = pa.UserIDFromContext(ctx)
+	sub.CreatedAt = tx.now
 
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO blog_subscriptions (
 			user_id,
-			blog_id
+			blog_id,
+			created_at
 		)
-		VALUES(?, ?)
+		VALUES(?, ?, ?)
 	`,
 		sub.UserID,
 		sub.Payload.(pa.SubBlogPayload).BlogID,
+		(*NullTime)(&sub.CreatedAt),
 	)
 
 	if err != nil {
@@ -308,16 +401,19 @@ func createBlogSubscription(ctx context.Context, tx *Tx, sub *pa.Subscription) e
 // createSubBlogSubscription creates a sub blog subscription.
 func createSubBlogSubscription(ctx context.Context, tx *Tx, sub *pa.Subscription) error {
 	sub.UserID = pa.UserIDFromContext(ctx)
+	sub.CreatedAt = tx.now
 
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO sub_blog_subscriptions (
 			user_id,
-			sub_blog_id
+			sub_blog_id,
+			created_at
 		)
-		VALUES(?, ?)
+		VALUES(?, ?, ?)
 	`,
 		sub.UserID,
 		sub.Payload.(pa.CommentPayload).SubBlogID,
+		(*NullTime)(&sub.CreatedAt),
 	)
 
 	if err != nil {
@@ -28,7 +28,7 @@ func NewUserService(db *DB) *UserService {
 // FindUserByID returns a user based on id.
 // returns ENOTFOUND if the user doesent exist.
 func (s *UserService) FindUserByID(ctx context.Context, id int) (*pa.User, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +47,7 @@ func (s *UserService) FindUserByID(ctx context.Context, id int) (*pa.User, error
 
 // FindUsers returns a range of user based on filter.
 func (s *UserService) FindUsers(ctx context.Context, filter pa.UserFilter) ([]*pa.User, int, error) {
-	tx, err := s.db.BeginTX(ctx, nil)
+	tx, err := s.db.BeginTX(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -127,6 +127,23 @@ func (s *UserService) DeleteUser(ctx context.Context, id int) error {
 	return tx.Commit()
 }
 
+// AnonymizeUser permanently deletes the user with id, his comments are kept under the deleted user tombstone.
+// returns ENOTFOUND if user doesent exist.
+// returns EUHATHORIZED if the caller isnt trying to anonymize himself.
+func (s *UserService) AnonymizeUser(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTX(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := anonymizeUser(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func findUserByID(ctx context.Context, tx *Tx, id int) (*pa.User, error) {
 	filter := pa.UserFilter{
 		ID: &id,
@@ -169,6 +186,11 @@ func findUsers(ctx context.Context, tx *Tx, filter pa.UserFilter) (_ []*pa.User,
 		where, args = append(where, "api_key = ?"), append(args, *v)
 	}
 
+	return queryUsers(ctx, tx, where, args, FormatLimitOffset(filter.Limit, filter.Offset))
+}
+
+// queryUsers returns the users matching all the where conditions.
+func queryUsers(ctx context.Context, tx *Tx, where []string, args []interface{}, limitOffset string) (_ []*pa.User, n int, err error) {
 	rows, err := tx.QueryContext(ctx, `
 		SELECT 
 		    id,
@@ -177,11 +199,12 @@ func findUsers(ctx context.Context, tx *Tx, filter pa.UserFilter) (_ []*pa.User,
 		    api_key,
 		    created_at,
 		    updated_at,
+		    sessions_revoked_at,
 		    COUNT(*) OVER()
 		FROM users
 		WHERE `+strings.Join(where, " AND ")+`
 		ORDER BY id ASC
-		`+FormatLimitOffset(filter.Limit, filter.Offset),
+		`+limitOffset,
 		args...,
 	)
 
@@ -202,6 +225,7 @@ func findUsers(ctx context.Context, tx *Tx, filter pa.UserFilter) (_ []*pa.User,
 			&user.APIKey,
 			(*NullTime)(&user.CreatedAt),
 			(*NullTime)(&user.UpdatedAt),
+			(*NullTime)(&user.SessionsRevokedAt),
 			&n,
 		); err != nil {
 			return nil, 0, err
@@ -235,12 +259,11 @@ func createUser(ctx context.Context, tx *Tx, user *pa.User) error {
 	}
 
 	// generate a random api-key.
-	buf := make([]byte, 32)
-	if _, err := rand.Read(buf); err != nil {
+	apiKey, err := generateAPIKey()
+	if err != nil {
 		return err
 	}
-	// encode rand bytes.
-	user.APIKey = base64.StdEncoding.EncodeToString(buf)
+	user.APIKey = apiKey
 
 	result, err := tx.ExecContext(ctx, `
 		INSERT INTO users (
@@ -335,11 +358,36 @@ func deleteUser(ctx context.Context, tx *Tx, id int) error {
 		return pa.Errorf(pa.EUNAUTHORIZED, "user not authorized")
 	}
 
-	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id); err != nil {
+	if err := recordErasureAudit(ctx, tx, pa.AuditActionDelete, id); err != nil {
 		return err
 	}
 
-	return nil
+	_, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	return err
+}
+
+func anonymizeUser(ctx context.Context, tx *Tx, id int) error {
+	user, err := findUserByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if pa.UserIDFromContext(ctx) != user.ID {
+		return pa.Errorf(pa.EUNAUTHORIZED, "user not authorized")
+	}
+
+	// the comments are still the user's, their audit entries get erased too.
+	if err := recordErasureAudit(ctx, tx, pa.AuditActionAnonymize, id); err != nil {
+		return err
+	}
+
+	// hand the comments over to the tombstone before the delete cascades them away.
+	if _, err := tx.ExecContext(ctx, `UPDATE comments SET user_id = ? WHERE user_id = ?`, pa.DeletedUserID, id); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	return err
 }
 
 func attachAuthtoUser(ctx context.Context, tx *Tx, user *pa.User) (err error) {
@@ -353,3 +401,13 @@ func attachAuthtoUser(ctx context.Context, tx *Tx, user *pa.User) (err error) {
 
 	return nil
 }
+
+// generateAPIKey returns a new random api key.
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	// encode rand bytes.
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
@@ -10,7 +10,7 @@ import (
 
 // TODO: write test
 
-func MustCreateUser(t *testing.T, db *sqlite.DB, ctx context.Context, user *pa.User) context.Context {
+func MustCreateUser(t testing.TB, db *sqlite.DB, ctx context.Context, user *pa.User) context.Context {
 	t.Helper()
 	if err := sqlite.NewUserService(db).CreateUser(ctx, user); err != nil {
 		t.Fatal(err)
@@ -15,16 +15,36 @@ type SubBlog struct {
 	// the id of the blog under which the sub blog is.
 	BlogID int `json:"blogID"`
 
+	// the position of the sub blog inside the blog series, starting at 1.
+	// left at 0 on creation the sub blog gets appended at the end of the series.
+	Position int `json:"position"`
+
+	// the neighbouring sub blogs in the series, nil if the sub blog is the first / last one.
+	Prev *SubBlogSummary `json:"prev"`
+	Next *SubBlogSummary `json:"next"`
+
+	// the human-readable identifier of the sub blog, generated from the title.
+	Slug string `json:"slug"`
+
 	// the descriptive fields of the sub blog.
 	Title    string     `json:"title"`
 	Content  string     `json:"body"`
 	Comments []*Comment `json:"comments"`
+	Media    []*Media   `json:"media"` // media from the media library used in the content.
 
 	// timestamps.
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// SubBlogSummary represents a light weight sub blog used for navigating a blog series.
+type SubBlogSummary struct {
+	ID       int    `json:"id"`
+	Slug     string `json:"slug"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+}
+
 // Validate performs basic validation on the sub blog.
 // returns EINVALID if any error is found.
 func (s *SubBlog) Validate() error {
@@ -47,20 +67,31 @@ type SubBlogService interface {
 	// returns ENOTFOUND if the sub blog doesent exist.
 	FindSubBlogByID(ctx context.Context, id int) (*SubBlog, error)
 
+	// FindSubBlogBySlug returns a sub blog based on the slug. Slugs which the sub blog had before being
+	// renamed still resolve to the sub blog, the current slug can be found under SubBlog.Slug.
+	// returns ENOTFOUND if the sub blog doesent exist.
+	FindSubBlogBySlug(ctx context.Context, slug string) (*SubBlog, error)
+
 	// FindSubBlogs returns a range of sub blogs and the length of the range. If filter
 	// is specified FindSubBlogs will apply the filter to return set response.
+	// The comments are only loaded if included by the filter.
+	// returns EINVALID if the query spec of the filter is invalid.
 	FindSubBlogs(ctx context.Context, filter SubBlogFilter) ([]*SubBlog, int, error)
 
-	// CreateSubBlog creates a sub blog.
+	// CreateSubBlog creates a sub blog and generates a unique slug from its title.
+	// The sub blog is inserted at SubBlog.Position shifting the following sub blogs or appended
+	// at the end of the blog series if no position is set.
 	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	CreateSubBlog(ctx context.Context, subBlog *SubBlog) error
 
-	// UpdateSubBlog updates a sub blog based on the update field.
+	// UpdateSubBlog updates a sub blog based on the update field. A title change generates a new slug,
+	// the old slug is kept in the slug history.
 	// returns ENOTFOUND if sub blog doesent exist.
 	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	UpdateSubBlog(ctx context.Context, id int, update SubBlogUpdate) (*SubBlog, error)
 
-	// DeleteSubBlog permanently deletes a sub blog.
+	// DeleteSubBlog moves a sub blog to the trash, closing the gap in the blog series. Its comments
+	// are hidden along it until the sub blog is restored or purged (see TrashService).
 	// returns ENOTFOUND if sub blog doesent exist.
 	// returns EUNAUTHORIZED if used by anyone other then the adim user.
 	DeleteSubBlog(ctx context.Context, id int) error
@@ -69,13 +100,23 @@ type SubBlogService interface {
 // SubBlogFilter represents a filter used by FindSubBlogs to filter the response.
 type SubBlogFilter struct {
 	// fields to filter on.
-	ID     *int    `json:"id"`
-	Title  *string `json:"title"`
-	BlogID *int    `json:"blogID"`
+	ID            *int    `json:"id"`
+	Title         *string `json:"title"`
+	TitleContains *string `json:"titleContains"`
+	Slug          *string `json:"slug"`
+	BlogID        *int    `json:"blogID"`
+
+	// ordering and creation time range, sub blogs can be sorted by "id", "title", "position",
+	// "createdAt" and "updatedAt".
+	QuerySpec
+
+	// related objects to load along the sub blogs, the navigation and media are always loaded.
+	IncludeComments bool `json:"includeComments"`
 
 	// restrictions on the result set, used for pagination and set limits.
-	Offset int `json:"offset"`
-	Limit  int `json:"limit"`
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
 }
 
 // SubBlogUpdate represents an update used by UpdateSubBlog to update a sub blog.
@@ -1,6 +1,9 @@
 package pa
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Subscription represents a subscription handeled by the event handler on an event / topic.
 type Subscription struct {
@@ -18,6 +21,9 @@ type Subscription struct {
 	// by the topic, for example a Topic of type EventTopicNewBlog will come with a payload of type
 	// BlogPayload -> ./event.go
 	Payload Payload
+
+	// timestamp.
+	CreatedAt time.Time
 }
 
 // SubscriptionService represents a service which manages subscriptions in the system.
@@ -47,6 +53,7 @@ type SubscriptionFilter struct {
 	Payload Payload `json:"payload"`
 
 	// restrictions on the result set, used for pagination and set limits.
-	Offset int `json:"offset"`
-	Limit  int `json:"limit"`
+	Cursor *Cursor `json:"-"` // keyset pagination, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
 }
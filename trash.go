@@ -0,0 +1,57 @@
+package pa
+
+import (
+	"context"
+	"time"
+)
+
+// types of the objects found in the trash.
+const (
+	TrashTypeBlog    = "blog"
+	TrashTypeSubBlog = "subBlog"
+	TrashTypeComment = "comment"
+)
+
+// TrashItem represents a soft deleted blog, sub blog or comment. Deleted objects are hidden from every
+// find call until restored and get permanently deleted once purged.
+type TrashItem struct {
+	Type string `json:"type"` // TrashTypeBlog, TrashTypeSubBlog or TrashTypeComment.
+	ID   int    `json:"id"`
+
+	// the title of blogs and sub blogs, the content of comments.
+	Title string `json:"title"`
+
+	// timestamp.
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// TrashFilter represents a filter used by FindTrash to filter the response.
+type TrashFilter struct {
+	// fields to filter on.
+	Type *string `json:"type"`
+
+	// restrictions on the result set, used for pagination and set limits.
+	Cursor *Cursor `json:"-"` // keyset pagination over the deletion time, takes over the offset.
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+}
+
+// TrashService represents a service which manages the soft deleted blogs, sub blogs and comments.
+type TrashService interface {
+	// FindTrash returns a range of deleted objects, latest deleted first, and the length of the range.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	FindTrash(ctx context.Context, filter TrashFilter) ([]*TrashItem, int, error)
+
+	// Restore restores the deleted object of type typ with the id, restored sub blogs go back to
+	// their position in the blog series.
+	// returns ENOTFOUND if the object isnt in the trash.
+	// returns ECONFLICT if the parent of the object is in the trash, the parent has to be restored first.
+	// returns EINVALID if typ is unknown.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	Restore(ctx context.Context, typ string, id int) error
+
+	// Purge permanently deletes the objects deleted before t along everything underneath them and
+	// returns the number of purged objects.
+	// returns EUNAUTHORIZED if used by anyone other then the admin user.
+	Purge(ctx context.Context, t time.Time) (int, error)
+}
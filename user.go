@@ -5,6 +5,23 @@ import (
 	"time"
 )
 
+// erasure modes of an user account.
+const (
+	// ErasureModeDelete permanently deletes the user along all his assosiacions.
+	ErasureModeDelete = "delete"
+
+	// ErasureModeAnonymize permanently deletes the user but keeps his comments, attributed to the
+	// deleted user tombstone.
+	ErasureModeAnonymize = "anonymize"
+)
+
+// deleted user tombstone, the user owning the comments of anonymized users. The tombstone is created
+// by the migrations and cant be authentificated as.
+const (
+	DeletedUserID   = -1
+	DeletedUserName = "deleted user"
+)
+
 // User represents an user in the system.
 type User struct {
 	// the pk of the user.
@@ -21,6 +38,9 @@ type User struct {
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 
+	// sessions created before SessionsRevokedAt are no longer valid, set by full bans.
+	SessionsRevokedAt time.Time `json:"-"`
+
 	// assosciated auths.
 	Auths []*Auth `json:"auths"`
 
@@ -73,6 +93,12 @@ type UserService interface {
 	// returns ENOTFOUND if user doesent exist.
 	// returns EUHATHORIZED if the caller isnt trying to delete himself.
 	DeleteUser(ctx context.Context, id int) error
+
+	// AnonymizeUser permanently deletes a user like DeleteUser but keeps his comments, the comments
+	// are attributed to the deleted user tombstone.
+	// returns ENOTFOUND if user doesent exist.
+	// returns EUHATHORIZED if the caller isnt trying to anonymize himself.
+	AnonymizeUser(ctx context.Context, id int) error
 }
 
 // UserFilter represents a filter used by FindUsers to filter the response.